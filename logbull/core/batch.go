@@ -0,0 +1,140 @@
+package core
+
+import (
+	"reflect"
+)
+
+func compactBatch(logs []LogEntry) LogBatch {
+	if len(logs) < 2 {
+		return LogBatch{Logs: logs}
+	}
+
+	shared := make(map[string]any, len(logs[0].Fields))
+	for key, value := range logs[0].Fields {
+		shared[key] = value
+	}
+
+	for _, log := range logs[1:] {
+		for key, value := range shared {
+			other, ok := log.Fields[key]
+			if !ok || !reflect.DeepEqual(value, other) {
+				delete(shared, key)
+			}
+		}
+		if len(shared) == 0 {
+			return LogBatch{Logs: logs}
+		}
+	}
+
+	compacted := make([]LogEntry, len(logs))
+	for i, log := range logs {
+		fields := make(map[string]any, len(log.Fields)-len(shared))
+		for key, value := range log.Fields {
+			if _, ok := shared[key]; !ok {
+				fields[key] = value
+			}
+		}
+		log.Fields = fields
+		compacted[i] = log
+	}
+
+	return LogBatch{Logs: compacted, Fields: shared}
+}
+
+// ColumnarBatch is the BatchFormatColumnar layout of a batch: the entry at
+// index i is made of the i-th level, message, timestamp and column values.
+// Each field key is sent once per batch instead of once per entry, and
+// fields with the same value in every entry are sent once in Fields.
+type ColumnarBatch struct {
+	// Format is "columnar", telling it apart from a LogBatch.
+	Format     string   `json:"format"`
+	Levels     []string `json:"levels"`
+	Messages   []string `json:"messages"`
+	Timestamps []string `json:"timestamps"`
+	// Columns holds one value per entry for every field key, null for
+	// entries without the field.
+	Columns map[string][]any `json:"columns,omitempty"`
+	Fields  map[string]any   `json:"fields,omitempty"`
+}
+
+func columnarBatch(logs []LogEntry) ColumnarBatch {
+	compacted := compactBatch(logs)
+
+	batch := ColumnarBatch{
+		Format:     string(BatchFormatColumnar),
+		Levels:     make([]string, len(logs)),
+		Messages:   make([]string, len(logs)),
+		Timestamps: make([]string, len(logs)),
+		Fields:     compacted.Fields,
+	}
+
+	for i, log := range compacted.Logs {
+		batch.Levels[i] = log.Level
+		batch.Messages[i] = log.Message
+		batch.Timestamps[i] = log.Timestamp
+
+		for key, value := range log.Fields {
+			column, ok := batch.Columns[key]
+			if !ok {
+				if batch.Columns == nil {
+					batch.Columns = make(map[string][]any)
+				}
+				column = make([]any, len(logs))
+				batch.Columns[key] = column
+			}
+			column[i] = value
+		}
+	}
+
+	return batch
+}
+
+// Logs returns the entries of the batch. Null column values are left out,
+// since they cannot be told apart from missing fields.
+func (b ColumnarBatch) Logs() []LogEntry {
+	logs := make([]LogEntry, len(b.Levels))
+	for i := range logs {
+		fields := make(map[string]any, len(b.Fields)+len(b.Columns))
+		for key, value := range b.Fields {
+			fields[key] = value
+		}
+		for key, column := range b.Columns {
+			if i < len(column) && column[i] != nil {
+				fields[key] = column[i]
+			}
+		}
+
+		logs[i] = LogEntry{Level: b.Levels[i], Fields: fields}
+		if i < len(b.Messages) {
+			logs[i].Message = b.Messages[i]
+		}
+		if i < len(b.Timestamps) {
+			logs[i].Timestamp = b.Timestamps[i]
+		}
+	}
+	return logs
+}
+
+// entryOverhead approximates the JSON keys and punctuation of an encoded
+// entry, and fieldOverhead those of a field.
+const (
+	entryOverhead = 64
+	fieldOverhead = 6
+)
+
+// estimateEntrySize cheaply approximates the encoded size of entry, without
+// marshaling it. Non-string field values are counted as 16 bytes.
+func estimateEntrySize(entry LogEntry) int64 {
+	size := entryOverhead + len(entry.Level) + len(entry.Message) + len(entry.Timestamp)
+
+	for key, value := range entry.Fields {
+		size += fieldOverhead + len(key)
+		if str, ok := value.(string); ok {
+			size += len(str)
+		} else {
+			size += 16
+		}
+	}
+
+	return int64(size)
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCompactBatch(t *testing.T) {
+	t.Run("hoists shared fields", func(t *testing.T) {
+		logs := []LogEntry{
+			{Message: "a", Fields: map[string]any{"service": "api", "request_id": "r1", "step": 1}},
+			{Message: "b", Fields: map[string]any{"service": "api", "request_id": "r1", "step": 2}},
+		}
+
+		batch := compactBatch(logs)
+
+		if len(batch.Fields) != 2 || batch.Fields["service"] != "api" || batch.Fields["request_id"] != "r1" {
+			t.Errorf("compactBatch() fields = %v", batch.Fields)
+		}
+		for i, log := range batch.Logs {
+			if len(log.Fields) != 1 || log.Fields["step"] != i+1 {
+				t.Errorf("compactBatch() entry %d fields = %v", i, log.Fields)
+			}
+		}
+		if len(logs[0].Fields) != 3 {
+			t.Error("compactBatch() must not modify the original entries")
+		}
+	})
+
+	t.Run("no shared fields", func(t *testing.T) {
+		logs := []LogEntry{
+			{Message: "a", Fields: map[string]any{"service": "api"}},
+			{Message: "b", Fields: map[string]any{"service": "worker"}},
+		}
+
+		batch := compactBatch(logs)
+
+		if batch.Fields != nil {
+			t.Errorf("compactBatch() fields = %v, want nil", batch.Fields)
+		}
+		if batch.Logs[1].Fields["service"] != "worker" {
+			t.Error("compactBatch() changed entries without shared fields")
+		}
+	})
+
+	t.Run("single entry", func(t *testing.T) {
+		logs := []LogEntry{{Message: "a", Fields: map[string]any{"service": "api"}}}
+
+		batch := compactBatch(logs)
+
+		if batch.Fields != nil || batch.Logs[0].Fields["service"] != "api" {
+			t.Errorf("compactBatch() should not compact a single entry, got %+v", batch)
+		}
+	})
+}
+
+func TestColumnarBatch(t *testing.T) {
+	logs := make([]LogEntry, 50)
+	for i := range logs {
+		logs[i] = LogEntry{
+			Level:     "INFO",
+			Message:   fmt.Sprintf("request %d handled", i),
+			Timestamp: GenerateUniqueTimestamp(),
+			Fields: map[string]any{
+				"service":          "checkout",
+				"request_id":       fmt.Sprintf("req-%d", i),
+				"http_status_code": 200,
+				"duration_ms":      float64(i),
+			},
+		}
+	}
+	logs[7].Fields["error"] = "timeout"
+
+	batch := columnarBatch(logs)
+	if len(batch.Fields) != 2 || batch.Fields["service"] != "checkout" || batch.Fields["http_status_code"] != 200 {
+		t.Errorf("columnarBatch() fields = %v, want the shared ones", batch.Fields)
+	}
+	if len(batch.Columns) != 3 || batch.Columns["error"][7] != "timeout" || batch.Columns["error"][8] != nil {
+		t.Errorf("columnarBatch() has %d columns, error column %v", len(batch.Columns), batch.Columns["error"])
+	}
+
+	rows, _ := json.Marshal(LogBatch{Logs: logs})
+	columnar, _ := json.Marshal(batch)
+	if len(columnar)*3 > len(rows)*2 {
+		t.Errorf("Columnar batch is %d bytes, rows %d, want it at least a third smaller", len(columnar), len(rows))
+	}
+
+	var decoded ColumnarBatch
+	if err := json.Unmarshal(columnar, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i, log := range decoded.Logs() {
+		want := logs[i]
+		if log.Message != want.Message || log.Timestamp != want.Timestamp || len(log.Fields) != len(want.Fields) ||
+			log.Fields["request_id"] != want.Fields["request_id"] || log.Fields["service"] != "checkout" {
+			t.Errorf("Logs()[%d] = %+v, want %+v", i, log, want)
+		}
+	}
+}
+
+func TestSender_ColumnarBatches(t *testing.T) {
+	for _, formats := range []string{`["columnar"]`, `[]`} {
+		t.Run(formats, func(t *testing.T) {
+			var mu sync.Mutex
+			var body map[string]any
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == versionPath {
+					fmt.Fprintf(w, `{"version":"2.2.0","batch_formats":%s}`, formats)
+					return
+				}
+				data, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				json.Unmarshal(data, &body)
+				mu.Unlock()
+				w.Write([]byte(`{"accepted":2}`))
+			}))
+			defer server.Close()
+
+			sender, err := NewSender(&Config{
+				ProjectID:             "12345678-1234-1234-1234-123456789012",
+				Host:                  server.URL,
+				BatchFormat:           BatchFormatColumnar,
+				NegotiateCapabilities: true,
+			})
+			if err != nil {
+				t.Fatalf("NewSender() error = %v", err)
+			}
+			defer sender.Shutdown()
+			waitForCapabilities(t, sender)
+
+			sender.AddLog(LogEntry{Level: "INFO", Message: "a", Timestamp: GenerateUniqueTimestamp(), Fields: map[string]any{"n": 1}})
+			sender.AddLog(LogEntry{Level: "INFO", Message: "b", Timestamp: GenerateUniqueTimestamp(), Fields: map[string]any{"n": 2}})
+			if err := sender.FlushSync(context.Background()); err != nil {
+				t.Fatalf("FlushSync() error = %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			_, columnar := body["columns"]
+			if want := formats != `[]`; columnar != want || (body["logs"] != nil) == want {
+				t.Errorf("Body = %v, want columnar %v", body, want)
+			}
+		})
+	}
+
+	if _, err := NewSender(&Config{Transport: &captureTransport{}, BatchFormat: "parquet"}); err == nil {
+		t.Error("NewSender() expected error for an unknown BatchFormat")
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DropRateField and RejectionRateField carry, on the CRITICAL entry
+	// reporting an exceeded ErrorBudget, the window's rates.
+	DropRateField      = "drop_rate"
+	RejectionRateField = "rejection_rate"
+
+	defaultBudgetWindow = time.Minute
+)
+
+// ErrorBudget raises an alert when a sender loses more logs over a window
+// than acceptable, so silent log loss becomes an actionable signal.
+type ErrorBudget struct {
+	// Window is how long the rates are measured over (default: one minute).
+	Window time.Duration
+	// MaxDropRate is the largest acceptable share, between 0 and 1, of the
+	// logs logged in a window that were dropped or expired (Stats.DroppedLogs
+	// and ExpiredLogs). Zero disables the check.
+	MaxDropRate float64
+	// MaxRejectionRate is the largest acceptable share of the logs sent in a
+	// window that the server rejected. Zero disables the check.
+	MaxRejectionRate float64
+	// OnExceeded is called from the batch loop, so it must not block. When
+	// nil, a CRITICAL entry describing the alert is logged instead.
+	OnExceeded func(alert BudgetAlert)
+}
+
+// BudgetAlert describes a window in which an ErrorBudget rate was exceeded.
+type BudgetAlert struct {
+	Window time.Duration
+	// LoggedLogs counts logs enqueued or dropped when logged, and LostLogs
+	// those dropped or expired.
+	LoggedLogs uint64
+	LostLogs   uint64
+	// SentLogs counts logs in batches the server answered, and RejectedLogs
+	// those it rejected.
+	SentLogs      uint64
+	RejectedLogs  uint64
+	DropRate      float64
+	RejectionRate float64
+}
+
+func (b *ErrorBudget) check() error {
+	if b == nil {
+		return nil
+	}
+	if b.Window < 0 {
+		return fmt.Errorf("negative Window %v", b.Window)
+	}
+	if b.MaxDropRate < 0 || b.MaxDropRate > 1 {
+		return fmt.Errorf("MaxDropRate must be between 0 and 1, got %v", b.MaxDropRate)
+	}
+	if b.MaxRejectionRate < 0 || b.MaxRejectionRate > 1 {
+		return fmt.Errorf("MaxRejectionRate must be between 0 and 1, got %v", b.MaxRejectionRate)
+	}
+	return nil
+}
+
+// budgetWatch measures Config.ErrorBudget in consecutive windows. Only the
+// batch processor uses it.
+type budgetWatch struct {
+	budget ErrorBudget
+	start  time.Time
+	// stats are the counters at the start of the window
+	stats Stats
+}
+
+func newBudgetWatch(budget ErrorBudget, now time.Time) *budgetWatch {
+	if budget.Window == 0 {
+		budget.Window = defaultBudgetWindow
+	}
+	return &budgetWatch{budget: budget, start: now}
+}
+
+// measure returns the alert for the window ending now, or false when the
+// window is still open or within budget.
+func (w *budgetWatch) measure(stats Stats, now time.Time) (BudgetAlert, bool) {
+	elapsed := now.Sub(w.start)
+	if elapsed < w.budget.Window {
+		return BudgetAlert{}, false
+	}
+
+	lost := stats.DroppedLogs + stats.ExpiredLogs - w.stats.DroppedLogs - w.stats.ExpiredLogs
+	alert := BudgetAlert{
+		Window:       elapsed,
+		LoggedLogs:   stats.EnqueuedLogs + stats.DroppedLogs - w.stats.EnqueuedLogs - w.stats.DroppedLogs,
+		LostLogs:     lost,
+		SentLogs:     stats.SentLogs - w.stats.SentLogs,
+		RejectedLogs: stats.RejectedLogs - w.stats.RejectedLogs,
+	}
+	w.start, w.stats = now, stats
+
+	if alert.LoggedLogs > 0 {
+		alert.DropRate = min(1, float64(alert.LostLogs)/float64(alert.LoggedLogs))
+	}
+	if alert.SentLogs > 0 {
+		alert.RejectionRate = float64(alert.RejectedLogs) / float64(alert.SentLogs)
+	}
+
+	exceeded := w.budget.MaxDropRate > 0 && alert.DropRate > w.budget.MaxDropRate ||
+		w.budget.MaxRejectionRate > 0 && alert.RejectionRate > w.budget.MaxRejectionRate
+	return alert, exceeded
+}
+
+// checkErrorBudget alerts when the window that just ended exceeded
+// Config.ErrorBudget.
+func (s *Sender) checkErrorBudget() {
+	if s.budget == nil {
+		return
+	}
+
+	now := s.config.clock().Now()
+	alert, exceeded := s.budget.measure(s.Stats(), now)
+	if !exceeded {
+		return
+	}
+
+	if s.config.ErrorBudget.OnExceeded != nil {
+		s.config.ErrorBudget.OnExceeded(alert)
+		return
+	}
+
+	_ = s.add(context.Background(), LogEntry{
+		Level: string(CRITICAL),
+		Message: fmt.Sprintf(
+			"log loss above error budget: %d of %d logs lost, %d of %d rejected in %v",
+			alert.LostLogs, alert.LoggedLogs, alert.RejectedLogs, alert.SentLogs, alert.Window.Round(time.Second),
+		),
+		Timestamp: FormatTimestamp(now),
+		Fields: map[string]any{
+			DropRateField:      alert.DropRate,
+			RejectionRateField: alert.RejectionRate,
+		},
+	}, true)
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudgetWatch_Measure(t *testing.T) {
+	start := time.Now()
+	watch := newBudgetWatch(ErrorBudget{MaxDropRate: 0.1, MaxRejectionRate: 0.5}, start)
+
+	if _, exceeded := watch.measure(Stats{EnqueuedLogs: 10, DroppedLogs: 10}, start.Add(30*time.Second)); exceeded {
+		t.Error("measure() alerted before the window ended")
+	}
+
+	alert, exceeded := watch.measure(Stats{EnqueuedLogs: 90, DroppedLogs: 5, ExpiredLogs: 5, SentLogs: 80, RejectedLogs: 8}, start.Add(time.Minute))
+	if !exceeded {
+		t.Fatal("measure() did not alert on a 10.5% drop rate")
+	}
+	if alert.LoggedLogs != 95 || alert.LostLogs != 10 || alert.Window != time.Minute || alert.RejectionRate != 0.1 {
+		t.Errorf("Alert = %+v", alert)
+	}
+
+	// The next window only counts what happened since
+	alert, exceeded = watch.measure(Stats{EnqueuedLogs: 190, DroppedLogs: 6, ExpiredLogs: 5, SentLogs: 180, RejectedLogs: 8}, start.Add(2*time.Minute))
+	if exceeded || alert.LoggedLogs != 101 || alert.LostLogs != 1 {
+		t.Errorf("measure() = %+v, %v, want 1 of 101 lost within budget", alert, exceeded)
+	}
+
+	if _, exceeded := watch.measure(Stats{EnqueuedLogs: 190, DroppedLogs: 6, ExpiredLogs: 5, SentLogs: 190, RejectedLogs: 18}, start.Add(3*time.Minute)); !exceeded {
+		t.Error("measure() did not alert when every sent log was rejected")
+	}
+}
+
+func TestErrorBudget_Check(t *testing.T) {
+	for _, budget := range []*ErrorBudget{{Window: -time.Second}, {MaxDropRate: 1.5}, {MaxRejectionRate: -0.1}} {
+		if _, err := NewSender(&Config{Transport: &captureTransport{}, ErrorBudget: budget}); err == nil || !strings.Contains(err.Error(), "ErrorBudget") {
+			t.Errorf("NewSender(%+v) error = %v, want invalid ErrorBudget", budget, err)
+		}
+	}
+}
+
+// newRejectingServer rejects every entry, recording their messages.
+func newRejectingServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		response := LogBullResponse{Rejected: len(batch.Logs)}
+		mu.Lock()
+		for i, log := range batch.Logs {
+			messages = append(messages, log.Message)
+			response.Errors = append(response.Errors, RejectedLog{Index: i, Message: "invalid"})
+		}
+		mu.Unlock()
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func TestLogBullLogger_ErrorBudget(t *testing.T) {
+	server, _ := newRejectingServer(t)
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+	alerts := make(chan BudgetAlert, 1)
+
+	logger, err := NewLogger(Config{
+		ProjectID:     preflightProjectID,
+		Host:          server.URL,
+		ConsoleFormat: ConsoleDisabled,
+		Silent:        true,
+		Clock:         clock,
+		ErrorBudget: &ErrorBudget{
+			MaxRejectionRate: 0.5,
+			OnExceeded:       func(alert BudgetAlert) { alerts <- alert },
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("first", nil)
+	logger.Info("second", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	clock.tick(time.Minute)
+
+	select {
+	case alert := <-alerts:
+		if alert.SentLogs != 2 || alert.RejectedLogs != 2 || alert.RejectionRate != 1 {
+			t.Errorf("Alert = %+v", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnExceeded was not called")
+	}
+}
+
+func TestLogBullLogger_ErrorBudgetEntry(t *testing.T) {
+	server, messages := newRejectingServer(t)
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+
+	logger, err := NewLogger(Config{
+		ProjectID:     preflightProjectID,
+		Host:          server.URL,
+		ConsoleFormat: ConsoleDisabled,
+		Silent:        true,
+		Clock:         clock,
+		ErrorBudget:   &ErrorBudget{MaxRejectionRate: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("first", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	clock.tick(time.Minute)
+	// Returns once the tick that queued the alert was handled
+	clock.tick(time.Second)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	got := messages()
+	if len(got) != 2 || !strings.HasPrefix(got[1], "log loss above error budget: 0 of 1 logs lost, 1 of 1 rejected") {
+		t.Errorf("Messages = %q, want the CRITICAL alert", got)
+	}
+}
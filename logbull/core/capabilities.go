@@ -0,0 +1,183 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// versionPath is the LogBull endpoint describing the server's API.
+const versionPath = "/api/v1/system/version"
+
+// ServerCapabilities is what a LogBull server reports about its API. Servers
+// predating the version endpoint report the zero value: JSON batches without
+// compression.
+type ServerCapabilities struct {
+	// Version is the server release, e.g. "1.4.0".
+	Version string `json:"version"`
+	// MaxBatchBytes is the largest request body the server accepts, zero
+	// when it does not say.
+	MaxBatchBytes int `json:"max_batch_bytes"`
+	// ContentTypes lists the batch encodings accepted besides JSON, e.g.
+	// "application/msgpack".
+	ContentTypes []string `json:"content_types"`
+	// Compression lists the accepted request Content-Encodings, e.g. "gzip".
+	Compression []string `json:"compression"`
+	// BatchFormats lists the accepted batch layouts besides rows, e.g.
+	// "columnar".
+	BatchFormats []string `json:"batch_formats"`
+}
+
+func (c ServerCapabilities) acceptsContentType(contentType string) bool {
+	if contentType == JSONEncoder.ContentType() {
+		return true
+	}
+	return containsString(c.ContentTypes, contentType)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns what the server reported when NegotiateCapabilities
+// is set. It returns false until the probe has completed.
+func (s *Sender) Capabilities() (ServerCapabilities, bool) {
+	capabilities := s.capabilities.Load()
+	if capabilities == nil {
+		return ServerCapabilities{}, false
+	}
+	return *capabilities, true
+}
+
+// negotiates reports whether the sender probes the server's capabilities.
+// Only LogBull servers have the version endpoint.
+func (s *Sender) negotiates() bool {
+	return s.config.NegotiateCapabilities && s.config.Transport == nil && s.config.Protocol != ProtocolOTLP
+}
+
+// negotiate probes the server in the background and adapts the sender to its
+// answer. Until it completes, batches are sent as if the server had no
+// version endpoint.
+func (s *Sender) negotiate() {
+	if !s.negotiates() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-s.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		capabilities, err := s.probeCapabilities(ctx)
+		if err != nil {
+			s.config.reportError(fmt.Errorf("capability probe failed, assuming an older server: %w", err), map[string]any{"operation": "negotiate"})
+			return
+		}
+		s.capabilities.Store(&capabilities)
+
+		if s.config.Encoder != nil && !capabilities.acceptsContentType(s.config.Encoder.ContentType()) {
+			s.fallBackToJSON()
+		}
+	}()
+}
+
+func (s *Sender) probeCapabilities(ctx context.Context) (ServerCapabilities, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Host()+versionPath, nil)
+	if err != nil {
+		return ServerCapabilities{}, err
+	}
+
+	apiKey, err := s.apiKey()
+	if err != nil {
+		return ServerCapabilities{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "LogBull-Go-Client/1.0")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ServerCapabilities{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ServerCapabilities{}, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// Released before the version endpoint existed
+		return ServerCapabilities{}, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return ServerCapabilities{}, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var capabilities ServerCapabilities
+	if err := json.Unmarshal(body, &capabilities); err != nil {
+		return ServerCapabilities{}, fmt.Errorf("invalid version response: %w", err)
+	}
+	return capabilities, nil
+}
+
+// maxBatchBytes is the smaller of MaxBatchBytes and the server's limit, or
+// zero when neither is set.
+func (s *Sender) maxBatchBytes() int {
+	limit := s.config.MaxBatchBytes
+	if capabilities, ok := s.Capabilities(); ok && capabilities.MaxBatchBytes > 0 {
+		if limit <= 0 || capabilities.MaxBatchBytes < limit {
+			limit = capabilities.MaxBatchBytes
+		}
+	}
+	return limit
+}
+
+// sendsColumnar reports whether batches are sent as ColumnarBatch, which the
+// server has to have announced.
+func (s *Sender) sendsColumnar() bool {
+	if s.config.BatchFormat != BatchFormatColumnar || s.config.Protocol == ProtocolOTLP {
+		return false
+	}
+	if _, ok := s.encoder().(valueEncoder); !ok {
+		return false
+	}
+	capabilities, ok := s.Capabilities()
+	return ok && containsString(capabilities.BatchFormats, string(BatchFormatColumnar))
+}
+
+// gzipsRequests reports whether batch bodies are compressed, which the
+// server has to have announced.
+func (s *Sender) gzipsRequests() bool {
+	capabilities, ok := s.Capabilities()
+	return ok && s.config.Protocol != ProtocolOTLP && containsString(capabilities.Compression, "gzip")
+}
+
+func gzipBody(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
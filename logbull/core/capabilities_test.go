@@ -0,0 +1,160 @@
+package core
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForCapabilities polls until the background capability probe is done.
+func waitForCapabilities(t *testing.T, sender *Sender) ServerCapabilities {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if capabilities, ok := sender.Capabilities(); ok {
+			return capabilities
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Capability probe did not complete")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSender_NegotiateCapabilities(t *testing.T) {
+	var mu sync.Mutex
+	var contentType, contentEncoding string
+	var received LogBatch
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == versionPath {
+			w.Write([]byte(`{"version":"2.1.0","max_batch_bytes":4096,"content_types":["application/msgpack"],"compression":["gzip"]}`))
+			return
+		}
+
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			body = reader
+		}
+		data, _ := io.ReadAll(body)
+
+		mu.Lock()
+		contentType = r.Header.Get("Content-Type")
+		contentEncoding = r.Header.Get("Content-Encoding")
+		unmarshalMsgPack(data, &received)
+		mu.Unlock()
+
+		w.Write([]byte(`{"accepted":1}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID:             "12345678-1234-1234-1234-123456789012",
+		Host:                  server.URL,
+		Encoder:               MsgPackEncoder,
+		NegotiateCapabilities: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	capabilities := waitForCapabilities(t, sender)
+	if capabilities.Version != "2.1.0" {
+		t.Errorf("Version = %q, want 2.1.0", capabilities.Version)
+	}
+	if got := sender.maxBatchBytes(); got != 4096 {
+		t.Errorf("maxBatchBytes() = %d, want the server's 4096", got)
+	}
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "negotiated", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if contentType != "application/msgpack" || contentEncoding != "gzip" {
+		t.Errorf("Content-Type = %q, Content-Encoding = %q, want msgpack and gzip", contentType, contentEncoding)
+	}
+	if len(received.Logs) != 1 || received.Logs[0].Message != "negotiated" {
+		t.Errorf("Received batch = %+v", received)
+	}
+}
+
+func TestSender_NegotiateCapabilitiesLegacyServer(t *testing.T) {
+	var mu sync.Mutex
+	var contentType, contentEncoding string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == versionPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		contentType = r.Header.Get("Content-Type")
+		contentEncoding = r.Header.Get("Content-Encoding")
+		mu.Unlock()
+
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		w.Write([]byte(`{"accepted":1}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID:             "12345678-1234-1234-1234-123456789012",
+		Host:                  server.URL,
+		Encoder:               CBOREncoder,
+		MaxBatchBytes:         1 << 20,
+		NegotiateCapabilities: true,
+		Silent:                true,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	if capabilities := waitForCapabilities(t, sender); capabilities.Version != "" {
+		t.Errorf("Version = %q, want empty for a legacy server", capabilities.Version)
+	}
+	if got := sender.maxBatchBytes(); got != 1<<20 {
+		t.Errorf("maxBatchBytes() = %d, want MaxBatchBytes", got)
+	}
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "legacy", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if contentType != "application/json" || contentEncoding != "" {
+		t.Errorf("Content-Type = %q, Content-Encoding = %q, want plain JSON", contentType, contentEncoding)
+	}
+}
+
+func TestSender_CapabilitiesWithoutNegotiation(t *testing.T) {
+	sender, err := NewSender(&Config{Transport: &captureTransport{}, NegotiateCapabilities: true})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	if _, ok := sender.Capabilities(); ok {
+		t.Error("Capabilities() should not be probed with a custom Transport")
+	}
+}
@@ -0,0 +1,402 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvProjectID = "LOGBULL_PROJECT_ID"
+	EnvHost      = "LOGBULL_HOST"
+	EnvAPIKey    = "LOGBULL_API_KEY"
+	EnvLogLevel  = "LOGBULL_LOG_LEVEL"
+	EnvProxyURL  = "LOGBULL_PROXY_URL"
+
+	EnvServiceName = "LOGBULL_SERVICE_NAME"
+	EnvEnvironment = "LOGBULL_ENVIRONMENT"
+)
+
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		ProjectID: strings.TrimSpace(os.Getenv(EnvProjectID)),
+		Host:      strings.TrimSpace(os.Getenv(EnvHost)),
+		APIKey:    strings.TrimSpace(os.Getenv(EnvAPIKey)),
+		ProxyURL:  strings.TrimSpace(os.Getenv(EnvProxyURL)),
+
+		ServiceName: strings.TrimSpace(os.Getenv(EnvServiceName)),
+		Environment: strings.TrimSpace(os.Getenv(EnvEnvironment)),
+	}
+
+	if value := strings.TrimSpace(os.Getenv(EnvLogLevel)); value != "" {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s value '%s'", EnvLogLevel, value)
+		}
+		config.LogLevel = level
+	}
+
+	return config, nil
+}
+
+// fileConfig is the on-disk form of Config. Durations are strings such as
+// "720h" and unknown keys are rejected to catch typos.
+type fileConfig struct {
+	ProjectID      string   `json:"project_id" yaml:"project_id"`
+	AuditProjectID string   `json:"audit_project_id" yaml:"audit_project_id"`
+	Host           string   `json:"host" yaml:"host"`
+	Hosts          []string `json:"hosts" yaml:"hosts"`
+	APIKey         string   `json:"api_key" yaml:"api_key"`
+	LogLevel       string   `json:"log_level" yaml:"log_level"`
+	Protocol       string   `json:"protocol" yaml:"protocol"`
+	Encoder        string   `json:"encoder" yaml:"encoder"`
+	BatchFormat    string   `json:"batch_format" yaml:"batch_format"`
+	ProxyURL       string   `json:"proxy_url" yaml:"proxy_url"`
+
+	NegotiateCapabilities bool `json:"negotiate_capabilities" yaml:"negotiate_capabilities"`
+
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout     string `json:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	KeepAlive           string `json:"keep_alive" yaml:"keep_alive"`
+	DisableHTTP2        bool   `json:"disable_http2" yaml:"disable_http2"`
+
+	OverflowPolicy      string `json:"overflow_policy" yaml:"overflow_policy"`
+	BlockTimeout        string `json:"block_timeout" yaml:"block_timeout"`
+	HedgeAfter          string `json:"hedge_after" yaml:"hedge_after"`
+	ShutdownTimeout     string `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	ShutdownOnGC        bool   `json:"shutdown_on_gc" yaml:"shutdown_on_gc"`
+	DedupWindow         string `json:"dedup_window" yaml:"dedup_window"`
+	MaxLogAge           string `json:"max_log_age" yaml:"max_log_age"`
+	ImmediateFlushLevel string `json:"immediate_flush_level" yaml:"immediate_flush_level"`
+
+	TimestampFormat   string `json:"timestamp_format" yaml:"timestamp_format"`
+	TimestampTimezone string `json:"timestamp_timezone" yaml:"timestamp_timezone"`
+
+	ConsoleFormat     string `json:"console_format" yaml:"console_format"`
+	ConsoleColor      bool   `json:"console_color" yaml:"console_color"`
+	ConsoleTimeFormat string `json:"console_time_format" yaml:"console_time_format"`
+	ConsoleLoggerName bool   `json:"console_logger_name" yaml:"console_logger_name"`
+
+	ServiceName     string `json:"service_name" yaml:"service_name"`
+	ServiceVersion  string `json:"service_version" yaml:"service_version"`
+	Environment     string `json:"environment" yaml:"environment"`
+	DisableMetadata bool   `json:"disable_metadata" yaml:"disable_metadata"`
+
+	DefaultFields map[string]any `json:"default_fields" yaml:"default_fields"`
+
+	FlattenFields   bool `json:"flatten_fields" yaml:"flatten_fields"`
+	FlattenMaxDepth int  `json:"flatten_max_depth" yaml:"flatten_max_depth"`
+	FlattenMaxKeys  int  `json:"flatten_max_keys" yaml:"flatten_max_keys"`
+
+	FieldKeys fileFieldKeys `json:"field_keys" yaml:"field_keys"`
+
+	IncludeCaller           bool `json:"include_caller" yaml:"include_caller"`
+	EnableFingerprint       bool `json:"enable_fingerprint" yaml:"enable_fingerprint"`
+	DisableMessageTemplates bool `json:"disable_message_templates" yaml:"disable_message_templates"`
+
+	EnableSequence     bool   `json:"enable_sequence" yaml:"enable_sequence"`
+	StrictOrdering     bool   `json:"strict_ordering" yaml:"strict_ordering"`
+	TimestampStrategy  string `json:"timestamp_strategy" yaml:"timestamp_strategy"`
+	SourceID           string `json:"source_id" yaml:"source_id"`
+	CompactBatchFields bool   `json:"compact_batch_fields" yaml:"compact_batch_fields"`
+	MaxBatchBytes      int    `json:"max_batch_bytes" yaml:"max_batch_bytes"`
+
+	MaxFieldValueLength int `json:"max_field_value_length" yaml:"max_field_value_length"`
+	MaxEntryBytes       int `json:"max_entry_bytes" yaml:"max_entry_bytes"`
+
+	Schema      *fileSchema      `json:"schema" yaml:"schema"`
+	ErrorBudget *fileErrorBudget `json:"error_budget" yaml:"error_budget"`
+
+	RetentionByLevel map[string]string `json:"retention_by_level" yaml:"retention_by_level"`
+	RateLimitByLevel map[string]int    `json:"rate_limit_by_level" yaml:"rate_limit_by_level"`
+	RejectedLogsFile string            `json:"rejected_logs_file" yaml:"rejected_logs_file"`
+	WALDir           string            `json:"wal_dir" yaml:"wal_dir"`
+	Silent           bool              `json:"silent" yaml:"silent"`
+}
+
+type fileFieldKeys struct {
+	SnakeCase      bool `json:"snake_case" yaml:"snake_case"`
+	Lowercase      bool `json:"lowercase" yaml:"lowercase"`
+	ReplaceInvalid bool `json:"replace_invalid" yaml:"replace_invalid"`
+}
+
+type fileErrorBudget struct {
+	Window           string  `json:"window" yaml:"window"`
+	MaxDropRate      float64 `json:"max_drop_rate" yaml:"max_drop_rate"`
+	MaxRejectionRate float64 `json:"max_rejection_rate" yaml:"max_rejection_rate"`
+}
+
+type fileSchema struct {
+	Required []string          `json:"required" yaml:"required"`
+	Levels   []string          `json:"levels" yaml:"levels"`
+	Types    map[string]string `json:"types" yaml:"types"`
+}
+
+// ConfigFromFile loads a Config from a YAML (.yaml, .yml) or JSON (.json)
+// file. Options that cannot be expressed in a file, such as OnRejected or
+// ConsoleWriter, can be set on the returned Config.
+func ConfigFromFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file fileConfig
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	default:
+		return Config{}, fmt.Errorf("unsupported config file extension '%s', use .yaml, .yml or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return file.toConfig()
+}
+
+func (f fileConfig) toConfig() (Config, error) {
+	config := Config{
+		ProjectID:               strings.TrimSpace(f.ProjectID),
+		AuditProjectID:          strings.TrimSpace(f.AuditProjectID),
+		Host:                    strings.TrimSpace(f.Host),
+		Hosts:                   f.Hosts,
+		APIKey:                  strings.TrimSpace(f.APIKey),
+		ProxyURL:                strings.TrimSpace(f.ProxyURL),
+		MaxIdleConnsPerHost:     f.MaxIdleConnsPerHost,
+		DisableHTTP2:            f.DisableHTTP2,
+		ShutdownOnGC:            f.ShutdownOnGC,
+		NegotiateCapabilities:   f.NegotiateCapabilities,
+		Protocol:                Protocol(f.Protocol),
+		BatchFormat:             BatchFormat(f.BatchFormat),
+		TimestampFormat:         TimestampFormat(f.TimestampFormat),
+		OverflowPolicy:          OverflowPolicy(f.OverflowPolicy),
+		ConsoleFormat:           ConsoleFormat(f.ConsoleFormat),
+		ConsoleColor:            f.ConsoleColor,
+		ConsoleTimeFormat:       f.ConsoleTimeFormat,
+		ConsoleLoggerName:       f.ConsoleLoggerName,
+		ServiceName:             f.ServiceName,
+		ServiceVersion:          f.ServiceVersion,
+		Environment:             f.Environment,
+		DisableMetadata:         f.DisableMetadata,
+		DefaultFields:           f.DefaultFields,
+		FlattenFields:           f.FlattenFields,
+		FlattenMaxDepth:         f.FlattenMaxDepth,
+		FlattenMaxKeys:          f.FlattenMaxKeys,
+		FieldKeys:               FieldKeyPolicy{SnakeCase: f.FieldKeys.SnakeCase, Lowercase: f.FieldKeys.Lowercase, ReplaceInvalid: f.FieldKeys.ReplaceInvalid},
+		EnableSequence:          f.EnableSequence,
+		StrictOrdering:          f.StrictOrdering,
+		TimestampStrategy:       TimestampStrategy(f.TimestampStrategy),
+		IncludeCaller:           f.IncludeCaller,
+		EnableFingerprint:       f.EnableFingerprint,
+		DisableMessageTemplates: f.DisableMessageTemplates,
+		SourceID:                f.SourceID,
+		CompactBatchFields:      f.CompactBatchFields,
+		MaxBatchBytes:           f.MaxBatchBytes,
+		MaxFieldValueLength:     f.MaxFieldValueLength,
+		MaxEntryBytes:           f.MaxEntryBytes,
+		RejectedLogsFile:        f.RejectedLogsFile,
+		WALDir:                  f.WALDir,
+		Silent:                  f.Silent,
+	}
+
+	if f.LogLevel != "" {
+		level, err := ParseLevel(f.LogLevel)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid log_level value '%s'", f.LogLevel)
+		}
+		config.LogLevel = level
+	}
+
+	if f.ImmediateFlushLevel != "" {
+		level, err := ParseLevel(f.ImmediateFlushLevel)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid immediate_flush_level value '%s'", f.ImmediateFlushLevel)
+		}
+		config.ImmediateFlushLevel = level
+	}
+
+	switch config.TimestampStrategy {
+	case "", TimestampUnique, TimestampSequence:
+	default:
+		return Config{}, fmt.Errorf("invalid timestamp_strategy value '%s'", f.TimestampStrategy)
+	}
+
+	if !validTimestampFormat(config.TimestampFormat) {
+		return Config{}, fmt.Errorf("invalid timestamp_format value '%s'", f.TimestampFormat)
+	}
+
+	if f.TimestampTimezone != "" {
+		location, err := time.LoadLocation(f.TimestampTimezone)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid timestamp_timezone value: %w", err)
+		}
+		config.TimestampLocation = location
+	}
+
+	switch config.Protocol {
+	case "", ProtocolBatch, ProtocolNDJSON, ProtocolOTLP:
+	default:
+		return Config{}, fmt.Errorf("invalid protocol value '%s'", f.Protocol)
+	}
+
+	if f.Encoder != "" {
+		encoder, err := encoderByName(f.Encoder)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid encoder value '%s'", f.Encoder)
+		}
+		config.Encoder = encoder
+	}
+
+	switch config.BatchFormat {
+	case "", BatchFormatRows, BatchFormatColumnar:
+	default:
+		return Config{}, fmt.Errorf("invalid batch_format value '%s'", f.BatchFormat)
+	}
+
+	switch config.OverflowPolicy {
+	case "", OverflowDropNewest, OverflowDropOldest, OverflowBlock:
+	default:
+		return Config{}, fmt.Errorf("invalid overflow_policy value '%s'", f.OverflowPolicy)
+	}
+
+	if f.BlockTimeout != "" {
+		timeout, err := time.ParseDuration(f.BlockTimeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid block_timeout value: %w", err)
+		}
+		config.BlockTimeout = timeout
+	}
+
+	if f.HedgeAfter != "" {
+		after, err := time.ParseDuration(f.HedgeAfter)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid hedge_after value: %w", err)
+		}
+		config.HedgeAfter = after
+	}
+
+	if f.IdleConnTimeout != "" {
+		timeout, err := time.ParseDuration(f.IdleConnTimeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid idle_conn_timeout value: %w", err)
+		}
+		config.IdleConnTimeout = timeout
+	}
+
+	if f.KeepAlive != "" {
+		keepAlive, err := time.ParseDuration(f.KeepAlive)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid keep_alive value: %w", err)
+		}
+		config.KeepAlive = keepAlive
+	}
+
+	if f.ShutdownTimeout != "" {
+		timeout, err := time.ParseDuration(f.ShutdownTimeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid shutdown_timeout value: %w", err)
+		}
+		config.ShutdownTimeout = timeout
+	}
+
+	if f.DedupWindow != "" {
+		window, err := time.ParseDuration(f.DedupWindow)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid dedup_window value: %w", err)
+		}
+		config.DedupWindow = window
+	}
+
+	if f.MaxLogAge != "" {
+		age, err := time.ParseDuration(f.MaxLogAge)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid max_log_age value: %w", err)
+		}
+		config.MaxLogAge = age
+	}
+
+	switch config.ConsoleFormat {
+	case "", ConsoleText, ConsoleJSON, ConsoleDisabled:
+	default:
+		return Config{}, fmt.Errorf("invalid console_format value '%s'", f.ConsoleFormat)
+	}
+
+	if f.Schema != nil {
+		schema := &Schema{Required: f.Schema.Required}
+		for _, name := range f.Schema.Levels {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid schema level '%s'", name)
+			}
+			schema.Levels = append(schema.Levels, level)
+		}
+		if len(f.Schema.Types) > 0 {
+			schema.Types = make(map[string]FieldType, len(f.Schema.Types))
+			for key, name := range f.Schema.Types {
+				schema.Types[key] = FieldType(name)
+			}
+		}
+		if err := schema.check(); err != nil {
+			return Config{}, fmt.Errorf("invalid schema: %w", err)
+		}
+		config.Schema = schema
+	}
+
+	if f.ErrorBudget != nil {
+		budget := &ErrorBudget{MaxDropRate: f.ErrorBudget.MaxDropRate, MaxRejectionRate: f.ErrorBudget.MaxRejectionRate}
+		if f.ErrorBudget.Window != "" {
+			window, err := time.ParseDuration(f.ErrorBudget.Window)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid error_budget window value: %w", err)
+			}
+			budget.Window = window
+		}
+		if err := budget.check(); err != nil {
+			return Config{}, fmt.Errorf("invalid error_budget: %w", err)
+		}
+		config.ErrorBudget = budget
+	}
+
+	if len(f.RetentionByLevel) > 0 {
+		config.RetentionByLevel = make(map[LogLevel]time.Duration, len(f.RetentionByLevel))
+		for name, value := range f.RetentionByLevel {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid retention_by_level level '%s'", name)
+			}
+
+			retention, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid retention_by_level value for %s: %w", name, err)
+			}
+			config.RetentionByLevel[level] = retention
+		}
+	}
+
+	if len(f.RateLimitByLevel) > 0 {
+		config.RateLimitByLevel = make(map[LogLevel]int, len(f.RateLimitByLevel))
+		for name, limit := range f.RateLimitByLevel {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid rate_limit_by_level level '%s'", name)
+			}
+			config.RateLimitByLevel[level] = limit
+		}
+	}
+
+	return config, nil
+}
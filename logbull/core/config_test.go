@@ -0,0 +1,274 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("reads all variables", func(t *testing.T) {
+		t.Setenv(EnvProjectID, "12345678-1234-1234-1234-123456789012")
+		t.Setenv(EnvHost, " http://localhost:4005 ")
+		t.Setenv(EnvAPIKey, "test-api-key")
+		t.Setenv(EnvLogLevel, "warning")
+		t.Setenv(EnvServiceName, "checkout")
+		t.Setenv(EnvEnvironment, "production")
+		t.Setenv(EnvProxyURL, "http://proxy.internal:3128")
+
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv() error = %v", err)
+		}
+
+		if config.ProjectID != "12345678-1234-1234-1234-123456789012" {
+			t.Errorf("ProjectID = %q", config.ProjectID)
+		}
+		if config.Host != "http://localhost:4005" {
+			t.Errorf("Host = %q", config.Host)
+		}
+		if config.APIKey != "test-api-key" {
+			t.Errorf("APIKey = %q", config.APIKey)
+		}
+		if config.LogLevel != WARNING {
+			t.Errorf("LogLevel = %q, want WARNING", config.LogLevel)
+		}
+		if config.ServiceName != "checkout" || config.Environment != "production" {
+			t.Errorf("ServiceName = %q, Environment = %q", config.ServiceName, config.Environment)
+		}
+		if config.ProxyURL != "http://proxy.internal:3128" {
+			t.Errorf("ProxyURL = %q", config.ProxyURL)
+		}
+	})
+
+	t.Run("empty environment", func(t *testing.T) {
+		t.Setenv(EnvProjectID, "")
+		t.Setenv(EnvHost, "")
+		t.Setenv(EnvAPIKey, "")
+		t.Setenv(EnvLogLevel, "")
+
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv() error = %v", err)
+		}
+		if config.LogLevel != "" {
+			t.Errorf("LogLevel = %q, want empty", config.LogLevel)
+		}
+	})
+
+	t.Run("invalid log level", func(t *testing.T) {
+		t.Setenv(EnvLogLevel, "verbose")
+
+		if _, err := ConfigFromEnv(); err == nil {
+			t.Error("ConfigFromEnv() expected error for invalid log level")
+		}
+	})
+}
+
+func TestConfigFromFile(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		path := writeFile(t, "logbull.yaml", `
+project_id: 12345678-1234-1234-1234-123456789012
+audit_project_id: 87654321-4321-4321-4321-210987654321
+host: http://localhost:4005
+hosts: [http://localhost:4005, http://backup:4005]
+api_key: test-api-key
+log_level: warn
+protocol: ndjson
+batch_format: columnar
+negotiate_capabilities: true
+console_format: json
+service_name: checkout
+environment: production
+max_batch_bytes: 1048576
+enable_fingerprint: true
+max_field_value_length: 4096
+max_entry_bytes: 65536
+overflow_policy: block
+block_timeout: 2s
+hedge_after: 300ms
+shutdown_timeout: 30s
+shutdown_on_gc: true
+max_idle_conns_per_host: 50
+idle_conn_timeout: 2m
+keep_alive: 15s
+disable_http2: true
+dedup_window: 500ms
+max_log_age: 1h
+immediate_flush_level: err
+timestamp_format: epoch_millis
+timestamp_timezone: UTC
+retention_by_level:
+  debug: 168h
+  error: 8760h
+rate_limit_by_level:
+  debug: 100
+wal_dir: /var/lib/app/logbull-wal
+error_budget:
+  window: 5m
+  max_drop_rate: 0.01
+field_keys:
+  snake_case: true
+  replace_invalid: true
+schema:
+  required: [service, request_id]
+  levels: [info, error]
+  types:
+    duration_ms: number
+`)
+
+		config, err := ConfigFromFile(path)
+		if err != nil {
+			t.Fatalf("ConfigFromFile() error = %v", err)
+		}
+
+		if config.ProjectID != "12345678-1234-1234-1234-123456789012" || config.Host != "http://localhost:4005" {
+			t.Errorf("ProjectID = %q, Host = %q", config.ProjectID, config.Host)
+		}
+		if config.AuditProjectID != "87654321-4321-4321-4321-210987654321" {
+			t.Errorf("AuditProjectID = %q", config.AuditProjectID)
+		}
+		if config.LogLevel != WARNING {
+			t.Errorf("LogLevel = %q, want WARNING", config.LogLevel)
+		}
+		if !config.NegotiateCapabilities {
+			t.Error("NegotiateCapabilities = false, want true")
+		}
+		if config.BatchFormat != BatchFormatColumnar {
+			t.Errorf("BatchFormat = %q, want columnar", config.BatchFormat)
+		}
+		if config.Protocol != ProtocolNDJSON || config.ConsoleFormat != ConsoleJSON {
+			t.Errorf("Protocol = %q, ConsoleFormat = %q", config.Protocol, config.ConsoleFormat)
+		}
+		if config.ServiceName != "checkout" || config.Environment != "production" {
+			t.Errorf("ServiceName = %q, Environment = %q", config.ServiceName, config.Environment)
+		}
+		if config.MaxBatchBytes != 1048576 {
+			t.Errorf("MaxBatchBytes = %d", config.MaxBatchBytes)
+		}
+		if !config.EnableFingerprint {
+			t.Error("EnableFingerprint = false, want true")
+		}
+		if config.MaxFieldValueLength != 4096 || config.MaxEntryBytes != 65536 {
+			t.Errorf("MaxFieldValueLength = %d, MaxEntryBytes = %d", config.MaxFieldValueLength, config.MaxEntryBytes)
+		}
+		if config.ImmediateFlushLevel != ERROR {
+			t.Errorf("ImmediateFlushLevel = %q, want ERROR", config.ImmediateFlushLevel)
+		}
+		if config.TimestampFormat != TimestampEpochMillis || config.TimestampLocation != time.UTC {
+			t.Errorf("TimestampFormat = %q, TimestampLocation = %v", config.TimestampFormat, config.TimestampLocation)
+		}
+		if config.HedgeAfter != 300*time.Millisecond {
+			t.Errorf("HedgeAfter = %v", config.HedgeAfter)
+		}
+		if config.OverflowPolicy != OverflowBlock || config.BlockTimeout != 2*time.Second {
+			t.Errorf("OverflowPolicy = %q, BlockTimeout = %v", config.OverflowPolicy, config.BlockTimeout)
+		}
+		if config.MaxIdleConnsPerHost != 50 || config.IdleConnTimeout != 2*time.Minute || config.KeepAlive != 15*time.Second || !config.DisableHTTP2 {
+			t.Errorf("MaxIdleConnsPerHost = %d, IdleConnTimeout = %v, KeepAlive = %v, DisableHTTP2 = %v",
+				config.MaxIdleConnsPerHost, config.IdleConnTimeout, config.KeepAlive, config.DisableHTTP2)
+		}
+		if config.ShutdownTimeout != 30*time.Second || config.DedupWindow != 500*time.Millisecond {
+			t.Errorf("ShutdownTimeout = %v, DedupWindow = %v", config.ShutdownTimeout, config.DedupWindow)
+		}
+		if !config.ShutdownOnGC {
+			t.Error("ShutdownOnGC = false")
+		}
+		if config.MaxLogAge != time.Hour {
+			t.Errorf("MaxLogAge = %v", config.MaxLogAge)
+		}
+		if budget := config.ErrorBudget; budget == nil || budget.Window != 5*time.Minute || budget.MaxDropRate != 0.01 {
+			t.Errorf("ErrorBudget = %+v", config.ErrorBudget)
+		}
+		if config.FieldKeys != (FieldKeyPolicy{SnakeCase: true, ReplaceInvalid: true}) {
+			t.Errorf("FieldKeys = %+v", config.FieldKeys)
+		}
+		if schema := config.Schema; schema == nil || len(schema.Required) != 2 || len(schema.Levels) != 2 ||
+			schema.Levels[1] != ERROR || schema.Types["duration_ms"] != FieldTypeNumber {
+			t.Errorf("Schema = %+v", config.Schema)
+		}
+		if len(config.RateLimitByLevel) != 1 || config.RateLimitByLevel[DEBUG] != 100 {
+			t.Errorf("RateLimitByLevel = %v", config.RateLimitByLevel)
+		}
+		if len(config.Hosts) != 2 || config.Hosts[1] != "http://backup:4005" {
+			t.Errorf("Hosts = %v", config.Hosts)
+		}
+		if config.WALDir != "/var/lib/app/logbull-wal" {
+			t.Errorf("WALDir = %q", config.WALDir)
+		}
+		if config.RetentionByLevel[DEBUG] != 7*24*time.Hour || config.RetentionByLevel[ERROR] != 365*24*time.Hour {
+			t.Errorf("RetentionByLevel = %v", config.RetentionByLevel)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := writeFile(t, "logbull.json", `{"project_id": "p", "host": "h", "enable_sequence": true, "strict_ordering": true, "silent": true, "encoder": "cbor"}`)
+
+		config, err := ConfigFromFile(path)
+		if err != nil {
+			t.Fatalf("ConfigFromFile() error = %v", err)
+		}
+		if config.ProjectID != "p" || config.Host != "h" || !config.EnableSequence || !config.StrictOrdering || !config.Silent || config.Encoder != CBOREncoder {
+			t.Errorf("ConfigFromFile() = %+v", config)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown yaml key", "logbull.yml", "project_idd: typo\n"},
+		{"unknown json key", "logbull.json", `{"hots": "typo"}`},
+		{"invalid log level", "logbull.yaml", "log_level: verbose\n"},
+		{"invalid protocol", "logbull.yaml", "protocol: grpc\n"},
+		{"invalid encoder", "logbull.yaml", "encoder: protobuf\n"},
+		{"invalid batch format", "logbull.yaml", "batch_format: parquet\n"},
+		{"invalid console format", "logbull.yaml", "console_format: xml\n"},
+		{"invalid overflow policy", "logbull.yaml", "overflow_policy: drop_all\n"},
+		{"invalid block timeout", "logbull.yaml", "block_timeout: forever\n"},
+		{"invalid hedge after", "logbull.yaml", "hedge_after: soon\n"},
+		{"invalid shutdown timeout", "logbull.yaml", "shutdown_timeout: soon\n"},
+		{"invalid dedup window", "logbull.yaml", "dedup_window: short\n"},
+		{"invalid max log age", "logbull.yaml", "max_log_age: forever\n"},
+		{"invalid idle conn timeout", "logbull.yaml", "idle_conn_timeout: 5\n"},
+		{"invalid keep alive", "logbull.yaml", "keep_alive: always\n"},
+		{"invalid immediate flush level", "logbull.yaml", "immediate_flush_level: urgent\n"},
+		{"invalid timestamp strategy", "logbull.yaml", "timestamp_strategy: random\n"},
+		{"invalid timestamp format", "logbull.yaml", "timestamp_format: unix\n"},
+		{"invalid timestamp timezone", "logbull.yaml", "timestamp_timezone: Mars/Olympus\n"},
+		{"invalid retention level", "logbull.yaml", "retention_by_level:\n  verbose: 1h\n"},
+		{"invalid retention duration", "logbull.yaml", "retention_by_level:\n  debug: a week\n"},
+		{"invalid rate limit level", "logbull.yaml", "rate_limit_by_level:\n  verbose: 10\n"},
+		{"invalid schema level", "logbull.yaml", "schema:\n  levels: [verbose]\n"},
+		{"invalid schema type", "logbull.yaml", "schema:\n  types:\n    id: uuid\n"},
+		{"invalid error budget window", "logbull.yaml", "error_budget:\n  window: hourly\n"},
+		{"invalid error budget rate", "logbull.yaml", "error_budget:\n  max_drop_rate: 5\n"},
+		{"unknown field key policy", "logbull.yaml", "field_keys:\n  camel_case: true\n"},
+		{"unsupported extension", "logbull.toml", "host = 'x'\n"},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ConfigFromFile(writeFile(t, tt.file, tt.content)); err == nil {
+				t.Error("ConfigFromFile() expected error")
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := ConfigFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("ConfigFromFile() expected error for missing file")
+		}
+	})
+}
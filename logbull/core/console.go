@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var levelColors = map[string]string{
+	"DEBUG":    "\033[90m",
+	"INFO":     "\033[36m",
+	"WARNING":  "\033[33m",
+	"ERROR":    "\033[31m",
+	"CRITICAL": "\033[1;31m",
+}
+
+const colorReset = "\033[0m"
+
+func (l *LogBullLogger) printToConsole(entry LogEntry) {
+	if l.config.ConsoleFormat == ConsoleDisabled {
+		return
+	}
+	printConsoleEntry(l.config, l.name, entry)
+}
+
+// printConsoleEntry writes entry in Config.ConsoleFormat, or as text when the
+// console is disabled. name is the LogBullLogger name, if any.
+func printConsoleEntry(config *Config, name string, entry LogEntry) {
+	var output string
+
+	if config.ConsoleFormat == ConsoleJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			config.reportError(fmt.Errorf("failed to marshal console entry: %w", err), map[string]any{"operation": "console"})
+			return
+		}
+		output = string(data)
+	} else {
+		output = formatConsoleText(config, name, entry)
+	}
+
+	fmt.Fprintln(consoleWriter(config, entry.Level), output)
+}
+
+func formatConsoleText(config *Config, name string, entry LogEntry) string {
+	timestamp := entry.Timestamp
+	if config.ConsoleTimeFormat != "" {
+		if t, err := time.Parse(timestampLayout, entry.Timestamp); err == nil {
+			timestamp = t.Format(config.ConsoleTimeFormat)
+		}
+	}
+
+	level := entry.Level
+	if config.ConsoleColor {
+		level = levelColors[level] + level + colorReset
+	}
+
+	output := fmt.Sprintf("[%s] [%s] %s", timestamp, level, entry.Message)
+
+	prefixName := config.ConsoleLoggerName && name != ""
+	if prefixName {
+		output = fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, level, name, entry.Message)
+	}
+
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			if prefixName && k == LoggerNameField && entry.Fields[k] == name {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]string, 0, len(keys))
+		for _, k := range keys {
+			fields = append(fields, fmt.Sprintf("%s=%v", k, entry.Fields[k]))
+		}
+		if len(fields) > 0 {
+			output += fmt.Sprintf(" (%s)", strings.Join(fields, ", "))
+		}
+	}
+
+	return output
+}
+
+func consoleWriter(config *Config, level string) io.Writer {
+	if config.ConsoleWriter != nil {
+		return config.ConsoleWriter
+	}
+
+	if level == "ERROR" || level == "CRITICAL" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"testing"
 )
 
@@ -164,3 +165,25 @@ func TestLogBullLogger_ConsoleOnlyLevelFiltering(t *testing.T) {
 
 	// Verify no panics occurred
 }
+
+func TestLogBullLogger_ConsoleOnlySetHost(t *testing.T) {
+	logger, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	if err := logger.SetHost("http://localhost:4005"); err == nil {
+		t.Error("SetHost() expected error in console-only mode")
+	}
+}
+
+func TestLogBullLogger_ConsoleOnlyPing(t *testing.T) {
+	logger, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	if err := logger.Ping(context.Background()); err == nil {
+		t.Error("Ping() expected error in console-only mode")
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLogBullLogger_ConsoleText(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(Config{ConsoleWriter: &buf})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Error("payment failed", map[string]any{"b": 2, "a": 1})
+
+	output := buf.String()
+	if !strings.Contains(output, "[ERROR] payment failed (a=1, b=2)") {
+		t.Errorf("console output = %q", output)
+	}
+}
+
+func TestLogBullLogger_ConsoleJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(Config{ConsoleFormat: ConsoleJSON, ConsoleWriter: &buf})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Info("user login", map[string]any{"user_id": "42"})
+
+	var entry LogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("console output is not JSON: %v (%q)", err, buf.String())
+	}
+	if entry.Level != "INFO" || entry.Message != "user login" || entry.Fields["user_id"] != "42" {
+		t.Errorf("console entry = %+v", entry)
+	}
+}
+
+func TestLogBullLogger_ConsoleDisabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(Config{ConsoleFormat: ConsoleDisabled, ConsoleWriter: &buf})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Critical("not printed", nil)
+
+	if buf.Len() != 0 {
+		t.Errorf("console output = %q, want empty", buf.String())
+	}
+}
+
+func TestLogBullLogger_ConsoleColorAndTimeFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(Config{
+		ConsoleColor:      true,
+		ConsoleTimeFormat: "15:04:05",
+		ConsoleWriter:     &buf,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Warning("disk almost full", nil)
+
+	output := buf.String()
+	if !strings.Contains(output, levelColors["WARNING"]+"WARNING"+colorReset) {
+		t.Errorf("console output = %q, want colored level", output)
+	}
+	if strings.Contains(output, "T") && strings.Contains(output, "Z]") {
+		t.Errorf("console output = %q, want custom time format", output)
+	}
+}
+
+func TestLogBullLogger_ConsoleLoggerName(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(Config{ConsoleWriter: &buf, ConsoleLoggerName: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Named("payments").Info("charged", map[string]any{"amount": 5})
+	logger.Named("payments").Info("no fields", nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("console output = %q, want 2 lines", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[INFO] [payments] charged (amount=5)") {
+		t.Errorf("console line = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[INFO] [payments] no fields") {
+		t.Errorf("console line = %q", lines[1])
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+// Fields LogBullLogger.WithCtx takes from a context.
+const (
+	RequestIDField = "request_id"
+	TraceIDField   = "trace_id"
+	SpanIDField    = "span_id"
+)
+
+type (
+	loggerContextKey    struct{}
+	fieldsContextKey    struct{}
+	requestIDContextKey struct{}
+)
+
+func ContextWithLogger(ctx context.Context, logger *LogBullLogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+func LoggerFromContext(ctx context.Context) *LogBullLogger {
+	logger, _ := ctx.Value(loggerContextKey{}).(*LogBullLogger)
+	return logger
+}
+
+// ContextWithFields returns a context carrying fields for LogBullLogger.WithCtx,
+// merged over fields already in ctx.
+func ContextWithFields(ctx context.Context, fields map[string]any) context.Context {
+	return context.WithValue(ctx, fieldsContextKey{}, formatting.MergeFields(FieldsFromContext(ctx), fields))
+}
+
+// FieldsFromContext returns the fields added with ContextWithFields. The map
+// must not be modified.
+func FieldsFromContext(ctx context.Context) map[string]any {
+	fields, _ := ctx.Value(fieldsContextKey{}).(map[string]any)
+	return fields
+}
+
+// ContextWithRequestID returns a context carrying the request ID that
+// LogBullLogger.WithCtx logs as RequestIDField. The HTTP middlewares set it.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// WithCtx returns a derived logger with the fields found in ctx: those added
+// with ContextWithFields, the request ID and the OpenTelemetry trace and span
+// IDs of a valid span. A context without any returns the receiver unchanged.
+func (l *LogBullLogger) WithCtx(ctx context.Context) *LogBullLogger {
+	fields := make(map[string]any)
+	for key, value := range FieldsFromContext(ctx) {
+		fields[key] = value
+	}
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields[RequestIDField] = requestID
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields[TraceIDField] = span.TraceID().String()
+		fields[SpanIDField] = span.SpanID().String()
+	}
+
+	if len(fields) == 0 {
+		return l
+	}
+	return l.WithFields(fields)
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextWithLogger(t *testing.T) {
+	logger, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	if got := LoggerFromContext(ctx); got != logger {
+		t.Errorf("LoggerFromContext() = %p, want %p", got, logger)
+	}
+}
+
+func TestLoggerFromContext_Missing(t *testing.T) {
+	if got := LoggerFromContext(context.Background()); got != nil {
+		t.Errorf("LoggerFromContext() = %v, want nil", got)
+	}
+}
+
+func TestContextWithFields(t *testing.T) {
+	ctx := ContextWithFields(context.Background(), map[string]any{"tenant": "a", "region": "eu"})
+	ctx = ContextWithFields(ctx, map[string]any{"tenant": "b"})
+
+	fields := FieldsFromContext(ctx)
+	if fields["tenant"] != "b" || fields["region"] != "eu" {
+		t.Errorf("FieldsFromContext() = %v", fields)
+	}
+	if FieldsFromContext(context.Background()) != nil {
+		t.Error("FieldsFromContext() of an empty context should be nil")
+	}
+}
+
+func TestLogBullLogger_WithCtx(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	if got := logger.WithCtx(context.Background()); got != logger {
+		t.Error("WithCtx() of an empty context should return the receiver")
+	}
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), span)
+	ctx = ContextWithRequestID(ctx, "req-1")
+	ctx = ContextWithFields(ctx, map[string]any{"tenant": "acme"})
+
+	logger.WithCtx(ctx).Info("handled", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	expected := map[string]any{
+		TraceIDField:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanIDField:    "00f067aa0ba902b7",
+		RequestIDField: "req-1",
+		"tenant":       "acme",
+	}
+	for key, value := range expected {
+		if entries[0].Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, entries[0].Fields[key], value)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultAPIKeyCacheTTL = 5 * time.Minute
+
+// apiKeyCache caches the result of Config.APIKeyProvider so the provider is
+// not called for every request.
+type apiKeyCache struct {
+	mu        sync.Mutex
+	key       string
+	fetchedAt time.Time
+}
+
+// apiKey returns the key to send in X-API-Key. When the provider fails, a
+// previously fetched key is reused until a fetch succeeds.
+func (s *Sender) apiKey() (string, error) {
+	provider := s.config.APIKeyProvider
+	if provider == nil {
+		return s.config.APIKey, nil
+	}
+
+	ttl := s.config.APIKeyCacheTTL
+	if ttl <= 0 {
+		ttl = defaultAPIKeyCacheTTL
+	}
+
+	cache := &s.apiKeyCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.fetchedAt.IsZero() && s.config.clock().Now().Sub(cache.fetchedAt) < ttl {
+		return cache.key, nil
+	}
+
+	key, err := provider()
+	if err != nil {
+		if cache.key != "" {
+			s.config.reportError(fmt.Errorf("API key provider failed, reusing previous key: %w", err), map[string]any{"operation": "api_key"})
+			return cache.key, nil
+		}
+		return "", fmt.Errorf("API key provider failed: %w", err)
+	}
+
+	cache.key = key
+	cache.fetchedAt = s.config.clock().Now()
+	return key, nil
+}
+
+// invalidateAPIKey forces the next request to consult the provider again,
+// e.g. after the server rejected a rotated-out key.
+func (s *Sender) invalidateAPIKey() {
+	if s.config.APIKeyProvider == nil {
+		return
+	}
+
+	s.apiKeyCache.mu.Lock()
+	s.apiKeyCache.fetchedAt = time.Time{}
+	s.apiKeyCache.mu.Unlock()
+}
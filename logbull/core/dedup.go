@@ -0,0 +1,139 @@
+package core
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// RepeatCountField carries, on a summary entry, how many identical entries
+// were collapsed into it.
+const RepeatCountField = "repeat_count"
+
+// maxDedupKeys bounds the distinct entries tracked at once; entries seen
+// while the table is full are not deduplicated.
+const maxDedupKeys = 10000
+
+type dedupWindow struct {
+	level   string
+	message string
+	fields  map[string]any
+	start   time.Time
+	// last is the timestamp of the latest duplicate
+	last    string
+	repeats int
+}
+
+// deduplicator collapses identical entries within a window. The first one is
+// sent as-is; later copies are counted and sent as one summary entry with
+// RepeatCountField when the window closes.
+type deduplicator struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[uint64]*dedupWindow
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window:  window,
+		windows: make(map[uint64]*dedupWindow),
+	}
+}
+
+// admit reports whether entry should be sent. A duplicate is counted instead.
+// When entry reopens a window that collapsed duplicates, summary is that
+// window's summary entry.
+func (d *deduplicator) admit(entry LogEntry, now time.Time) (admitted bool, summary *LogEntry) {
+	key, ok := dedupKey(entry)
+	if !ok {
+		return true, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, exists := d.windows[key]
+	if exists && now.Sub(w.start) < d.window {
+		w.repeats++
+		w.last = entry.Timestamp
+		return false, nil
+	}
+
+	if exists {
+		summary = w.summary()
+	} else if len(d.windows) >= maxDedupKeys {
+		return true, nil
+	}
+
+	fields := make(map[string]any, len(entry.Fields))
+	for k, v := range entry.Fields {
+		fields[k] = v
+	}
+	d.windows[key] = &dedupWindow{
+		level:   entry.Level,
+		message: entry.Message,
+		fields:  fields,
+		start:   now,
+	}
+
+	return true, summary
+}
+
+// expire closes the windows older than the window, or all of them when all
+// is set, and returns the summaries of those that collapsed duplicates.
+func (d *deduplicator) expire(now time.Time, all bool) []LogEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var summaries []LogEntry
+	for key, w := range d.windows {
+		if !all && now.Sub(w.start) < d.window {
+			continue
+		}
+		if summary := w.summary(); summary != nil {
+			summaries = append(summaries, *summary)
+		}
+		delete(d.windows, key)
+	}
+	return summaries
+}
+
+func (w *dedupWindow) summary() *LogEntry {
+	if w.repeats == 0 {
+		return nil
+	}
+
+	fields := make(map[string]any, len(w.fields)+1)
+	for k, v := range w.fields {
+		fields[k] = v
+	}
+	fields[RepeatCountField] = w.repeats
+
+	return &LogEntry{
+		Level:     w.level,
+		Message:   w.message,
+		Timestamp: w.last,
+		Fields:    fields,
+	}
+}
+
+func dedupKey(entry LogEntry) (uint64, bool) {
+	h := fnv.New64a()
+	h.Write([]byte(entry.Level))
+	h.Write([]byte{0})
+	h.Write([]byte(entry.Message))
+	h.Write([]byte{0})
+
+	// json.Marshal sorts map keys, so equal fields hash equally
+	if len(entry.Fields) > 0 {
+		data, err := json.Marshal(entry.Fields)
+		if err != nil {
+			return 0, false
+		}
+		h.Write(data)
+	}
+
+	return h.Sum64(), true
+}
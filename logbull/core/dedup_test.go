@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduplicator(t *testing.T) {
+	d := newDeduplicator(time.Second)
+	start := time.Now()
+
+	entry := LogEntry{Level: "ERROR", Message: "db down", Timestamp: "t1", Fields: map[string]any{"db": "main"}}
+
+	if admitted, _ := d.admit(entry, start); !admitted {
+		t.Fatal("First entry should be admitted")
+	}
+	for i := 0; i < 3; i++ {
+		dup := entry
+		dup.Timestamp = "t2"
+		dup.Fields = map[string]any{"db": "main"}
+		if admitted, _ := d.admit(dup, start.Add(100*time.Millisecond)); admitted {
+			t.Fatal("Duplicate within the window should not be admitted")
+		}
+	}
+
+	other := LogEntry{Level: "ERROR", Message: "db down", Fields: map[string]any{"db": "replica"}}
+	if admitted, _ := d.admit(other, start); !admitted {
+		t.Error("Entry with different fields should be admitted")
+	}
+
+	if summaries := d.expire(start.Add(500*time.Millisecond), false); len(summaries) != 0 {
+		t.Errorf("expire() before the window closed = %v", summaries)
+	}
+
+	// A new occurrence after the window reopens it and returns the summary
+	admitted, summary := d.admit(entry, start.Add(2*time.Second))
+	if !admitted || summary == nil {
+		t.Fatalf("admit() after the window = %v, %v", admitted, summary)
+	}
+	if summary.Fields[RepeatCountField] != 3 || summary.Fields["db"] != "main" || summary.Timestamp != "t2" {
+		t.Errorf("Summary = %+v, want 3 repeats of the last duplicate", summary)
+	}
+
+	// The replica window closed without duplicates
+	if summaries := d.expire(start.Add(3*time.Second), false); len(summaries) != 0 {
+		t.Errorf("expire() = %v, want no summaries", summaries)
+	}
+}
+
+func TestSender_DedupWindow(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, DedupWindow: time.Hour})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		sender.AddLog(LogEntry{Level: "ERROR", Message: "retry failed", Timestamp: GenerateUniqueTimestamp()})
+	}
+	sender.AddLog(LogEntry{Level: "INFO", Message: "other", Timestamp: GenerateUniqueTimestamp()})
+
+	if deduped := sender.Stats().DedupedLogs; deduped != 99 {
+		t.Errorf("DedupedLogs = %d, want 99", deduped)
+	}
+
+	// Shutdown closes open windows and sends their summaries
+	sender.Shutdown()
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 log entries, got %d: %+v", len(entries), entries)
+	}
+
+	var summary *LogEntry
+	for i := range entries {
+		if _, ok := entries[i].Fields[RepeatCountField]; ok {
+			summary = &entries[i]
+		}
+	}
+	if summary == nil || summary.Message != "retry failed" || summary.Fields[RepeatCountField] != 99 {
+		t.Errorf("Summary = %+v, want retry failed with 99 repeats", summary)
+	}
+}
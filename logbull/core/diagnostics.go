@@ -0,0 +1,22 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/logbull/logbull-go/logbull/internal/diag"
+)
+
+// reportError routes an internal error to Config.ErrorHandler, or to stderr
+// unless Config.Silent is set.
+func (c *Config) reportError(err error, context map[string]any) {
+	diag.Report(c.ErrorHandler, c.Silent, err, context)
+}
+
+// reportErrorf is reportError for errors without a Go error value.
+func (c *Config) reportErrorf(context map[string]any, format string, args ...any) {
+	c.reportError(fmt.Errorf(format, args...), context)
+}
+
+func (c *Config) notice(format string, args ...any) {
+	diag.Notice(c.Silent, fmt.Sprintf(format, args...))
+}
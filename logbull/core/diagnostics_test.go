@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/diag"
+)
+
+func captureDiagnostics(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	original := diag.Output
+	diag.Output = &buf
+	t.Cleanup(func() { diag.Output = original })
+
+	return &buf
+}
+
+func TestConfig_ErrorHandler(t *testing.T) {
+	output := captureDiagnostics(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	var contexts []map[string]any
+
+	logger, err := NewLogger(Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		ConsoleFormat: ConsoleDisabled,
+		ErrorHandler: func(err error, context map[string]any) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+			contexts = append(contexts, context)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("hello", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := logger.FlushSync(ctx); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(errs) != 1 {
+		t.Fatalf("ErrorHandler calls = %d, want 1", len(errs))
+	}
+	if !strings.Contains(errs[0].Error(), "status 500") {
+		t.Errorf("err = %v, want status 500", errs[0])
+	}
+	if got := contexts[0]["operation"]; got != "send" {
+		t.Errorf("context[operation] = %v, want send", got)
+	}
+	if got := contexts[0]["status"]; got != http.StatusInternalServerError {
+		t.Errorf("context[status] = %v, want %d", got, http.StatusInternalServerError)
+	}
+	if got := contexts[0]["body"]; got != "boom" {
+		t.Errorf("context[body] = %v, want boom", got)
+	}
+	if output.Len() != 0 {
+		t.Errorf("stderr = %q, want nothing when ErrorHandler is set", output.String())
+	}
+}
+
+func TestConfig_ErrorHandlerReceivesQueueFull(t *testing.T) {
+	captureDiagnostics(t)
+
+	var got error
+	sender := &Sender{
+		config: &Config{ErrorHandler: func(err error, context map[string]any) { got = err }},
+		queues: newLogQueues(1),
+		stopCh: make(chan struct{}),
+	}
+
+	sender.AddLog(LogEntry{Message: "first"})
+	sender.AddLog(LogEntry{Message: "second"})
+
+	if !errors.Is(got, ErrQueueFull) {
+		t.Errorf("ErrorHandler err = %v, want ErrQueueFull", got)
+	}
+}
+
+func TestConfig_Silent(t *testing.T) {
+	t.Run("suppresses diagnostics", func(t *testing.T) {
+		output := captureDiagnostics(t)
+
+		logger, err := NewLogger(Config{Silent: true, ConsoleFormat: ConsoleDisabled})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		defer logger.Shutdown()
+
+		logger.Info("", nil)
+
+		if output.Len() != 0 {
+			t.Errorf("output = %q, want nothing", output.String())
+		}
+	})
+
+	t.Run("prints diagnostics by default", func(t *testing.T) {
+		output := captureDiagnostics(t)
+
+		logger, err := NewLogger(Config{ConsoleFormat: ConsoleDisabled})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		defer logger.Shutdown()
+
+		logger.Info("", nil)
+
+		got := output.String()
+		if !strings.Contains(got, "console-only mode") {
+			t.Errorf("output = %q, want console-only notice", got)
+		}
+		if !strings.Contains(got, "invalid log message") || !strings.Contains(got, "operation=log") {
+			t.Errorf("output = %q, want invalid message error with context", got)
+		}
+	})
+}
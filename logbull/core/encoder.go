@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder serializes batches sent with ProtocolBatch. The Content-Type tells
+// the server which encoding it receives; a server answering 415 Unsupported
+// Media Type makes the sender fall back to JSONEncoder.
+type Encoder interface {
+	ContentType() string
+	Encode(batch LogBatch) ([]byte, error)
+}
+
+var (
+	// JSONEncoder is the default encoder.
+	JSONEncoder Encoder = jsonEncoder{}
+	// MsgPackEncoder produces smaller payloads and is cheaper to marshal.
+	MsgPackEncoder Encoder = msgpackEncoder{}
+	// CBOREncoder produces CBOR (RFC 8949) payloads.
+	CBOREncoder Encoder = cborEncoder{}
+)
+
+// valueEncoder is implemented by the built-in encoders, which can also
+// encode a ColumnarBatch.
+type valueEncoder interface {
+	encodeValue(v any) ([]byte, error)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (e jsonEncoder) Encode(batch LogBatch) ([]byte, error) {
+	return e.encodeValue(batch)
+}
+
+func (jsonEncoder) encodeValue(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+func (e msgpackEncoder) Encode(batch LogBatch) ([]byte, error) {
+	return e.encodeValue(batch)
+}
+
+func (msgpackEncoder) encodeValue(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.SetSortMapKeys(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type cborEncoder struct{}
+
+var cborMode = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{Time: cbor.TimeRFC3339Nano, Sort: cbor.SortCanonical}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+func (cborEncoder) ContentType() string {
+	return "application/cbor"
+}
+
+func (e cborEncoder) Encode(batch LogBatch) ([]byte, error) {
+	return e.encodeValue(batch)
+}
+
+func (cborEncoder) encodeValue(v any) ([]byte, error) {
+	return cborMode.Marshal(v)
+}
+
+// encoderByName returns the encoder for "json", "msgpack" or "cbor".
+func encoderByName(name string) (Encoder, error) {
+	switch name {
+	case "", "json":
+		return JSONEncoder, nil
+	case "msgpack":
+		return MsgPackEncoder, nil
+	case "cbor":
+		return CBOREncoder, nil
+	}
+	return nil, fmt.Errorf("unknown encoder '%s'", name)
+}
+
+// encoder returns the encoder for the next batch.
+func (s *Sender) encoder() Encoder {
+	if s.config.Encoder == nil || s.jsonFallback.Load() {
+		return JSONEncoder
+	}
+	return s.config.Encoder
+}
+
+// fallBackToJSON switches to JSONEncoder after the server rejected the
+// configured encoding. It reports whether the batch should be sent again.
+func (s *Sender) fallBackToJSON() bool {
+	if s.encoder() == JSONEncoder || !s.jsonFallback.CompareAndSwap(false, true) {
+		return false
+	}
+
+	s.config.reportErrorf(
+		map[string]any{"operation": "encode", "content_type": s.config.Encoder.ContentType()},
+		"server does not accept %s batches, falling back to JSON",
+		s.config.Encoder.ContentType(),
+	)
+	return true
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEncoders(t *testing.T) {
+	batch := LogBatch{Logs: []LogEntry{{
+		Level:     "INFO",
+		Message:   "order placed",
+		Timestamp: "2024-03-01T12:30:00.123456789Z",
+		Fields:    map[string]any{"order_id": "o-1", "amount": 12.5},
+	}}}
+
+	decoders := map[Encoder]func([]byte, any) error{
+		JSONEncoder:    json.Unmarshal,
+		MsgPackEncoder: unmarshalMsgPack,
+		CBOREncoder:    cbor.Unmarshal,
+	}
+
+	jsonData, _ := JSONEncoder.Encode(batch)
+	for encoder, decode := range decoders {
+		t.Run(encoder.ContentType(), func(t *testing.T) {
+			data, err := encoder.Encode(batch)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if encoder != JSONEncoder && len(data) >= len(jsonData) {
+				t.Errorf("Encoded size = %d, want less than JSON's %d", len(data), len(jsonData))
+			}
+
+			var decoded LogBatch
+			if err := decode(data, &decoded); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if len(decoded.Logs) != 1 {
+				t.Fatalf("Decoded %d logs, want 1", len(decoded.Logs))
+			}
+			entry := decoded.Logs[0]
+			if entry.Message != "order placed" || entry.Level != "INFO" || entry.Timestamp != batch.Logs[0].Timestamp {
+				t.Errorf("entry = %+v", entry)
+			}
+			if entry.Fields["order_id"] != "o-1" || entry.Fields["amount"] != 12.5 {
+				t.Errorf("fields = %v", entry.Fields)
+			}
+		})
+	}
+}
+
+// unmarshalMsgPack decodes with the JSON field names MsgPackEncoder uses.
+func unmarshalMsgPack(data []byte, v any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+func TestSender_Encoder(t *testing.T) {
+	var mu sync.Mutex
+	var contentType string
+	var decoded LogBatch
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		contentType = r.Header.Get("Content-Type")
+		unmarshalMsgPack(body, &decoded)
+		mu.Unlock()
+
+		w.Write([]byte(`{"accepted":1}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Encoder:   MsgPackEncoder,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "packed", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if contentType != "application/msgpack" {
+		t.Errorf("Content-Type = %q, want application/msgpack", contentType)
+	}
+	if len(decoded.Logs) != 1 || decoded.Logs[0].Message != "packed" {
+		t.Errorf("Decoded batch = %+v", decoded)
+	}
+}
+
+func TestSender_EncoderFallback(t *testing.T) {
+	var mu sync.Mutex
+	var contentTypes []string
+	var received []LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		received = append(received, batch.Logs...)
+		w.Write([]byte(`{"accepted":1}`))
+	}))
+	defer server.Close()
+
+	var reported []error
+	sender, err := NewSender(&Config{
+		ProjectID:    "12345678-1234-1234-1234-123456789012",
+		Host:         server.URL,
+		Encoder:      CBOREncoder,
+		ErrorHandler: func(err error, _ map[string]any) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	for _, message := range []string{"first", "second"} {
+		sender.AddLog(LogEntry{Level: "INFO", Message: message, Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"application/cbor", "application/json", "application/json"}
+	if len(contentTypes) != len(want) {
+		t.Fatalf("Content-Types = %v, want %v", contentTypes, want)
+	}
+	for i := range want {
+		if contentTypes[i] != want[i] {
+			t.Errorf("Content-Types = %v, want %v", contentTypes, want)
+			break
+		}
+	}
+	if len(received) != 2 {
+		t.Errorf("Received %d logs, want 2", len(received))
+	}
+	if len(reported) != 1 {
+		t.Errorf("Reported errors = %v, want one fallback notice", reported)
+	}
+}
+
+func TestNewSender_EncoderRequiresBatchProtocol(t *testing.T) {
+	_, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      "http://localhost:4005",
+		Protocol:  ProtocolOTLP,
+		Encoder:   MsgPackEncoder,
+	})
+	if err == nil {
+		t.Error("NewSender() with Encoder and ProtocolOTLP expected error")
+	}
+}
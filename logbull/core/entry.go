@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+// EntryBuilder builds a LogEntry to submit with Sender.AddLog, for callers
+// that construct entries themselves instead of logging through
+// LogBullLogger or a handler:
+//
+//	entry, err := logbull.NewEntry(logbull.INFO, "Order imported").
+//		WithTime(order.CreatedAt).
+//		WithFields(order.Fields()).
+//		Build()
+//	if err == nil {
+//		logger.Sender().AddLog(entry)
+//	}
+//
+// The sender still adds DefaultFields, metadata and the other
+// Config-driven fields when the entry is queued.
+type EntryBuilder struct {
+	level   LogLevel
+	message string
+	time    time.Time
+	fields  map[string]any
+	keys    FieldKeyPolicy
+}
+
+func NewEntry(level LogLevel, message string) *EntryBuilder {
+	return &EntryBuilder{level: level, message: message}
+}
+
+// WithTime sets the event time. Without it, Build stamps the entry with a
+// unique current timestamp.
+func (b *EntryBuilder) WithTime(t time.Time) *EntryBuilder {
+	b.time = t
+	return b
+}
+
+// WithFields merges fields into the entry's fields; later values win.
+func (b *EntryBuilder) WithFields(fields map[string]any) *EntryBuilder {
+	if b.fields == nil {
+		b.fields = make(map[string]any, len(fields))
+	}
+	for key, value := range fields {
+		b.fields[key] = value
+	}
+	return b
+}
+
+func (b *EntryBuilder) WithField(key string, value any) *EntryBuilder {
+	return b.WithFields(map[string]any{key: value})
+}
+
+// WithFieldKeys normalizes the field keys with policy, usually the
+// Config.FieldKeys of the logger the entry is sent through.
+func (b *EntryBuilder) WithFieldKeys(policy FieldKeyPolicy) *EntryBuilder {
+	b.keys = policy
+	return b
+}
+
+// Build validates the level, message and fields like LogBullLogger does, and
+// returns the entry with its message, timestamp and fields formatted: errors
+// become their message and values JSON cannot encode become strings.
+func (b *EntryBuilder) Build() (LogEntry, error) {
+	if b.level.Priority() == 0 {
+		return LogEntry{}, fmt.Errorf("invalid log level '%s'", b.level)
+	}
+
+	if err := validation.ValidateLogMessage(b.message); err != nil {
+		return LogEntry{}, fmt.Errorf("invalid log message: %w (level=%s %s)", err, b.level, formatting.PreviewEntry(b.message, b.fields))
+	}
+
+	if err := validation.ValidateLogFields(b.fields); err != nil {
+		return LogEntry{}, fmt.Errorf("invalid log fields: %w (level=%s %s)", err, b.level, formatting.PreviewEntry(b.message, b.fields))
+	}
+
+	return LogEntry{
+		Level:     b.level.String(),
+		Message:   formatting.FormatMessage(b.message),
+		Timestamp: FormatTimestamp(b.time),
+		Fields:    formatting.EnsureFields(b.fields, b.keys),
+	}, nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+func TestNewEntry(t *testing.T) {
+	fields := map[string]any{"order_id": "o1"}
+	builder := NewEntry(INFO, "  Order imported  ").
+		WithTime(time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))).
+		WithFields(fields).
+		WithField("source", "csv")
+	fields["order_id"] = "changed"
+
+	entry, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if entry.Level != "INFO" || entry.Message != "Order imported" {
+		t.Errorf("Level = %q, Message = %q", entry.Level, entry.Message)
+	}
+	if entry.Timestamp != "2024-03-01T11:30:00.000000000Z" {
+		t.Errorf("Timestamp = %s, want the event time in UTC", entry.Timestamp)
+	}
+	if entry.Fields["order_id"] != "o1" || entry.Fields["source"] != "csv" {
+		t.Errorf("Fields = %v", entry.Fields)
+	}
+
+	untimed, err := NewEntry(DEBUG, "tick").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if untimed.Timestamp == "" || untimed.Fields == nil {
+		t.Errorf("Entry = %+v, want a generated timestamp and empty fields", untimed)
+	}
+}
+
+func TestNewEntry_FormatsFields(t *testing.T) {
+	entry, err := NewEntry(ERROR, "Import failed").
+		WithField("cause", errors.New("row 12: bad date")).
+		WithField("done", make(chan int)).
+		WithField("rowCount", 12).
+		WithFieldKeys(FieldKeyPolicy{SnakeCase: true}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if entry.Fields["cause"] != "row 12: bad date" {
+		t.Errorf("cause = %v, want the error message", entry.Fields["cause"])
+	}
+	if _, ok := entry.Fields["done"].(string); !ok {
+		t.Errorf("done = %T, want a string for a value JSON cannot encode", entry.Fields["done"])
+	}
+	if entry.Fields["row_count"] != 12 {
+		t.Errorf("Fields = %v, want rowCount normalized to row_count", entry.Fields)
+	}
+	if _, err := json.Marshal(entry); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+func TestNewEntry_Invalid(t *testing.T) {
+	if _, err := NewEntry("VERBOSE", "hello").Build(); err == nil {
+		t.Error("Build() expected error for an unknown level")
+	}
+
+	if _, err := NewEntry(INFO, "   ").Build(); !errors.Is(err, validation.ErrEmptyMessage) {
+		t.Errorf("Build() error = %v, want ErrEmptyMessage", err)
+	}
+
+	if _, err := NewEntry(INFO, "hello").WithField(" ", 1).Build(); !errors.Is(err, validation.ErrInvalidFields) {
+		t.Errorf("Build() error = %v, want ErrInvalidFields", err)
+	}
+}
+
+func TestNewEntry_AddLog(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, DefaultFields: map[string]any{"service": "importer"}})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	entry, err := NewEntry(WARNING, "Row skipped").WithField("row", 12).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	sender.AddLog(entry)
+
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 || entries[0].Fields["row"] != 12 || entries[0].Fields["service"] != "importer" {
+		t.Errorf("Sent = %+v, want the entry with DefaultFields", entries)
+	}
+}
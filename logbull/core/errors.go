@@ -0,0 +1,32 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+var (
+	ErrQueueFull      = errors.New("log queue full")
+	ErrSenderShutdown = errors.New("sender is shut down")
+	ErrLoggerFrozen   = errors.New("logger is frozen")
+	ErrUnauthorized   = errors.New("server rejected credentials")
+	// ErrLogPanic wraps a panic recovered while logging an entry, e.g. from
+	// a FingerprintFunc; the entry is dropped.
+	ErrLogPanic = errors.New("panic while logging")
+)
+
+// Validation errors. Configuration mistakes are returned by NewLogger and the
+// handler constructors, and invalid messages or fields by the Try methods.
+// They are *ValidationError values, so errors.As gives access to the details.
+var (
+	ErrInvalidProjectID = validation.ErrInvalidProjectID
+	ErrInvalidHost      = validation.ErrInvalidHost
+	ErrInvalidProxyURL  = validation.ErrInvalidProxyURL
+	ErrInvalidAPIKey    = validation.ErrInvalidAPIKey
+	ErrEmptyMessage     = validation.ErrEmptyMessage
+	ErrMessageTooLong   = validation.ErrMessageTooLong
+	ErrInvalidFields    = validation.ErrInvalidFields
+)
+
+type ValidationError = validation.Error
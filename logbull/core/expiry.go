@@ -0,0 +1,50 @@
+package core
+
+import "time"
+
+// stampQueued records when entry was queued, for Config.MaxLogAge.
+func (s *Sender) stampQueued(entry *LogEntry) {
+	if s.config.MaxLogAge > 0 {
+		entry.queuedAt = s.config.clock().Now().UnixNano()
+	}
+}
+
+// stampReplayed dates an entry replayed from the write-ahead log by its
+// timestamp, since it was first queued by an earlier process. Audit entries
+// never expire.
+func (s *Sender) stampReplayed(entry *LogEntry) {
+	if s.config.MaxLogAge <= 0 || entry.Fields[AuditTypeField] == AuditType {
+		return
+	}
+	if t, err := time.Parse(timestampLayout, entry.Timestamp); err == nil {
+		entry.queuedAt = t.UnixNano()
+	}
+}
+
+// dropExpired removes the logs queued longer than Config.MaxLogAge from
+// logs, counting them in Stats.ExpiredLogs.
+func (s *Sender) dropExpired(logs []LogEntry) []LogEntry {
+	if s.config.MaxLogAge <= 0 {
+		return logs
+	}
+
+	cutoff := s.config.clock().Now().Add(-s.config.MaxLogAge).UnixNano()
+
+	var expired []LogEntry
+	kept := logs[:0]
+	for _, entry := range logs {
+		if entry.queuedAt != 0 && entry.queuedAt < cutoff {
+			expired = append(expired, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(expired) == 0 {
+		return logs
+	}
+
+	clear(logs[len(kept):])
+	s.expiredLogs.Add(uint64(len(expired)))
+	s.releaseWAL(expired)
+	return kept
+}
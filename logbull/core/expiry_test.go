@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSender_MaxLogAge(t *testing.T) {
+	transport := &captureTransport{}
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, Clock: clock, MaxLogAge: time.Minute})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("stale", nil)
+	logger.Audit("stale audit", nil)
+	clock.advance(2 * time.Minute)
+	logger.Info("fresh", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 2 || entries[0].Message != "stale audit" || entries[1].Message != "fresh" {
+		t.Errorf("Delivered %+v, want the audit and fresh entries", entries)
+	}
+	if expired := logger.Stats().ExpiredLogs; expired != 1 {
+		t.Errorf("ExpiredLogs = %d, want 1", expired)
+	}
+}
+
+func TestSender_MaxLogAgeReplayedWAL(t *testing.T) {
+	dir := t.TempDir()
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+
+	first, err := NewSender(&Config{Transport: transportFunc(func(context.Context, []LogEntry) error {
+		return context.DeadlineExceeded
+	}), WALDir: dir, Clock: clock, ErrorHandler: func(error, map[string]any) {}})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	first.AddLog(LogEntry{Level: "INFO", Message: "before the outage", Timestamp: FormatTimestamp(clock.Now())})
+	first.FlushSync(context.Background())
+	first.Shutdown()
+
+	clock.advance(time.Hour)
+
+	transport := &captureTransport{}
+	second, err := NewSender(&Config{Transport: transport, WALDir: dir, Clock: clock, MaxLogAge: time.Minute})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer second.Shutdown()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for second.Stats().ExpiredLogs == 0 && time.Now().Before(deadline) {
+		second.FlushSync(context.Background())
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if expired := second.Stats().ExpiredLogs; expired != 1 || len(transport.all()) != 0 {
+		t.Errorf("ExpiredLogs = %d and %d delivered, want 1 and 0", expired, len(transport.all()))
+	}
+	if files := walFiles(t, dir); len(files) != 0 {
+		t.Errorf("WAL files = %v, want the expired segment removed", files)
+	}
+}
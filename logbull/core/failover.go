@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+const (
+	// failoverAfter is how many consecutive failed requests make the sender
+	// switch to the next host of Config.Hosts.
+	failoverAfter = 3
+
+	// primaryProbeInterval is how often a failed-over sender checks whether
+	// the primary host is back.
+	primaryProbeInterval = 30 * time.Second
+)
+
+// hostSet is the list of hosts logs can be sent to, primary first, and the
+// one currently used. It is replaced as a whole, never modified.
+type hostSet struct {
+	hosts  []string
+	active int
+}
+
+func (h *hostSet) current() string {
+	return h.hosts[h.active]
+}
+
+// next returns the host after host, or false when there is no other host.
+func (h *hostSet) next(host string) (int, bool) {
+	if len(h.hosts) < 2 {
+		return 0, false
+	}
+	for i, candidate := range h.hosts {
+		if candidate == host {
+			return (i + 1) % len(h.hosts), true
+		}
+	}
+	return 0, false
+}
+
+// senderHosts returns Config.Hosts, or Config.Host alone when it is empty.
+func senderHosts(config *Config) ([]string, error) {
+	if len(config.Hosts) == 0 {
+		return []string{config.Host}, nil
+	}
+
+	hosts := make([]string, len(config.Hosts))
+	for i, host := range config.Hosts {
+		hosts[i] = strings.TrimSpace(host)
+		if config.Transport != nil {
+			continue
+		}
+		if err := validation.ValidateHostURL(hosts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Host != "" && config.Host != hosts[0] {
+		return nil, fmt.Errorf("must start with Host '%s' when both are set", config.Host)
+	}
+	return hosts, nil
+}
+
+// failOver records a failed request to host and returns the host to send
+// the batch to instead. After failoverAfter consecutive failures, that host
+// also becomes the one later batches are sent to. It is called with resendMu
+// held.
+func (s *Sender) failOver(host string) (string, bool) {
+	set := s.hosts.Load()
+	next, ok := set.next(host)
+	if !ok {
+		return "", false
+	}
+
+	if s.hostFailures.Add(1) >= failoverAfter && set.current() == host {
+		if s.hosts.CompareAndSwap(set, &hostSet{hosts: set.hosts, active: next}) {
+			s.hostFailures.Store(0)
+			s.lastPrimaryProbe.Store(s.config.clock().Now().UnixNano())
+			s.config.reportErrorf(
+				map[string]any{"operation": "failover", "host": host, "next_host": set.hosts[next]},
+				"failing over from %s to %s after %d failed requests",
+				host,
+				set.hosts[next],
+				failoverAfter,
+			)
+			s.hostChanged(host, set.hosts[next])
+		}
+	}
+
+	return set.hosts[next], true
+}
+
+// resend sends a batch that failed on another host to host, with the same
+// body, so the same batch ID and sequence fields. It is called with resendMu
+// held, so later batches wait for it in awaitResend and do not reach host
+// before it.
+func (s *Sender) resend(logs []LogEntry, host string, data []byte, batchID string) {
+	if err := s.postBatch(logs, host, data, batchID, true); err != nil {
+		s.markUnreachable(logs)
+		s.markFailed(logs, err)
+	}
+}
+
+// awaitResend waits until no failed batch is being re-sent.
+func (s *Sender) awaitResend() {
+	s.resendMu.RLock()
+	defer s.resendMu.RUnlock()
+}
+
+// probePrimary checks, at most every primaryProbeInterval, whether a
+// failed-over sender's primary host is reachable again, and sends to it
+// again once it is.
+func (s *Sender) probePrimary() {
+	set := s.hosts.Load()
+	if set.active == 0 {
+		return
+	}
+
+	now := s.config.clock().Now().UnixNano()
+	last := s.lastPrimaryProbe.Load()
+	if now-last < int64(primaryProbeInterval) || !s.lastPrimaryProbe.CompareAndSwap(last, now) {
+		return
+	}
+
+	// Called from the batch processor, so the wait group is not at zero
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-s.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		primary := set.hosts[0]
+		if err := s.pingHost(ctx, primary); err != nil {
+			return
+		}
+
+		if s.hosts.CompareAndSwap(set, &hostSet{hosts: set.hosts}) {
+			s.hostFailures.Store(0)
+			s.config.notice("primary host %s is reachable again, sending logs to it", primary)
+			s.hostChanged(set.current(), primary)
+		}
+	}()
+}
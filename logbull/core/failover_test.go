@@ -0,0 +1,323 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// batchServer records the batches it receives, answering with status.
+type batchServer struct {
+	*httptest.Server
+
+	status atomic.Int32
+	// hold, when set, is waited on before answering
+	hold chan struct{}
+
+	mu        sync.Mutex
+	batchIDs  []string
+	sequences []float64
+}
+
+func newBatchServer(t *testing.T, status int) *batchServer {
+	t.Helper()
+
+	b := &batchServer{}
+	b.status.Store(int32(status))
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		if len(batch.Logs) > 0 {
+			b.mu.Lock()
+			b.batchIDs = append(b.batchIDs, r.Header.Get(BatchIDHeader))
+			for _, log := range batch.Logs {
+				sequence, _ := log.Fields["sequence"].(float64)
+				b.sequences = append(b.sequences, sequence)
+			}
+			hold := b.hold
+			b.mu.Unlock()
+
+			if hold != nil {
+				<-hold
+			}
+		}
+
+		w.WriteHeader(int(b.status.Load()))
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func (b *batchServer) received() ([]string, []float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.batchIDs...), append([]float64(nil), b.sequences...)
+}
+
+// manualClock only moves and ticks when the test says so.
+type manualClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTicker(time.Duration) Ticker {
+	return manualTicker{c.ticks}
+}
+
+func (c *manualClock) advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func (c *manualClock) tick(d time.Duration) {
+	c.ticks <- c.advance(d)
+}
+
+type manualTicker struct {
+	c chan time.Time
+}
+
+func (t manualTicker) C() <-chan time.Time { return t.c }
+func (manualTicker) Reset(time.Duration)   {}
+func (manualTicker) Stop()                 {}
+
+func newFailoverSender(t *testing.T, config Config) *Sender {
+	t.Helper()
+
+	config.ProjectID = "12345678-1234-1234-1234-123456789012"
+	config.EnableSequence = true
+	config.ErrorHandler = func(error, map[string]any) {}
+	sender, err := NewSender(&config)
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	t.Cleanup(sender.Shutdown)
+	return sender
+}
+
+func TestSender_FailoverResendsWithSameBatchID(t *testing.T) {
+	primary := newBatchServer(t, http.StatusServiceUnavailable)
+	secondary := newBatchServer(t, http.StatusOK)
+
+	sender := newFailoverSender(t, Config{Hosts: []string{primary.URL, secondary.URL}})
+
+	for i := 0; i < 5; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+
+	primaryIDs, _ := primary.received()
+	secondaryIDs, sequences := secondary.received()
+
+	if len(primaryIDs) != failoverAfter {
+		t.Errorf("Primary received %d batches, want %d before failing over", len(primaryIDs), failoverAfter)
+	}
+	if len(secondaryIDs) != 5 {
+		t.Fatalf("Secondary received %d batches, want 5", len(secondaryIDs))
+	}
+	for i, id := range primaryIDs {
+		if secondaryIDs[i] != id {
+			t.Errorf("Re-sent batch %d has ID %s, want the original %s", i, secondaryIDs[i], id)
+		}
+	}
+	for i, sequence := range sequences {
+		if sequence != float64(i+1) {
+			t.Errorf("Sequences = %v, want 1 to 5 in order", sequences)
+			break
+		}
+	}
+	if sender.Host() != secondary.URL {
+		t.Errorf("Host() = %s, want the secondary", sender.Host())
+	}
+}
+
+func TestSender_FailoverOrdersResendBeforeLaterBatches(t *testing.T) {
+	primary := newBatchServer(t, http.StatusServiceUnavailable)
+	secondary := newBatchServer(t, http.StatusOK)
+	hold := make(chan struct{})
+	secondary.mu.Lock()
+	secondary.hold = hold
+	secondary.mu.Unlock()
+
+	sender := newFailoverSender(t, Config{Hosts: []string{primary.URL, secondary.URL}})
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "first", Timestamp: GenerateUniqueTimestamp()})
+	sender.Flush()
+
+	// Wait until the first batch is being re-sent to the secondary
+	deadline := time.Now().Add(2 * time.Second)
+	for ids, _ := secondary.received(); len(ids) == 0 && time.Now().Before(deadline); ids, _ = secondary.received() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A later batch handed to another worker waits for the re-send
+	sender.AddLog(LogEntry{Level: "INFO", Message: "second", Timestamp: GenerateUniqueTimestamp()})
+	sender.Flush()
+	time.Sleep(100 * time.Millisecond)
+	if ids, _ := primary.received(); len(ids) != 1 {
+		t.Errorf("Primary received %d batches during the re-send, want 1", len(ids))
+	}
+
+	close(hold)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if _, sequences := secondary.received(); len(sequences) != 2 || sequences[0] != 1 || sequences[1] != 2 {
+		t.Errorf("Secondary sequences = %v, want [1 2]", sequences)
+	}
+}
+
+// failOverClock calls onFailOver when failOver reads the time, right after
+// switching hosts.
+type failOverClock struct {
+	systemClock
+	onFailOver func()
+}
+
+func (c *failOverClock) Now() time.Time {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if strings.HasSuffix(frame.Function, ".(*Sender).failOver") {
+			c.onFailOver()
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	return c.systemClock.Now()
+}
+
+func TestSender_FailoverOrdersResendBeforeBatchesDuringSwitch(t *testing.T) {
+	primary := newBatchServer(t, http.StatusServiceUnavailable)
+	secondary := newBatchServer(t, http.StatusOK)
+
+	var sender *Sender
+	clock := &failOverClock{}
+	clock.onFailOver = func() {
+		// A batch sent by another worker right after the host switch
+		sender.AddLog(LogEntry{Level: "INFO", Message: "during switch", Timestamp: GenerateUniqueTimestamp()})
+		sender.Flush()
+		time.Sleep(100 * time.Millisecond)
+	}
+	sender = newFailoverSender(t, Config{Hosts: []string{primary.URL, secondary.URL}, Clock: clock})
+
+	for i := 0; i < failoverAfter; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	_, sequences := secondary.received()
+	for i, sequence := range sequences {
+		if sequence != float64(i+1) {
+			t.Errorf("Secondary sequences = %v, want 1 to %d in order", sequences, failoverAfter+1)
+			break
+		}
+	}
+	if len(sequences) != failoverAfter+1 {
+		t.Errorf("Secondary received %d logs, want %d", len(sequences), failoverAfter+1)
+	}
+}
+
+func TestSender_FailoverReturnsToPrimary(t *testing.T) {
+	primary := newBatchServer(t, http.StatusServiceUnavailable)
+	secondary := newBatchServer(t, http.StatusOK)
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+
+	sender := newFailoverSender(t, Config{Hosts: []string{primary.URL, secondary.URL}, Clock: clock})
+
+	for i := 0; i < failoverAfter; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+	if sender.Host() != secondary.URL {
+		t.Fatalf("Host() = %s, want the secondary", sender.Host())
+	}
+
+	primary.status.Store(http.StatusOK)
+
+	// Not probed before primaryProbeInterval
+	clock.tick(time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if sender.Host() != secondary.URL {
+		t.Fatalf("Host() = %s, want the secondary until the next probe", sender.Host())
+	}
+
+	clock.tick(primaryProbeInterval)
+	deadline := time.Now().Add(2 * time.Second)
+	for sender.Host() != primary.URL && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sender.Host() != primary.URL {
+		t.Errorf("Host() = %s, want the recovered primary", sender.Host())
+	}
+}
+
+func TestSender_SingleHostDoesNotResend(t *testing.T) {
+	primary := newBatchServer(t, http.StatusServiceUnavailable)
+
+	var failed atomic.Int32
+	sender := newFailoverSender(t, Config{
+		Host:             primary.URL,
+		OnDeliveryFailed: func(LogBatch, error) { failed.Add(1) },
+	})
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if ids, _ := primary.received(); len(ids) != 1 || failed.Load() != 1 {
+		t.Errorf("Primary received %d batches and %d failed, want 1 and 1", len(ids), failed.Load())
+	}
+}
+
+func TestNewSender_InvalidHosts(t *testing.T) {
+	projectID := "12345678-1234-1234-1234-123456789012"
+
+	if _, err := NewSender(&Config{ProjectID: projectID, Hosts: []string{"http://a.example", "b.example"}}); err == nil {
+		t.Error("NewSender() expected error for an invalid host")
+	}
+	if _, err := NewSender(&Config{ProjectID: projectID, Host: "http://c.example", Hosts: []string{"http://a.example"}}); err == nil {
+		t.Error("NewSender() expected error for a Host that is not the first of Hosts")
+	}
+
+	logger, err := NewLogger(Config{ProjectID: projectID, Hosts: []string{"http://a.example", "http://b.example"}, ConsoleFormat: ConsoleDisabled})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+	if logger.Sender() == nil || logger.Sender().Host() != "http://a.example" {
+		t.Error("NewLogger() with Hosts only should send to the first host")
+	}
+}
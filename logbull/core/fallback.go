@@ -0,0 +1,61 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultFallbackAfter = 1 * time.Minute
+
+// FallbackWriter stores logs on the local host when they cannot reach the
+// LogBull server. NewSystemFallback returns one backed by syslog on Unix and
+// by the Event Log on Windows.
+type FallbackWriter interface {
+	WriteEntry(entry LogEntry) error
+}
+
+func fallbackMessage(entry LogEntry) string {
+	return formatConsoleText(&Config{}, "", entry)
+}
+
+func (s *Sender) fallbackAfter() time.Duration {
+	if s.config.FallbackAfter > 0 {
+		return s.config.FallbackAfter
+	}
+	return defaultFallbackAfter
+}
+
+// markUnreachable records a failed delivery and, once the server has been
+// unreachable for FallbackAfter, writes logs to the FallbackWriter.
+func (s *Sender) markUnreachable(logs []LogEntry) {
+	if s.config.FallbackWriter == nil {
+		return
+	}
+
+	now := s.config.clock().Now().UnixNano()
+	since := s.unreachableSince.Load()
+	if since == 0 {
+		if !s.unreachableSince.CompareAndSwap(0, now) {
+			since = s.unreachableSince.Load()
+		} else {
+			since = now
+		}
+	}
+
+	if now-since >= int64(s.fallbackAfter()) {
+		s.writeFallback(logs)
+	}
+}
+
+func (s *Sender) writeFallback(logs []LogEntry) {
+	if s.config.FallbackWriter == nil {
+		return
+	}
+
+	for _, log := range logs {
+		if err := s.config.FallbackWriter.WriteEntry(log); err != nil {
+			s.config.reportError(fmt.Errorf("failed to write fallback log: %w", err), map[string]any{"operation": "fallback"})
+			return
+		}
+	}
+}
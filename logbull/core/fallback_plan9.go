@@ -0,0 +1,10 @@
+//go:build plan9
+
+package core
+
+import "errors"
+
+// NewSystemFallback is not supported on Plan 9.
+func NewSystemFallback(string) (FallbackWriter, error) {
+	return nil, errors.New("no system log available on plan9")
+}
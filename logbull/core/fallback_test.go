@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingFallback struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (f *recordingFallback) WriteEntry(entry LogEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *recordingFallback) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var messages []string
+	for _, entry := range f.entries {
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
+
+func TestSender_FallbackOnQueueOverflow(t *testing.T) {
+	fallback := &recordingFallback{}
+	block := make(chan struct{})
+
+	sender, err := NewSender(&Config{
+		Transport: transportFunc(func(ctx context.Context, logs []LogEntry) error {
+			<-block
+			return nil
+		}),
+		FallbackWriter: fallback,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	for i := 0; i < queueCapacity; i++ {
+		sender.queues.queues[priorityHigh] <- queuedEntry{LogEntry: LogEntry{Level: "ERROR", Message: "queued"}}
+	}
+	if err := sender.TryAddLog(LogEntry{Level: "ERROR", Message: "overflow"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("TryAddLog() error = %v, want ErrQueueFull", err)
+	}
+
+	if got := fallback.messages(); len(got) != 1 || got[0] != "overflow" {
+		t.Errorf("fallback messages = %v, want [overflow]", got)
+	}
+
+	close(block)
+	sender.Shutdown()
+}
+
+func TestSender_FallbackWhenUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	host := server.URL
+	server.Close()
+
+	fallback := &recordingFallback{}
+	sender, err := NewSender(&Config{
+		ProjectID:      "12345678-1234-1234-1234-123456789012",
+		Host:           host,
+		FallbackWriter: fallback,
+		FallbackAfter:  time.Nanosecond,
+		Silent:         true,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	for _, message := range []string{"first", "second"} {
+		sender.AddLog(LogEntry{Level: "ERROR", Message: message, Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+
+	// The first failure only starts the FallbackAfter window
+	if got := fallback.messages(); len(got) != 1 || got[0] != "second" {
+		t.Errorf("fallback messages = %v, want [second]", got)
+	}
+}
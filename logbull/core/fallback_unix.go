@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+
+package core
+
+import "log/syslog"
+
+type syslogFallback struct {
+	writer *syslog.Writer
+}
+
+// NewSystemFallback returns a FallbackWriter that sends logs to the local
+// syslog daemon under tag, with a priority matching each level.
+func NewSystemFallback(tag string) (FallbackWriter, error) {
+	writer, err := syslog.New(syslog.LOG_USER|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogFallback{writer: writer}, nil
+}
+
+func (f *syslogFallback) WriteEntry(entry LogEntry) error {
+	message := fallbackMessage(entry)
+
+	switch LogLevel(entry.Level) {
+	case DEBUG:
+		return f.writer.Debug(message)
+	case WARNING:
+		return f.writer.Warning(message)
+	case ERROR:
+		return f.writer.Err(message)
+	case CRITICAL:
+		return f.writer.Crit(message)
+	default:
+		return f.writer.Info(message)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package core
+
+import "golang.org/x/sys/windows/svc/eventlog"
+
+const fallbackEventID = 1
+
+type eventLogFallback struct {
+	log *eventlog.Log
+}
+
+// NewSystemFallback returns a FallbackWriter that reports logs to the
+// Windows Event Log under source, which must already be registered, e.g.
+// with eventlog.InstallAsEventCreate.
+func NewSystemFallback(source string) (FallbackWriter, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogFallback{log: log}, nil
+}
+
+func (f *eventLogFallback) WriteEntry(entry LogEntry) error {
+	message := fallbackMessage(entry)
+
+	switch LogLevel(entry.Level) {
+	case WARNING:
+		return f.log.Warning(fallbackEventID, message)
+	case ERROR, CRITICAL:
+		return f.log.Error(fallbackEventID, message)
+	default:
+		return f.log.Info(fallbackEventID, message)
+	}
+}
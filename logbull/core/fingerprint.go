@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+)
+
+// FingerprintField carries the grouping key of ERROR and CRITICAL entries
+// when Config.EnableFingerprint is set.
+const FingerprintField = "fingerprint"
+
+// Variable parts of messages, replaced before hashing so "order 17 failed"
+// and "order 42 failed" share a fingerprint. Order matters: UUIDs contain
+// hex runs, and hex runs contain digits.
+var fingerprintNormalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+`), "<n>"},
+}
+
+// NormalizeMessage replaces UUIDs, quoted strings, hex values and numbers in
+// message with placeholders.
+func NormalizeMessage(message string) string {
+	for _, n := range fingerprintNormalizers {
+		message = n.pattern.ReplaceAllString(message, n.replacement)
+	}
+	return message
+}
+
+// DefaultFingerprint hashes the normalized message template, the error type
+// (or normalized error message) and the calling function of entry.
+func DefaultFingerprint(entry LogEntry) string {
+	message := entry.Message
+	if template, ok := entry.Fields[MessageTemplateField].(string); ok {
+		message = template
+	}
+
+	errorKind, ok := entry.Fields[ErrorTypeField].(string)
+	if !ok {
+		if errorMessage, ok := entry.Fields[ErrorField].(string); ok {
+			errorKind = NormalizeMessage(errorMessage)
+		}
+	}
+
+	function, _ := entry.Fields[CallerFunctionField].(string)
+
+	h := fnv.New64a()
+	for _, part := range []string{entry.Level, NormalizeMessage(message), errorKind, function} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func (s *Sender) fingerprinting() bool {
+	return s.config.EnableFingerprint || s.config.FingerprintFunc != nil
+}
+
+// addFingerprint sets FingerprintField on ERROR and CRITICAL entries that do
+// not carry one yet. It must run on the logging goroutine, so the calling
+// function can be found when the entry has no caller fields.
+func (s *Sender) addFingerprint(entry LogEntry, owned bool) (LogEntry, bool) {
+	if LogLevel(entry.Level).Priority() < ERROR.Priority() {
+		return entry, owned
+	}
+	if _, ok := entry.Fields[FingerprintField]; ok {
+		return entry, owned
+	}
+
+	fields := entry.Fields
+	if !owned || fields == nil {
+		fields = make(map[string]any, len(entry.Fields)+1)
+		for key, value := range entry.Fields {
+			fields[key] = value
+		}
+	}
+	entry.Fields = fields
+
+	fingerprintFunc := s.config.FingerprintFunc
+	if fingerprintFunc == nil {
+		fingerprintFunc = DefaultFingerprint
+	}
+
+	fingerprintEntry := entry
+	if _, ok := fields[CallerFunctionField]; !ok {
+		if frame, ok := callsite.Capture(0); ok {
+			// Only used for hashing; the caller fields stay opt-in
+			withCaller := make(map[string]any, len(fields)+1)
+			for key, value := range fields {
+				withCaller[key] = value
+			}
+			withCaller[CallerFunctionField] = frame.Function
+			fingerprintEntry.Fields = withCaller
+		}
+	}
+
+	if fingerprint := fingerprintFunc(fingerprintEntry); fingerprint != "" {
+		fields[FingerprintField] = fingerprint
+	}
+	return entry, true
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"order 42 failed", "order <n> failed"},
+		{"user 12345678-1234-1234-1234-123456789012 not found", "user <uuid> not found"},
+		{`file "a.txt" missing`, "file <str> missing"},
+		{"commit 9fceb02d0ae598e95dc970b74767f19372d61af8 rejected", "commit <hex> rejected"},
+		{"pointer 0x1f at offset 12", "pointer <hex> at offset <n>"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeMessage(tt.input); got != tt.want {
+			t.Errorf("NormalizeMessage(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func logOrderFailure(logger *LogBullLogger, id int, err error) {
+	logger.WithError(err).Error(fmt.Sprintf("order %d failed", id), nil)
+}
+
+func TestSender_Fingerprint(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, EnableFingerprint: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	notExist := fmt.Errorf("open config: %w", &fs.PathError{Op: "open", Path: "/a", Err: fs.ErrNotExist})
+	logOrderFailure(logger, 17, notExist)
+	logOrderFailure(logger, 42, notExist)
+	logOrderFailure(logger, 43, errors.New("timeout"))
+	logger.Error("order 44 failed", nil)
+	logger.Info("order 45 shipped", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 5 {
+		t.Fatalf("Expected 5 log entries, got %d", len(entries))
+	}
+
+	fingerprints := make([]any, len(entries))
+	for i, entry := range entries {
+		fingerprints[i] = entry.Fields[FingerprintField]
+	}
+
+	if fingerprints[0] == nil || fingerprints[0] != fingerprints[1] {
+		t.Errorf("Recurring errors should share a fingerprint: %v", fingerprints)
+	}
+	if fingerprints[2] == fingerprints[0] {
+		t.Error("A different error type should change the fingerprint")
+	}
+	if fingerprints[3] == nil || fingerprints[3] == fingerprints[0] {
+		t.Error("A different call site should change the fingerprint")
+	}
+	if fingerprints[4] != nil {
+		t.Error("INFO entries should not be fingerprinted")
+	}
+	if entries[0].Fields[ErrorTypeField] != "*fs.PathError" {
+		t.Errorf("error_type = %v, want *fs.PathError", entries[0].Fields[ErrorTypeField])
+	}
+	if _, ok := entries[0].Fields[CallerFunctionField]; ok {
+		t.Error("Fingerprinting must not add caller fields")
+	}
+}
+
+func TestSender_FingerprintFunc(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		FingerprintFunc: func(entry LogEntry) string {
+			if entry.Fields["skip"] == true {
+				return ""
+			}
+			return "custom-" + entry.Level
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Critical("disk full", nil)
+	logger.Error("ignored", map[string]any{"skip": true})
+	logger.Error("preset", map[string]any{FingerprintField: "mine"})
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 log entries, got %d", len(entries))
+	}
+	if entries[0].Fields[FingerprintField] != "custom-CRITICAL" {
+		t.Errorf("fingerprint = %v, want custom-CRITICAL", entries[0].Fields[FingerprintField])
+	}
+	if _, ok := entries[1].Fields[FingerprintField]; ok {
+		t.Error("An empty fingerprint should skip the field")
+	}
+	if entries[2].Fields[FingerprintField] != "mine" {
+		t.Errorf("fingerprint = %v, want the caller's value", entries[2].Fields[FingerprintField])
+	}
+}
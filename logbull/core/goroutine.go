@@ -0,0 +1,27 @@
+package core
+
+import "context"
+
+// Go runs fn in a new goroutine with a context that keeps the values of ctx,
+// such as the request-scoped logger, ContextWithFields fields, the request ID
+// and the trace, but not its cancellation or deadline. Background work
+// started by a request thus stays correlated with it after the request has
+// returned:
+//
+//	logbull.Go(r.Context(), func(ctx context.Context) {
+//		logbull.LoggerFromContext(ctx).Info("Sending receipt", nil)
+//	})
+//
+// A panic in fn is logged like RecoverAndLog by the logger in ctx, then
+// re-raised.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	go runDetached(ctx, fn)
+}
+
+func runDetached(ctx context.Context, fn func(ctx context.Context)) {
+	if logger := LoggerFromContext(ctx); logger != nil {
+		defer RecoverAndLogWithConfig(logger.WithCtx(ctx), RecoverConfig{Repanic: true})
+	}
+	fn(ctx)
+}
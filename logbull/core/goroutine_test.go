@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = ContextWithLogger(ctx, logger.WithField("job", "receipt"))
+	ctx = ContextWithRequestID(ctx, "req-1")
+
+	done := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		defer close(done)
+
+		if err := ctx.Err(); err != nil {
+			t.Errorf("ctx.Err() = %v, want the parent's cancellation to be dropped", err)
+		}
+		LoggerFromContext(ctx).WithCtx(ctx).Info("Sending receipt", nil)
+	})
+	cancel()
+	<-done
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Fields["job"] != "receipt" || entries[0].Fields[RequestIDField] != "req-1" {
+		t.Errorf("Fields = %v, want job and request_id", entries[0].Fields)
+	}
+}
+
+func TestGo_PanicIsLogged(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+	ctx := ContextWithRequestID(ContextWithLogger(context.Background(), logger), "req-2")
+
+	// runDetached is the body of the goroutine; the re-raised panic would
+	// crash the test binary there
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to be re-raised")
+			}
+		}()
+		runDetached(context.WithoutCancel(ctx), func(context.Context) {
+			panic("boom")
+		})
+	}()
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Level != "CRITICAL" || entries[0].Fields[PanicField] != "boom" || entries[0].Fields[RequestIDField] != "req-2" {
+		t.Errorf("Panic entry = %+v", entries[0])
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"net/http"
+)
+
+// hedgeAttempt is the outcome of one copy of a batch request.
+type hedgeAttempt struct {
+	// index of the copy, 0 for the original request
+	index int
+	resp  *http.Response
+	err   error
+	// cancel releases the request; call it once the body is read
+	cancel context.CancelFunc
+}
+
+// usable reports whether the attempt got an answer worth keeping, i.e. not a
+// network error or 5xx that another copy could still beat.
+func (a hedgeAttempt) usable() bool {
+	return a.err == nil && a.resp.StatusCode < 500
+}
+
+func (a hedgeAttempt) discard() {
+	if a.resp != nil {
+		a.resp.Body.Close()
+	}
+	a.cancel()
+}
+
+// doHedged sends req, created with a context cancel releases. With
+// Config.HedgeAfter, a copy is sent when req has not been answered in time,
+// and the first usable answer wins; the copies carry the same batch ID, so
+// the server keeps one of them. When both fail, the last failure is
+// returned. The returned cancel must be called once the body is read.
+func (s *Sender) doHedged(req *http.Request, cancel context.CancelFunc) (*http.Response, context.CancelFunc, error) {
+	if s.config.HedgeAfter <= 0 {
+		resp, err := s.client.Do(req)
+		return resp, cancel, err
+	}
+
+	results := make(chan hedgeAttempt, 2)
+	var cancels []context.CancelFunc
+	send := func(req *http.Request, cancel context.CancelFunc) {
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := s.client.Do(req)
+			results <- hedgeAttempt{index: index, resp: resp, err: err, cancel: cancel}
+		}()
+	}
+	send(req, cancel)
+	pending := 1
+
+	ticker := s.config.clock().NewTicker(s.config.HedgeAfter)
+	defer ticker.Stop()
+	hedge := ticker.C()
+
+	var failed *hedgeAttempt
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			ticker.Stop()
+
+			ctx, hedgeCancel := context.WithCancel(context.Background())
+			hedgeReq := req.Clone(ctx)
+			body, err := req.GetBody()
+			if err != nil {
+				hedgeCancel()
+				continue
+			}
+			hedgeReq.Body = body
+
+			s.hedgedRequests.Add(1)
+			send(hedgeReq, hedgeCancel)
+			pending++
+
+		case result := <-results:
+			pending--
+
+			if !result.usable() && pending > 0 {
+				if failed != nil {
+					failed.discard()
+				}
+				failed = &result
+				continue
+			}
+			if failed != nil {
+				failed.discard()
+			}
+
+			// Stop the slower copy; its answer is dropped
+			for i, c := range cancels {
+				if i != result.index {
+					c()
+				}
+			}
+			if pending > 0 {
+				go func() {
+					(<-results).discard()
+				}()
+			}
+			return result.resp, result.cancel, result.err
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSender_HedgeAfter(t *testing.T) {
+	var mu sync.Mutex
+	var batchIDs []string
+	canceled := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		batchIDs = append(batchIDs, r.Header.Get(BatchIDHeader))
+		first := len(batchIDs) == 1
+		mu.Unlock()
+
+		if first {
+			// Stall until the hedged copy wins and the client gives up
+			select {
+			case <-r.Context().Done():
+				close(canceled)
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	sender := newFailoverSender(t, Config{Host: server.URL, HedgeAfter: 50 * time.Millisecond})
+	sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+
+	start := time.Now()
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("FlushSync() took %v, want the hedged copy to answer first", elapsed)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Error("Stalled request was not canceled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batchIDs) != 2 || batchIDs[0] != batchIDs[1] {
+		t.Errorf("Batch IDs = %v, want two copies with the same ID", batchIDs)
+	}
+
+	stats := sender.Stats()
+	if stats.HedgedRequests != 1 || stats.SentBatches != 1 || stats.SendErrors != 0 {
+		t.Errorf("HedgedRequests = %d, SentBatches = %d, SendErrors = %d, want 1, 1, 0", stats.HedgedRequests, stats.SentBatches, stats.SendErrors)
+	}
+}
+
+func TestSender_HedgeAfterNotReached(t *testing.T) {
+	server := newBatchServer(t, http.StatusOK)
+
+	sender := newFailoverSender(t, Config{Host: server.URL, HedgeAfter: time.Minute})
+	sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if ids, _ := server.received(); len(ids) != 1 {
+		t.Errorf("Server received %d batches, want 1", len(ids))
+	}
+	if hedged := sender.Stats().HedgedRequests; hedged != 0 {
+		t.Errorf("HedgedRequests = %d, want 0", hedged)
+	}
+}
+
+func TestConfig_InvalidHedgeAfter(t *testing.T) {
+	config := Config{HedgeAfter: -time.Second}
+	if err := config.checkSender(); err == nil {
+		t.Error("checkSender() error = nil, want an error for a negative HedgeAfter")
+	}
+}
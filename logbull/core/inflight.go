@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightTracker counts batches being delivered and lets callers wait until
+// none are left. Unlike sync.WaitGroup it allows new batches to start while
+// someone is waiting, and the wait can be cancelled.
+type inflightTracker struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func (t *inflightTracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+}
+
+func (t *inflightTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count--
+	if t.count == 0 {
+		close(t.idle)
+	}
+}
+
+func (t *inflightTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.count == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
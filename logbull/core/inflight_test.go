@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInflightTracker(t *testing.T) {
+	t.Run("idle tracker returns immediately", func(t *testing.T) {
+		var tracker inflightTracker
+		if err := tracker.wait(context.Background()); err != nil {
+			t.Errorf("wait() error = %v", err)
+		}
+	})
+
+	t.Run("waits for all batches", func(t *testing.T) {
+		var tracker inflightTracker
+		tracker.add()
+		tracker.add()
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			tracker.done()
+			tracker.done()
+		}()
+
+		if err := tracker.wait(context.Background()); err != nil {
+			t.Errorf("wait() error = %v", err)
+		}
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		var tracker inflightTracker
+		tracker.add()
+		defer tracker.done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := tracker.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("wait() error = %v, want DeadlineExceeded", err)
+		}
+	})
+}
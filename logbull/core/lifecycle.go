@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/diag"
+)
+
+// signalFlushTimeout bounds the shutdown started by a signal, leaving room
+// within the 30 second grace period of Kubernetes and Docker.
+const signalFlushTimeout = 20 * time.Second
+
+// InstallSignalFlush shuts down every logger and handler, delivering their
+// queued logs, when the process receives SIGINT or SIGTERM, then exits with
+// the conventional status 128+signal. On Windows these are Ctrl+C and
+// Ctrl+Break, and the console close, logoff and system shutdown events.
+// Windows services stopped through the service manager get no signal and
+// should call ShutdownAll from their stop handler instead.
+//
+// Applications that already shut down gracefully on these signals should
+// call ShutdownAll at the end of their own handler rather than install this
+// one. The returned function removes the handler.
+func InstallSignalFlush() (stop func()) {
+	return installSignalFlush(shutdownSignals, os.Exit, signalFlushTimeout)
+}
+
+func installSignalFlush(signals []os.Signal, exit func(code int), timeout time.Duration) func() {
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(received, signals...)
+
+	go func() {
+		select {
+		case sig := <-received:
+			signal.Stop(received)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := ShutdownAll(ctx); err != nil {
+				diag.Report(nil, false, fmt.Errorf("shutdown on %v did not finish, some logs were not delivered: %w", sig, err), map[string]any{"operation": "shutdown"})
+			}
+			exit(exitCode(sig))
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !plan9
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// Windows delivers Ctrl+C and Ctrl+Break as os.Interrupt, and the console
+// close, logoff and shutdown events as SIGTERM.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func exitCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return 128 + int(number)
+	}
+	return 1
+}
@@ -0,0 +1,11 @@
+//go:build plan9
+
+package core
+
+import "os"
+
+var shutdownSignals = []os.Signal{os.Interrupt}
+
+func exitCode(os.Signal) int {
+	return 1
+}
@@ -0,0 +1,45 @@
+//go:build !windows && !plan9
+
+package core
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInstallSignalFlush(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	exited := make(chan int, 1)
+	// SIGUSR1 keeps the test from racing other SIGTERM handlers
+	stop := installSignalFlush([]os.Signal{syscall.SIGUSR1}, func(code int) { exited <- code }, 5*time.Second)
+	defer stop()
+
+	logger.Info("last words", nil)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+
+	select {
+	case code := <-exited:
+		if want := 128 + int(syscall.SIGUSR1); code != want {
+			t.Errorf("exit code = %d, want %d", code, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Signal did not trigger the shutdown")
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 || entries[0].Message != "last words" {
+		t.Errorf("Delivered entries = %+v, want the queued log", entries)
+	}
+	if err := logger.TryInfo("after shutdown", nil); !errors.Is(err, ErrSenderShutdown) {
+		t.Errorf("TryInfo() after the signal error = %v, want ErrSenderShutdown", err)
+	}
+
+	// Removing the handler after it ran is a no-op
+	stop()
+}
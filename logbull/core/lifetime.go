@@ -0,0 +1,35 @@
+package core
+
+import "runtime"
+
+// loggerLifetime is shared by a logger, the loggers derived from it and the
+// Config copies returned by its Config method, which is how FromLogger
+// handlers hold it. Senders never reference it: their goroutines keep them
+// reachable forever, so a finalizer on a sender would never run.
+type loggerLifetime struct {
+	// Holding pointers also keeps it out of the tiny allocator, whose
+	// objects may never be finalized
+	sender *Sender
+	audit  *Sender
+}
+
+// shutdownOnGC shuts down the logger's senders once no logger, derived logger
+// or FromLogger handler can reach them, if Config.ShutdownOnGC is set.
+func (l *LogBullLogger) shutdownOnGC() {
+	if !l.config.ShutdownOnGC || l.sender == nil {
+		return
+	}
+
+	l.lifetime = &loggerLifetime{sender: l.sender, audit: l.audit}
+	runtime.SetFinalizer(l.lifetime, (*loggerLifetime).shutdown)
+}
+
+func (lt *loggerLifetime) shutdown() {
+	// Finalizers share one goroutine; Shutdown may take ShutdownTimeout
+	go func() {
+		lt.sender.Shutdown()
+		if lt.audit != lt.sender {
+			lt.audit.Shutdown()
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestActiveSenders(t *testing.T) {
+	before := ActiveSenders()
+
+	sender, err := NewSender(&Config{Transport: &captureTransport{}})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	if got := ActiveSenders(); got != before+1 {
+		t.Errorf("ActiveSenders() = %d, want %d", got, before+1)
+	}
+
+	sender.Shutdown()
+	if got := ActiveSenders(); got != before {
+		t.Errorf("ActiveSenders() after Shutdown = %d, want %d", got, before)
+	}
+}
+
+// collectUntilStopped runs the garbage collector until sender is shut down
+// or the timeout passes, and reports whether it was.
+func collectUntilStopped(sender *Sender, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case <-sender.stopCh:
+			// Wait for Shutdown to finish delivering
+			sender.Shutdown()
+			return true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+func TestLogBullLogger_ShutdownOnGC(t *testing.T) {
+	transport := &captureTransport{}
+	sender := func() *Sender {
+		logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, ShutdownOnGC: true})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		logger.WithField("k", "v").Info("forgotten", nil)
+		return logger.Sender()
+	}()
+
+	if !collectUntilStopped(sender, 2*time.Second) {
+		t.Fatal("Sender of a collected logger was not shut down")
+	}
+	if entries := transport.all(); len(entries) != 1 || entries[0].Message != "forgotten" {
+		t.Errorf("Delivered %+v, want the queued entry", entries)
+	}
+}
+
+func TestLogBullLogger_ShutdownOnGCKeptByConfig(t *testing.T) {
+	logger, err := NewLogger(Config{Transport: &captureTransport{}, ConsoleFormat: ConsoleDisabled, ShutdownOnGC: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	sender := logger.Sender()
+	defer sender.Shutdown()
+
+	// What FromLogger handlers keep
+	config := logger.Config()
+	logger = nil
+
+	if collectUntilStopped(sender, 100*time.Millisecond) {
+		t.Error("Sender was shut down while a Config from its logger was reachable")
+	}
+	runtime.KeepAlive(config)
+
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Errorf("FlushSync() error = %v", err)
+	}
+}
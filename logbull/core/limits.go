@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+// TruncatedField is set on entries shortened to fit Config.MaxEntryBytes.
+const TruncatedField = "truncated"
+
+// limitEntrySize shrinks an entry whose JSON encoding exceeds maxBytes: the
+// largest field values are replaced with formatting.TruncationMarker until it
+// fits, then the message is cut.
+func limitEntrySize(entry LogEntry, maxBytes int) LogEntry {
+	data, err := json.Marshal(entry)
+	if err != nil || len(data) <= maxBytes {
+		return entry
+	}
+
+	type fieldSize struct {
+		key  string
+		size int
+	}
+
+	sizes := make([]fieldSize, 0, len(entry.Fields))
+	fields := make(map[string]any, len(entry.Fields)+1)
+	for key, value := range entry.Fields {
+		fields[key] = value
+		if encoded, err := json.Marshal(value); err == nil {
+			sizes = append(sizes, fieldSize{key, len(encoded)})
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].size != sizes[j].size {
+			return sizes[i].size > sizes[j].size
+		}
+		return sizes[i].key < sizes[j].key
+	})
+
+	fields[TruncatedField] = true
+	excess := len(data) - maxBytes + len(`,"`+TruncatedField+`":true`)
+
+	markerSize := len(formatting.TruncationMarker) + 2
+	for _, field := range sizes {
+		if excess <= 0 {
+			break
+		}
+		if field.size <= markerSize {
+			continue
+		}
+		fields[field.key] = formatting.TruncationMarker
+		excess -= field.size - markerSize
+	}
+
+	if excess > 0 {
+		entry.Message, _ = formatting.TruncateString(entry.Message, max(len(entry.Message)-excess, 0))
+	}
+
+	entry.Fields = fields
+	return entry
+}
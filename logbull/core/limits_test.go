@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+func TestLimitEntrySize(t *testing.T) {
+	entry := LogEntry{
+		Level:     "INFO",
+		Message:   "request finished",
+		Timestamp: "2024-03-01T12:30:00Z",
+		Fields: map[string]any{
+			"body":    strings.Repeat("b", 4000),
+			"headers": map[string]any{"cookie": strings.Repeat("c", 2000)},
+			"status":  200,
+		},
+	}
+
+	limited := limitEntrySize(entry, 1024)
+
+	data, _ := json.Marshal(limited)
+	if len(data) > 1024 {
+		t.Errorf("Encoded size = %d, want at most 1024", len(data))
+	}
+	if limited.Fields["body"] != formatting.TruncationMarker {
+		t.Errorf("body = %v, want the truncation marker", limited.Fields["body"])
+	}
+	if limited.Fields["status"] != 200 || limited.Message != "request finished" {
+		t.Errorf("Small fields and the message should be kept: %+v", limited)
+	}
+	if limited.Fields[TruncatedField] != true {
+		t.Error("Expected the truncated field")
+	}
+	if entry.Fields["body"] == formatting.TruncationMarker {
+		t.Error("limitEntrySize() modified the original fields")
+	}
+
+	t.Run("cuts the message last", func(t *testing.T) {
+		limited := limitEntrySize(LogEntry{Level: "INFO", Message: strings.Repeat("m", 5000)}, 1024)
+		if data, _ := json.Marshal(limited); len(data) > 1024 {
+			t.Errorf("Encoded size = %d, want at most 1024", len(data))
+		}
+		if !strings.HasSuffix(limited.Message, formatting.TruncationMarker) {
+			t.Errorf("Message should end with the truncation marker")
+		}
+	})
+
+	t.Run("leaves small entries alone", func(t *testing.T) {
+		small := LogEntry{Level: "INFO", Message: "ok", Fields: map[string]any{"a": 1}}
+		if got := limitEntrySize(small, 1024); len(got.Fields) != 1 {
+			t.Errorf("Fields = %v, want unchanged", got.Fields)
+		}
+	})
+}
+
+func TestSender_ValueLimits(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:           transport,
+		ConsoleFormat:       ConsoleDisabled,
+		MaxFieldValueLength: 64,
+		MaxEntryBytes:       2048,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	fields := map[string]any{"sql": strings.Repeat("s", 1000)}
+	for i := 0; i < 40; i++ {
+		fields[strings.Repeat("k", 10)+string(rune('a'+i%26))+string(rune('a'+i/26))] = strings.Repeat("v", 60)
+	}
+	logger.Info("slow query", fields)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if sql, _ := entries[0].Fields["sql"].(string); len(sql) > 64 {
+		t.Errorf("sql has %d bytes, want at most 64", len(sql))
+	}
+	if data, _ := json.Marshal(entries[0]); len(data) > 2048 {
+		t.Errorf("Encoded size = %d, want at most 2048", len(data))
+	}
+	if len(fields["sql"].(string)) != 1000 {
+		t.Error("The caller's fields must not be modified")
+	}
+}
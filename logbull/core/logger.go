@@ -1,52 +1,70 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/logbull/logbull-go/logbull/fields"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
 	"github.com/logbull/logbull-go/logbull/internal/formatting"
 	"github.com/logbull/logbull-go/logbull/internal/validation"
 )
 
 type LogBullLogger struct {
-	config   *Config
-	sender   *Sender
-	minLevel LogLevel
-	context  map[string]any
-	mu       sync.RWMutex
+	config     *Config
+	sender     *Sender
+	audit      *Sender
+	minLevel   *levelVar
+	timestamps *uniqueTimestamps
+	context    map[string]any
+	name       string
+	frozen     bool
+	mu         sync.RWMutex
+
+	// lifetime is set when Config.ShutdownOnGC is
+	lifetime *loggerLifetime
 }
 
 func NewLogger(config Config) (*LogBullLogger, error) {
-	config.ProjectID = strings.TrimSpace(config.ProjectID)
-	config.Host = strings.TrimSpace(config.Host)
-	config.APIKey = strings.TrimSpace(config.APIKey)
+	config = normalizeConfig(config)
+	// A Config obtained from another logger must not keep that one alive
+	config.lifetime = nil
 
 	if config.LogLevel == "" {
 		config.LogLevel = INFO
 	}
 
+	if err := config.Schema.check(); err != nil {
+		return nil, fmt.Errorf("invalid Schema: %w", err)
+	}
+
 	// Check if credentials are provided
-	if config.ProjectID == "" || config.Host == "" {
+	if config.consoleOnly() {
 		// Console-only mode: no credentials provided
-		fmt.Println(
-			"LogBull: No credentials provided. Running in console-only mode. Logs will only be printed to the console and not sent to LogBull server.",
-		)
+		config.notice("No credentials provided. Running in console-only mode. Logs will only be printed to the console and not sent to LogBull server.")
 		return &LogBullLogger{
-			config:   &config,
-			sender:   nil,
-			minLevel: config.LogLevel,
-			context:  make(map[string]any),
+			config:     &config,
+			sender:     nil,
+			minLevel:   newLevelVar(config.LogLevel),
+			timestamps: newUniqueTimestamps(&config),
+			context:    make(map[string]any),
 		}, nil
 	}
 
-	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
-		return nil, err
-	}
+	// A custom transport does not talk to a LogBull server
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
 
-	if err := validation.ValidateHostURL(config.Host); err != nil {
-		return nil, err
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
 	}
 
 	if config.APIKey != "" {
@@ -59,13 +77,76 @@ func NewLogger(config Config) (*LogBullLogger, error) {
 	if err != nil {
 		return nil, err
 	}
+	sender.consoleMirrored = config.ConsoleFormat != ConsoleDisabled
 
-	return &LogBullLogger{
-		config:   &config,
-		sender:   sender,
-		minLevel: config.LogLevel,
-		context:  make(map[string]any),
-	}, nil
+	audit := sender
+	if config.AuditProjectID != "" {
+		if audit, err = newAuditSender(config); err != nil {
+			sender.Shutdown()
+			return nil, err
+		}
+	}
+
+	logger := &LogBullLogger{
+		config:     &config,
+		sender:     sender,
+		audit:      audit,
+		minLevel:   newLevelVar(config.LogLevel),
+		timestamps: newUniqueTimestamps(&config),
+		context:    make(map[string]any),
+	}
+	logger.shutdownOnGC()
+	return logger, nil
+}
+
+// newAuditSender creates the sender for Config.AuditProjectID.
+func newAuditSender(config Config) (*Sender, error) {
+	config.ProjectID = strings.TrimSpace(config.AuditProjectID)
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, fmt.Errorf("invalid audit project ID: %w", err)
+		}
+	}
+
+	audit, err := NewSender(&config)
+	if err != nil {
+		return nil, err
+	}
+	audit.consoleMirrored = config.ConsoleFormat != ConsoleDisabled
+	return audit, nil
+}
+
+// levelVar is the minimum level shared by a logger and the loggers derived
+// from it, so SetLevel applies to all of them.
+type levelVar struct {
+	level atomic.Pointer[LogLevel]
+}
+
+func newLevelVar(level LogLevel) *levelVar {
+	v := &levelVar{}
+	v.Store(level)
+	return v
+}
+
+func (v *levelVar) Load() LogLevel {
+	return *v.level.Load()
+}
+
+func (v *levelVar) Store(level LogLevel) {
+	v.level.Store(&level)
+}
+
+// Sender returns the logger's sender, which handlers created with the
+// FromLogger constructors share, or nil in console-only mode.
+func (l *LogBullLogger) Sender() *Sender {
+	return l.sender
+}
+
+// Config returns a copy of the configuration the logger was created with.
+func (l *LogBullLogger) Config() Config {
+	config := *l.config
+	config.lifetime = l.lifetime
+	return config
 }
 
 func (l *LogBullLogger) Debug(message string, fields map[string]any) {
@@ -88,80 +169,410 @@ func (l *LogBullLogger) Critical(message string, fields map[string]any) {
 	l.log(CRITICAL, message, fields)
 }
 
+// DebugF, InfoF, WarningF, ErrorF and CriticalF take typed fields instead of
+// a map, e.g. logger.InfoF("saved", fields.String("id", id)).
+func (l *LogBullLogger) DebugF(message string, fs ...fields.Field) {
+	l.log(DEBUG, message, fields.ToMap(fs))
+}
+
+func (l *LogBullLogger) InfoF(message string, fs ...fields.Field) {
+	l.log(INFO, message, fields.ToMap(fs))
+}
+
+func (l *LogBullLogger) WarningF(message string, fs ...fields.Field) {
+	l.log(WARNING, message, fields.ToMap(fs))
+}
+
+func (l *LogBullLogger) ErrorF(message string, fs ...fields.Field) {
+	l.log(ERROR, message, fields.ToMap(fs))
+}
+
+func (l *LogBullLogger) CriticalF(message string, fs ...fields.Field) {
+	l.log(CRITICAL, message, fields.ToMap(fs))
+}
+
+// Audit logs a compliance event tagged with "type": "audit". It ignores
+// LogLevel, waits for room instead of being dropped when the queue is full,
+// and goes to Config.AuditProjectID when one is set.
+func (l *LogBullLogger) Audit(message string, fields map[string]any) {
+	if err := l.TryAudit(message, fields); err != nil && !errors.Is(err, ErrSenderShutdown) {
+		l.config.reportError(err, map[string]any{"operation": "audit"})
+	}
+}
+
+func (l *LogBullLogger) TryAudit(message string, fields map[string]any) (err error) {
+	defer recoverLogPanic(&err)
+
+	entry, err := l.buildEntry(time.Time{}, INFO, message, fields)
+	if err != nil {
+		return err
+	}
+	entry.Fields[AuditTypeField] = AuditType
+
+	l.printToConsole(entry)
+
+	if l.audit != nil {
+		return l.audit.addAudit(entry)
+	}
+	return nil
+}
+
+func (l *LogBullLogger) TryDebug(message string, fields map[string]any) error {
+	return l.tryLog(DEBUG, message, fields)
+}
+
+func (l *LogBullLogger) TryInfo(message string, fields map[string]any) error {
+	return l.tryLog(INFO, message, fields)
+}
+
+func (l *LogBullLogger) TryWarning(message string, fields map[string]any) error {
+	return l.tryLog(WARNING, message, fields)
+}
+
+func (l *LogBullLogger) TryError(message string, fields map[string]any) error {
+	return l.tryLog(ERROR, message, fields)
+}
+
+func (l *LogBullLogger) TryCritical(message string, fields map[string]any) error {
+	return l.tryLog(CRITICAL, message, fields)
+}
+
+// WithFields returns a new logger holding an immutable snapshot of the
+// receiver's context merged with the given fields. The receiver is never
+// modified, so both loggers can be used from any goroutine.
+func (l *LogBullLogger) WithFields(fields map[string]any) *LogBullLogger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.derive(formatting.MergeFields(l.context, fields))
+}
+
+// WithContext is the same as WithFields.
+//
+// Deprecated: Use WithFields, or WithCtx to take fields from a
+// context.Context.
 func (l *LogBullLogger) WithContext(context map[string]any) *LogBullLogger {
+	return l.WithFields(context)
+}
+
+// AddContext merges fields into the receiver's own context, e.g. to add an
+// instance ID resolved after startup to a long-lived logger. Loggers derived
+// earlier keep their snapshot. It returns ErrLoggerFrozen on a frozen logger.
+func (l *LogBullLogger) AddContext(fields map[string]any) error {
+	if l.frozen {
+		return ErrLoggerFrozen
+	}
+	if err := validation.ValidateLogFields(fields); err != nil {
+		return fmt.Errorf("invalid context fields: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.context = formatting.MergeFields(l.context, fields)
+	return nil
+}
+
+func (l *LogBullLogger) WithField(key string, value any) *LogBullLogger {
+	return l.WithFields(map[string]any{key: value})
+}
+
+// WithError returns a derived logger with the error message under the
+// "error" field and its Go type under "error_type". A nil error returns the
+// receiver unchanged.
+func (l *LogBullLogger) WithError(err error) *LogBullLogger {
+	if err == nil {
+		return l
+	}
+	return l.WithFields(map[string]any{
+		ErrorField:     err.Error(),
+		ErrorTypeField: errorType(err),
+	})
+}
+
+// errorType names the type of err, looking through fmt.Errorf wrappers,
+// which say nothing about the failure itself.
+func errorType(err error) string {
+	for {
+		name := fmt.Sprintf("%T", err)
+		inner := errors.Unwrap(err)
+		if name != "*fmt.wrapError" || inner == nil {
+			return name
+		}
+		err = inner
+	}
+}
+
+// Freeze returns a copy of the logger that rejects operations mutating shared
+// state, such as SetHost and SetLevel. Loggers derived from a frozen logger
+// stay frozen. Use it for base loggers shared across packages.
+func (l *LogBullLogger) Freeze() *LogBullLogger {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	mergedContext := formatting.MergeFields(l.context, context)
+	frozen := l.derive(formatting.MergeFields(l.context, nil))
+	frozen.frozen = true
+	return frozen
+}
 
+func (l *LogBullLogger) IsFrozen() bool {
+	return l.frozen
+}
+
+func (l *LogBullLogger) derive(context map[string]any) *LogBullLogger {
 	return &LogBullLogger{
-		config:   l.config,
-		sender:   l.sender,
-		minLevel: l.minLevel,
-		context:  mergedContext,
+		config:     l.config,
+		sender:     l.sender,
+		audit:      l.audit,
+		minLevel:   l.minLevel,
+		timestamps: l.timestamps,
+		context:    context,
+		name:       l.name,
+		frozen:     l.frozen,
+		lifetime:   l.lifetime,
+	}
+}
+
+// Named returns a derived logger whose entries carry the component name in
+// the "logger" field. Names of nested loggers are joined with dots, so
+// logger.Named("payments").Named("checkout") is named "payments.checkout".
+func (l *LogBullLogger) Named(name string) *LogBullLogger {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return l
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	named := l.WithFields(map[string]any{LoggerNameField: name})
+	named.name = name
+	return named
+}
+
+// Name returns the name set with Named, or "" for an unnamed logger.
+func (l *LogBullLogger) Name() string {
+	return l.name
+}
+
+func (l *LogBullLogger) WithRetention(retention time.Duration) *LogBullLogger {
+	return l.WithFields(map[string]any{RetentionField: retentionSeconds(retention)})
+}
+
+// Level returns the minimum level of entries the logger sends.
+func (l *LogBullLogger) Level() LogLevel {
+	return l.minLevel.Load()
+}
+
+// SetLevel changes the minimum level at runtime for the logger and every
+// logger derived from the same root. It returns ErrLoggerFrozen on a frozen
+// logger.
+func (l *LogBullLogger) SetLevel(level LogLevel) error {
+	if l.frozen {
+		return ErrLoggerFrozen
 	}
+	if level.Priority() == 0 {
+		return fmt.Errorf("invalid log level '%s'", level)
+	}
+	l.minLevel.Store(level)
+	return nil
+}
+
+func (l *LogBullLogger) SetHost(host string) error {
+	if l.frozen {
+		return ErrLoggerFrozen
+	}
+	if l.sender == nil {
+		return fmt.Errorf("cannot set host: logger is running in console-only mode")
+	}
+	if err := l.sender.SetHost(host); err != nil {
+		return err
+	}
+	if l.audit != l.sender {
+		return l.audit.SetHost(host)
+	}
+	return nil
+}
+
+// Ping checks that the LogBull server is reachable and accepts the configured
+// credentials. Call it at startup to fail fast on a wrong ProjectID or APIKey.
+func (l *LogBullLogger) Ping(ctx context.Context) error {
+	if l.sender == nil {
+		return fmt.Errorf("cannot ping: logger is running in console-only mode")
+	}
+	return l.sender.Ping(ctx)
 }
 
 func (l *LogBullLogger) Flush() {
 	if l.sender != nil {
 		l.sender.Flush()
 	}
+	if l.audit != l.sender {
+		l.audit.Flush()
+	}
+}
+
+// FlushSync sends all queued logs and blocks until they are delivered or ctx
+// is done. Use it before process exit or in tests instead of sleeping.
+func (l *LogBullLogger) FlushSync(ctx context.Context) error {
+	if l.sender == nil {
+		return nil
+	}
+	if l.audit != l.sender {
+		if err := l.audit.FlushSync(ctx); err != nil {
+			return err
+		}
+	}
+	return l.sender.FlushSync(ctx)
+}
+
+// Stats reports the sender's queue and worker state. It returns the zero
+// value in console-only mode.
+func (l *LogBullLogger) Stats() Stats {
+	if l.sender == nil {
+		return Stats{}
+	}
+	return l.sender.Stats()
+}
+
+// Capabilities returns what the server reported when
+// Config.NegotiateCapabilities is set, and false until it has answered.
+func (l *LogBullLogger) Capabilities() (ServerCapabilities, bool) {
+	if l.sender == nil {
+		return ServerCapabilities{}, false
+	}
+	return l.sender.Capabilities()
 }
 
 func (l *LogBullLogger) Shutdown() {
-	if l.sender != nil {
-		l.sender.Shutdown()
+	l.ShutdownWithReport()
+}
+
+// ShutdownWithReport stops the logger like Shutdown and reports how many
+// queued logs were flushed and abandoned after ShutdownTimeout.
+func (l *LogBullLogger) ShutdownWithReport() ShutdownReport {
+	if l.sender == nil {
+		return ShutdownReport{}
 	}
+
+	report := l.sender.ShutdownWithReport()
+	if l.audit != l.sender {
+		audit := l.audit.ShutdownWithReport()
+		report.Flushed += audit.Flushed
+		report.Abandoned += audit.Abandoned
+	}
+	return report
 }
 
 func (l *LogBullLogger) log(level LogLevel, message string, fields map[string]any) {
-	if level.Priority() < l.minLevel.Priority() {
-		return
+	if err := l.tryLog(level, message, fields); err != nil && !errors.Is(err, ErrSenderShutdown) {
+		l.config.reportError(err, map[string]any{"operation": "log", "level": level.String()})
 	}
+}
 
-	if err := validation.ValidateLogMessage(message); err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: invalid log message: %v\n", err)
-		return
+// LogAt logs an entry with an explicit timestamp, e.g. when replaying
+// historical logs from a file. A zero time uses the current time.
+func (l *LogBullLogger) LogAt(t time.Time, level LogLevel, message string, fields map[string]any) {
+	if err := l.TryLogAt(t, level, message, fields); err != nil && !errors.Is(err, ErrSenderShutdown) {
+		l.config.reportError(err, map[string]any{"operation": "log", "level": level.String()})
 	}
+}
 
-	if err := validation.ValidateLogFields(fields); err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: invalid log fields: %v\n", err)
-		return
+func (l *LogBullLogger) TryLogAt(t time.Time, level LogLevel, message string, fields map[string]any) error {
+	if _, ok := levelPriority[level]; !ok {
+		return fmt.Errorf("invalid log level '%s'", level)
 	}
+	return l.tryLogAt(t, level, message, fields)
+}
 
-	l.mu.RLock()
-	mergedFields := formatting.MergeFields(l.context, fields)
-	l.mu.RUnlock()
+func (l *LogBullLogger) tryLog(level LogLevel, message string, fields map[string]any) error {
+	return l.tryLogAt(time.Time{}, level, message, fields)
+}
 
-	entry := LogEntry{
-		Level:     level.String(),
-		Message:   formatting.FormatMessage(message),
-		Timestamp: GenerateUniqueTimestamp(),
-		Fields:    formatting.EnsureFields(mergedFields),
+func (l *LogBullLogger) tryLogAt(t time.Time, level LogLevel, message string, fields map[string]any) (err error) {
+	defer recoverLogPanic(&err)
+
+	if level.Priority() < l.minLevel.Load().Priority() {
+		return nil
+	}
+
+	entry, err := l.buildEntry(t, level, message, fields)
+	if err != nil {
+		return err
 	}
 
 	l.printToConsole(entry)
 
 	// Only send to LogBull server if not in console-only mode
 	if l.sender != nil {
-		l.sender.AddLog(entry)
+		if err := l.sender.tryAdd(context.Background(), entry, true); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func (l *LogBullLogger) printToConsole(entry LogEntry) {
-	output := fmt.Sprintf("[%s] [%s] %s", entry.Timestamp, entry.Level, entry.Message)
+// buildEntry validates the message and fields and merges them with the
+// logger's context into an entry owned by the caller.
+func (l *LogBullLogger) buildEntry(t time.Time, level LogLevel, message string, fields map[string]any) (LogEntry, error) {
+	if err := validation.ValidateLogMessage(message); err != nil {
+		return LogEntry{}, fmt.Errorf(
+			"invalid log message: %w (level=%s %s)",
+			err,
+			level,
+			formatting.PreviewEntry(message, fields),
+		)
+	}
+
+	if err := validation.ValidateLogFields(fields); err != nil {
+		return LogEntry{}, fmt.Errorf(
+			"invalid log fields: %w (level=%s %s)",
+			err,
+			level,
+			formatting.PreviewEntry(message, fields),
+		)
+	}
+
+	l.mu.RLock()
+	mergedFields := formatting.MergeFields(l.context, fields)
+	l.mu.RUnlock()
 
-	if len(entry.Fields) > 0 {
-		var fields []string
-		for k, v := range entry.Fields {
-			fields = append(fields, fmt.Sprintf("%s=%v", k, v))
+	if err := l.config.Schema.Validate(LogEntry{Level: level.String(), Message: message, Fields: mergedFields}); err != nil {
+		return LogEntry{}, err
+	}
+
+	if l.config.IncludeCaller {
+		if frame, ok := callsite.Capture(0); ok {
+			callsite.AddFields(mergedFields, frame.Function, frame.File, frame.Line)
+		}
+	}
+
+	if !l.config.DisableMessageTemplates {
+		if rendered, ok := formatting.RenderTemplate(message, mergedFields); ok {
+			if _, exists := mergedFields[MessageTemplateField]; !exists {
+				mergedFields[MessageTemplateField] = formatting.FormatMessage(message)
+			}
+			message = rendered
 		}
-		output += fmt.Sprintf(" (%s)", strings.Join(fields, ", "))
 	}
 
-	if entry.Level == "ERROR" || entry.Level == "CRITICAL" {
-		fmt.Fprintln(os.Stderr, output)
-	} else {
-		fmt.Println(output)
+	mergedFields = formatting.NormalizeKeys(mergedFields, l.config.FieldKeys)
+
+	timestamp := ""
+	switch {
+	case !t.IsZero():
+		timestamp = FormatTimestamp(t)
+	case l.config.TimestampStrategy == TimestampSequence:
+		timestamp = FormatTimestamp(l.config.clock().Now())
+	default:
+		timestamp = l.timestamps.next(l.config.clock().Now())
 	}
+
+	return LogEntry{
+		Level:     level.String(),
+		Message:   formatting.FormatMessage(message),
+		Timestamp: timestamp,
+		Fields:    mergedFields,
+	}, nil
 }
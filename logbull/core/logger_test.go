@@ -1,14 +1,19 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/logbull/logbull-go/logbull/fields"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -34,8 +39,8 @@ func TestNewLogger(t *testing.T) {
 			ProjectID: "invalid",
 			Host:      "http://localhost:4005",
 		})
-		if err == nil {
-			t.Error("NewLogger() expected error for invalid project ID")
+		if !errors.Is(err, ErrInvalidProjectID) {
+			t.Errorf("NewLogger() error = %v, want ErrInvalidProjectID", err)
 		}
 	})
 
@@ -44,8 +49,8 @@ func TestNewLogger(t *testing.T) {
 			ProjectID: "12345678-1234-1234-1234-123456789012",
 			Host:      "invalid",
 		})
-		if err == nil {
-			t.Error("NewLogger() expected error for invalid host")
+		if !errors.Is(err, ErrInvalidHost) {
+			t.Errorf("NewLogger() error = %v, want ErrInvalidHost", err)
 		}
 	})
 
@@ -57,8 +62,8 @@ func TestNewLogger(t *testing.T) {
 		if err != nil {
 			t.Errorf("NewLogger() error = %v", err)
 		}
-		if logger.minLevel != INFO {
-			t.Errorf("NewLogger() default log level = %v, want INFO", logger.minLevel)
+		if logger.Level() != INFO {
+			t.Errorf("NewLogger() default log level = %v, want INFO", logger.Level())
 		}
 		defer logger.Shutdown()
 	})
@@ -160,7 +165,9 @@ func TestLogBullLogger_LevelFiltering(t *testing.T) {
 	logger.Error("should pass", nil)
 	logger.Critical("should pass", nil)
 
-	time.Sleep(100 * time.Millisecond)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogBullLogger_WithContext(t *testing.T) {
@@ -203,6 +210,360 @@ func TestLogBullLogger_WithContext(t *testing.T) {
 	})
 }
 
+func TestLogBullLogger_WithRetention(t *testing.T) {
+	logger, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	auditLogger := logger.WithRetention(365 * 24 * time.Hour)
+
+	if auditLogger.context[RetentionField] != int64(365*24*60*60) {
+		t.Errorf("WithRetention() field = %v, want one year in seconds", auditLogger.context[RetentionField])
+	}
+	if _, ok := logger.context[RetentionField]; ok {
+		t.Error("WithRetention() must not modify the parent logger")
+	}
+}
+
+func TestLogBullLogger_LogAt(t *testing.T) {
+	var mu sync.Mutex
+	var received []LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	logger, err := NewLogger(Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		ConsoleFormat: ConsoleDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	historical := time.Date(2023, 11, 5, 8, 0, 0, 0, time.FixedZone("CET", 3600))
+	logger.LogAt(historical, WARNING, "replayed", map[string]any{"line": 12})
+	logger.LogAt(historical, DEBUG, "filtered", nil)
+
+	if err := logger.TryLogAt(historical, LogLevel("VERBOSE"), "bad level", nil); err == nil {
+		t.Error("TryLogAt() expected error for unknown level")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(received))
+	}
+	if received[0].Timestamp != "2023-11-05T07:00:00.000000000Z" {
+		t.Errorf("Timestamp = %s, want the given time in UTC", received[0].Timestamp)
+	}
+	if received[0].Level != "WARNING" {
+		t.Errorf("Level = %s, want WARNING", received[0].Level)
+	}
+}
+
+func TestLogBullLogger_MessageTemplates(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	logger.WithField("user_id", "user_42").Info("user {user_id} logged in from {ip}", map[string]any{"ip": "10.0.0.1"})
+	logger.Info("no placeholders", map[string]any{"ip": "10.0.0.1"})
+	logger.Info("unknown {placeholder}", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	if entries[0].Message != "user user_42 logged in from 10.0.0.1" {
+		t.Errorf("Message = %q, want placeholders substituted", entries[0].Message)
+	}
+	if entries[0].Fields[MessageTemplateField] != "user {user_id} logged in from {ip}" {
+		t.Errorf("%s = %v, want the template", MessageTemplateField, entries[0].Fields[MessageTemplateField])
+	}
+	for _, entry := range entries[1:] {
+		if _, ok := entry.Fields[MessageTemplateField]; ok {
+			t.Errorf("Unexpected %s for %q", MessageTemplateField, entry.Message)
+		}
+	}
+	if entries[2].Message != "unknown {placeholder}" {
+		t.Errorf("Message = %q, want unknown placeholders kept", entries[2].Message)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		transport := &captureTransport{}
+		logger, err := NewLogger(Config{
+			Transport:               transport,
+			ConsoleFormat:           ConsoleDisabled,
+			DisableMessageTemplates: true,
+		})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		defer logger.Shutdown()
+
+		logger.Info("user {user_id}", map[string]any{"user_id": "user_42"})
+		logger.FlushSync(context.Background())
+
+		entries := transport.all()
+		if len(entries) != 1 || entries[0].Message != "user {user_id}" {
+			t.Errorf("Entries = %v, want the message unchanged", entries)
+		}
+	})
+}
+
+func TestLogBullLogger_FieldKeys(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		FieldKeys:     FieldKeyPolicy{SnakeCase: true},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.WithField("requestId", "r-1").Info("user {userId} signed in", map[string]any{"userId": "u-1"})
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Fields["request_id"] != "r-1" || entry.Fields["user_id"] != "u-1" || entry.Fields["userId"] != nil {
+		t.Errorf("Fields = %v, want snake_case keys", entry.Fields)
+	}
+	if entry.Message != "user u-1 signed in" {
+		t.Errorf("Message = %q, want the template rendered with the logged keys", entry.Message)
+	}
+}
+
+func TestLogBullLogger_Named(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	payments := logger.Named("payments")
+	checkout := payments.Named("checkout").WithField("order_id", 7)
+
+	if logger.Named("  ") != logger {
+		t.Error("Named(\"\") should return the receiver")
+	}
+	if checkout.Name() != "payments.checkout" {
+		t.Errorf("Name() = %q, want payments.checkout", checkout.Name())
+	}
+
+	payments.Info("payments", nil)
+	checkout.Info("checkout", nil)
+	logger.Info("root", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Fields[LoggerNameField] != "payments" {
+		t.Errorf("%s = %v, want payments", LoggerNameField, entries[0].Fields[LoggerNameField])
+	}
+	if entries[1].Fields[LoggerNameField] != "payments.checkout" || entries[1].Fields["order_id"] != 7 {
+		t.Errorf("Fields = %v, want the nested name and context", entries[1].Fields)
+	}
+	if _, ok := entries[2].Fields[LoggerNameField]; ok {
+		t.Errorf("Unexpected %s on the root logger", LoggerNameField)
+	}
+}
+
+func TestLogBullLogger_IncludeCaller(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		IncludeCaller: true,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info("direct", nil)
+	logger.WithField("k", "v").TryWarning("derived", nil)
+	logger.LogAt(time.Now(), ERROR, "log at", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if function, _ := entry.Fields[CallerFunctionField].(string); !strings.HasSuffix(function, ".TestLogBullLogger_IncludeCaller") {
+			t.Errorf("%q: %s = %v", entry.Message, CallerFunctionField, entry.Fields[CallerFunctionField])
+		}
+		if entry.Fields[CallerFileField] != file {
+			t.Errorf("%q: %s = %v, want %s", entry.Message, CallerFileField, entry.Fields[CallerFileField], file)
+		}
+		if entry.Fields[CallerLineField] != line+1+i {
+			t.Errorf("%q: %s = %v, want %d", entry.Message, CallerLineField, entry.Fields[CallerLineField], line+1+i)
+		}
+	}
+}
+
+func TestLogBullLogger_FieldChaining(t *testing.T) {
+	logger, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	chained := logger.
+		WithField("user_id", "42").
+		WithFields(map[string]any{"order_id": 7, "user_id": "43"}).
+		WithError(errors.New("payment declined"))
+
+	expected := map[string]any{
+		"user_id":  "43",
+		"order_id": 7,
+		"error":    "payment declined",
+	}
+	for key, value := range expected {
+		if chained.context[key] != value {
+			t.Errorf("context[%s] = %v, want %v", key, chained.context[key], value)
+		}
+	}
+
+	if len(logger.context) != 0 {
+		t.Errorf("Chaining must not modify the parent logger, got context %v", logger.context)
+	}
+
+	if logger.WithError(nil) != logger {
+		t.Error("WithError(nil) should return the receiver")
+	}
+}
+
+func TestLogBullLogger_TypedFields(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		LogLevel:      DEBUG,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.WithField("request_id", "r1").InfoF("saved {id}",
+		fields.String("id", "42"),
+		fields.Int("attempt", 2),
+		fields.Err(nil),
+	)
+	logger.DebugF("debug")
+	logger.WarningF("warning")
+	logger.ErrorF("error", fields.Err(errors.New("boom")))
+	logger.CriticalF("critical")
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 5 {
+		t.Fatalf("Expected 5 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Message != "saved 42" {
+		t.Errorf("Message = %q, want saved 42", first.Message)
+	}
+	expected := map[string]any{"id": "42", "attempt": 2, "request_id": "r1"}
+	for key, value := range expected {
+		if first.Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, first.Fields[key], value)
+		}
+	}
+	if _, ok := first.Fields[ErrorField]; ok {
+		t.Error("A nil error should not add an error field")
+	}
+
+	if entries[3].Level != "ERROR" || entries[3].Fields[ErrorField] != "boom" {
+		t.Errorf("ErrorF entry = %+v", entries[3])
+	}
+}
+
+func TestLogBullLogger_TimestampSequence(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:         transport,
+		ConsoleFormat:     ConsoleDisabled,
+		TimestampStrategy: TimestampSequence,
+		SourceID:          "worker-1",
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	processTimestamps.mu.Lock()
+	lastUnique := processTimestamps.lastNs
+	processTimestamps.mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		logger.Info("burst", nil)
+	}
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	processTimestamps.mu.Lock()
+	defer processTimestamps.mu.Unlock()
+	if processTimestamps.lastNs != lastUnique {
+		t.Error("TimestampSequence should not use GenerateUniqueTimestamp")
+	}
+
+	entries := transport.all()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Fields["sequence"] != uint64(i+1) || entry.Fields["source_id"] != "worker-1" {
+			t.Errorf("entry %d: sequence = %v, source_id = %v", i, entry.Fields["sequence"], entry.Fields["source_id"])
+		}
+		if _, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err != nil {
+			t.Errorf("entry %d: invalid timestamp %q", i, entry.Timestamp)
+		}
+	}
+
+	if _, err := NewLogger(Config{Transport: transport, TimestampStrategy: "random"}); err == nil {
+		t.Error("NewLogger() expected error for invalid TimestampStrategy")
+	}
+}
+
 func TestLogBullLogger_ContextMerging(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -237,6 +598,201 @@ func TestLogBullLogger_ContextMerging(t *testing.T) {
 	}
 }
 
+func TestLogBullLogger_AddContext(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	before := logger.WithField("component", "db")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			if err := logger.AddContext(map[string]any{fmt.Sprintf("key_%d", id): id}); err != nil {
+				t.Errorf("AddContext() error = %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			logger.Info("concurrent", nil)
+		}()
+	}
+	wg.Wait()
+
+	if len(logger.context) != 20 {
+		t.Errorf("context has %d fields, want 20", len(logger.context))
+	}
+	if len(before.context) != 1 {
+		t.Errorf("AddContext must not modify derived loggers, got %v", before.context)
+	}
+
+	if err := logger.Freeze().AddContext(map[string]any{"k": "v"}); !errors.Is(err, ErrLoggerFrozen) {
+		t.Errorf("AddContext() on frozen logger error = %v, want ErrLoggerFrozen", err)
+	}
+	if err := logger.AddContext(map[string]any{"": "v"}); err == nil {
+		t.Error("AddContext() expected error for invalid fields")
+	}
+}
+
+func TestLogBullLogger_Audit(t *testing.T) {
+	t.Run("bypasses level filtering", func(t *testing.T) {
+		transport := &captureTransport{}
+		logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, LogLevel: CRITICAL})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		defer logger.Shutdown()
+
+		logger.Info("filtered", nil)
+		logger.WithField("user_id", "u1").Audit("permission granted", map[string]any{"type": "override"})
+		if err := logger.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+
+		entries := transport.all()
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 log entry, got %d", len(entries))
+		}
+		if entries[0].Fields[AuditTypeField] != AuditType || entries[0].Fields["user_id"] != "u1" {
+			t.Errorf("Fields = %v, want type=audit and the logger context", entries[0].Fields)
+		}
+		if err := logger.TryAudit("", nil); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("TryAudit() error = %v, want ErrEmptyMessage", err)
+		}
+	})
+
+	t.Run("routes to the audit project", func(t *testing.T) {
+		var mu sync.Mutex
+		paths := map[string][]string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var batch LogBatch
+			json.NewDecoder(r.Body).Decode(&batch)
+
+			mu.Lock()
+			for _, entry := range batch.Logs {
+				paths[r.URL.Path] = append(paths[r.URL.Path], entry.Message)
+			}
+			mu.Unlock()
+
+			json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+		}))
+		defer server.Close()
+
+		logger, err := NewLogger(Config{
+			ProjectID:      "12345678-1234-1234-1234-123456789012",
+			AuditProjectID: "87654321-4321-4321-4321-210987654321",
+			Host:           server.URL,
+			ConsoleFormat:  ConsoleDisabled,
+		})
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		defer logger.Shutdown()
+
+		logger.Info("regular", nil)
+		logger.Audit("user deleted", nil)
+		if err := logger.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if got := paths["/api/v1/logs/receiving/12345678-1234-1234-1234-123456789012"]; len(got) != 1 || got[0] != "regular" {
+			t.Errorf("Main project received %v", got)
+		}
+		if got := paths["/api/v1/logs/receiving/87654321-4321-4321-4321-210987654321"]; len(got) != 1 || got[0] != "user deleted" {
+			t.Errorf("Audit project received %v", got)
+		}
+	})
+
+	t.Run("rejects an invalid audit project", func(t *testing.T) {
+		_, err := NewLogger(Config{
+			ProjectID:      "12345678-1234-1234-1234-123456789012",
+			AuditProjectID: "not-a-uuid",
+			Host:           "http://localhost:4005",
+		})
+		if !errors.Is(err, ErrInvalidProjectID) {
+			t.Errorf("NewLogger() error = %v, want ErrInvalidProjectID", err)
+		}
+	})
+}
+
+func TestLogBullLogger_ConcurrentDerivation(t *testing.T) {
+	var buf safeBuffer
+
+	base, err := NewLogger(Config{ConsoleWriter: &buf})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	base = base.WithContext(map[string]any{"service": "api"}).Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			derived := base.WithContext(map[string]any{"worker": id})
+			derived.Info("derived log", map[string]any{"step": id})
+			base.Info("base log", nil)
+
+			if derived.context["worker"] != id {
+				t.Errorf("derived context worker = %v, want %d", derived.context["worker"], id)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(base.context) != 1 || base.context["service"] != "api" {
+		t.Errorf("base context was modified: %v", base.context)
+	}
+}
+
+func TestLogBullLogger_Freeze(t *testing.T) {
+	logger, err := NewLogger(Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      "http://localhost:4005",
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	frozen := logger.Freeze()
+	if logger.IsFrozen() || !frozen.IsFrozen() {
+		t.Fatal("Freeze() should return a frozen copy without freezing the receiver")
+	}
+
+	if err := frozen.SetHost("http://localhost:4006"); !errors.Is(err, ErrLoggerFrozen) {
+		t.Errorf("SetHost() error = %v, want ErrLoggerFrozen", err)
+	}
+
+	derived := frozen.WithContext(map[string]any{"request_id": "r1"})
+	if !derived.IsFrozen() {
+		t.Error("Loggers derived from a frozen logger should stay frozen")
+	}
+
+	if err := logger.SetHost("http://localhost:4006"); err != nil {
+		t.Errorf("SetHost() on unfrozen logger error = %v", err)
+	}
+}
+
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
 func TestLogBullLogger_ConcurrentLogging(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -272,8 +828,9 @@ func TestLogBullLogger_ConcurrentLogging(t *testing.T) {
 	}
 
 	wg.Wait()
-	logger.Flush()
-	time.Sleep(500 * time.Millisecond)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogBullLogger_InvalidMessage(t *testing.T) {
@@ -295,7 +852,9 @@ func TestLogBullLogger_InvalidMessage(t *testing.T) {
 	logger.Info("", nil)
 	logger.Info("   ", nil)
 
-	time.Sleep(100 * time.Millisecond)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogBullLogger_InvalidFields(t *testing.T) {
@@ -322,7 +881,9 @@ func TestLogBullLogger_InvalidFields(t *testing.T) {
 
 	logger.Info("test", tooManyFields)
 
-	time.Sleep(100 * time.Millisecond)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogBullLogger_MessageTruncation(t *testing.T) {
@@ -344,7 +905,57 @@ func TestLogBullLogger_MessageTruncation(t *testing.T) {
 	longMessage := strings.Repeat("a", 15000)
 	logger.Info(longMessage, nil)
 
-	time.Sleep(100 * time.Millisecond)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+}
+
+func TestLogBullLogger_TryMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	logger, err := NewLogger(Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	t.Run("valid entry", func(t *testing.T) {
+		if err := logger.TryInfo("billing event", map[string]any{"amount": 10}); err != nil {
+			t.Errorf("TryInfo() error = %v", err)
+		}
+	})
+
+	t.Run("filtered level", func(t *testing.T) {
+		if err := logger.TryDebug("filtered", nil); err != nil {
+			t.Errorf("TryDebug() error = %v, want nil for filtered level", err)
+		}
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		if err := logger.TryError("   ", nil); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("TryError() error = %v, want ErrEmptyMessage", err)
+		}
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		if err := logger.TryWarning("test", map[string]any{"": "value"}); err == nil {
+			t.Error("TryWarning() expected error for empty field key")
+		}
+	})
+
+	t.Run("after shutdown", func(t *testing.T) {
+		logger.Shutdown()
+
+		if err := logger.TryCritical("late", nil); !errors.Is(err, ErrSenderShutdown) {
+			t.Errorf("TryCritical() error = %v, want ErrSenderShutdown", err)
+		}
+	})
 }
 
 func BenchmarkLogBullLogger_Info(b *testing.B) {
@@ -392,3 +1003,56 @@ func BenchmarkLogBullLogger_InfoParallel(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkLogBullLogger_InfoFiltered(b *testing.B) {
+	logger, _ := NewLogger(Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          "http://localhost:4005",
+		LogLevel:      ERROR,
+		ConsoleFormat: ConsoleDisabled,
+	})
+	defer logger.Shutdown()
+
+	fields := map[string]any{"key": "value"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("benchmark message", fields)
+	}
+}
+
+func BenchmarkLogBullLogger_InfoEnqueued(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	logger, _ := NewLogger(Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		ConsoleFormat: ConsoleDisabled,
+	})
+	defer logger.Shutdown()
+
+	fields := map[string]any{"key": "value", "count": 42, "ok": true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Keep the queue from filling up so every entry takes the enqueue path
+		if i%queueCapacity == 0 {
+			drainQueue(logger.sender)
+		}
+		logger.Info("benchmark message", fields)
+	}
+}
+
+func drainQueue(sender *Sender) {
+	for {
+		if _, ok := sender.queues.pop(); !ok {
+			return
+		}
+	}
+}
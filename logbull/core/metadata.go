@@ -0,0 +1,65 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// detectMetadata returns the process-level fields attached to every entry:
+// the detected metadata unless disabled, overridden by Config.DefaultFields.
+// It returns nil when there are none.
+func detectMetadata(config *Config) map[string]any {
+	if config.DisableMetadata {
+		return defaultFields(config, nil)
+	}
+
+	metadata := map[string]any{
+		"pid": os.Getpid(),
+	}
+
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		metadata["host"] = hostname
+	}
+
+	service := strings.TrimSpace(config.ServiceName)
+	if service == "" && len(os.Args) > 0 {
+		service = strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	}
+	if service != "" {
+		metadata["service"] = service
+	}
+
+	version := strings.TrimSpace(config.ServiceVersion)
+	if version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+	}
+	if version != "" {
+		metadata["service_version"] = version
+	}
+
+	if environment := strings.TrimSpace(config.Environment); environment != "" {
+		metadata["environment"] = environment
+	}
+
+	return defaultFields(config, metadata)
+}
+
+// defaultFields copies Config.DefaultFields into metadata, so later changes
+// to the caller's map do not affect the sender.
+func defaultFields(config *Config, metadata map[string]any) map[string]any {
+	if len(config.DefaultFields) == 0 {
+		return metadata
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]any, len(config.DefaultFields))
+	}
+	for key, value := range config.DefaultFields {
+		metadata[key] = value
+	}
+	return metadata
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTLP/HTTP JSON encoding of log batches, following the protobuf JSON mapping
+// of opentelemetry.proto.collector.logs.v1.ExportLogsServiceRequest.
+
+const otlpScopeName = "github.com/logbull/logbull-go"
+
+// otlpResourceKeys maps metadata fields to OpenTelemetry semantic conventions.
+var otlpResourceKeys = map[string]string{
+	"host":            "host.name",
+	"pid":             "process.pid",
+	"service":         "service.name",
+	"service_version": "service.version",
+	"environment":     "deployment.environment",
+}
+
+var otlpSeverity = map[LogLevel]int{
+	DEBUG:    5,
+	INFO:     9,
+	WARNING:  13,
+	ERROR:    17,
+	CRITICAL: 21,
+}
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlist     `json:"kvlistValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlist struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+type otlpResponse struct {
+	PartialSuccess *struct {
+		RejectedLogRecords json.Number `json:"rejectedLogRecords"`
+		ErrorMessage       string      `json:"errorMessage"`
+	} `json:"partialSuccess"`
+}
+
+func encodeOTLP(logs []LogEntry, metadata map[string]any, projectID string) ([]byte, error) {
+	resourceFields := map[string]any{"logbull.project_id": projectID}
+	for key, value := range metadata {
+		if otlpKey, ok := otlpResourceKeys[key]; ok {
+			resourceFields[otlpKey] = value
+		} else {
+			resourceFields[key] = value
+		}
+	}
+
+	records := make([]otlpLogRecord, 0, len(logs))
+	for _, log := range logs {
+		records = append(records, otlpLogRecord{
+			TimeUnixNano:   otlpTime(log.Timestamp),
+			SeverityNumber: otlpSeverity[LogLevel(log.Level)],
+			SeverityText:   log.Level,
+			Body:           otlpValue(log.Message),
+			Attributes:     otlpAttributes(log.Fields, metadata),
+		})
+	}
+
+	return json.Marshal(otlpRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: otlpAttributes(resourceFields, nil)},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: otlpScopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+}
+
+// handleOTLPResponse reports a partial success and converts it to the
+// LogBull response form passed to OnDelivered.
+func (s *Sender) handleOTLPResponse(body []byte, logs int) LogBullResponse {
+	var response otlpResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.PartialSuccess == nil {
+		return LogBullResponse{Accepted: logs}
+	}
+
+	rejected, _ := response.PartialSuccess.RejectedLogRecords.Int64()
+	if rejected <= 0 {
+		return LogBullResponse{Accepted: logs}
+	}
+
+	s.config.reportErrorf(
+		map[string]any{"operation": "send", "rejected": rejected},
+		"OTLP endpoint rejected %d log records: %s",
+		rejected,
+		response.PartialSuccess.ErrorMessage,
+	)
+	return LogBullResponse{
+		Accepted: logs - int(rejected),
+		Rejected: int(rejected),
+		Message:  response.PartialSuccess.ErrorMessage,
+	}
+}
+
+func otlpTime(timestamp string) string {
+	t, err := time.Parse(timestampLayout, timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpAttributes converts fields to sorted key/values, skipping fields equal
+// to the resource metadata since it is already sent once per request.
+func otlpAttributes(fields map[string]any, metadata map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if resourceValue, ok := metadata[key]; ok && reflect.DeepEqual(resourceValue, value) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]otlpKeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, otlpKeyValue{Key: key, Value: otlpValue(fields[key])})
+	}
+	return attributes
+}
+
+func otlpValue(value any) otlpAnyValue {
+	switch v := value.(type) {
+	case nil:
+		return otlpAnyValue{}
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		return otlpInt(int64(v))
+	case int8:
+		return otlpInt(int64(v))
+	case int16:
+		return otlpInt(int64(v))
+	case int32:
+		return otlpInt(int64(v))
+	case int64:
+		return otlpInt(v)
+	case uint:
+		return otlpInt(int64(v))
+	case uint8:
+		return otlpInt(int64(v))
+	case uint16:
+		return otlpInt(int64(v))
+	case uint32:
+		return otlpInt(int64(v))
+	case uint64:
+		return otlpInt(int64(v))
+	case float32:
+		f := float64(v)
+		return otlpAnyValue{DoubleValue: &f}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	case []any:
+		values := make([]otlpAnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, otlpValue(item))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case map[string]any:
+		return otlpAnyValue{KvlistValue: &otlpKvlist{Values: otlpAttributes(v, nil)}}
+	}
+
+	// Other types (structs, typed slices and maps) go through their JSON form
+	data, err := json.Marshal(value)
+	if err != nil {
+		s := fmt.Sprint(value)
+		return otlpAnyValue{StringValue: &s}
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		s := string(data)
+		return otlpAnyValue{StringValue: &s}
+	}
+	return otlpValue(generic)
+}
+
+func otlpInt(v int64) otlpAnyValue {
+	s := strconv.FormatInt(v, 10)
+	return otlpAnyValue{IntValue: &s}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestEncodeOTLP(t *testing.T) {
+	logs := []LogEntry{
+		{
+			Level:     "ERROR",
+			Message:   "payment failed",
+			Timestamp: "2024-03-01T12:30:00.123456789Z",
+			Fields: map[string]any{
+				"service":  "checkout",
+				"attempt":  3,
+				"amount":   12.5,
+				"retry":    true,
+				"tags":     []any{"a", "b"},
+				"customer": map[string]any{"id": "c1"},
+				"items":    []string{"x"},
+			},
+		},
+	}
+
+	data, err := encodeOTLP(logs, map[string]any{"service": "checkout"}, "project-1")
+	if err != nil {
+		t.Fatalf("encodeOTLP() error = %v", err)
+	}
+
+	var request otlpRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("Invalid OTLP JSON: %v", err)
+	}
+
+	resource := request.ResourceLogs[0].Resource.Attributes
+	resourceValues := map[string]string{}
+	for _, kv := range resource {
+		if kv.Value.StringValue != nil {
+			resourceValues[kv.Key] = *kv.Value.StringValue
+		}
+	}
+	if resourceValues["service.name"] != "checkout" {
+		t.Errorf("service.name = %q, want checkout", resourceValues["service.name"])
+	}
+	if resourceValues["logbull.project_id"] != "project-1" {
+		t.Errorf("logbull.project_id = %q", resourceValues["logbull.project_id"])
+	}
+
+	record := request.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if record.TimeUnixNano != "1709296200123456789" {
+		t.Errorf("TimeUnixNano = %s", record.TimeUnixNano)
+	}
+	if record.SeverityNumber != 17 || record.SeverityText != "ERROR" {
+		t.Errorf("Severity = %d %s, want 17 ERROR", record.SeverityNumber, record.SeverityText)
+	}
+	if record.Body.StringValue == nil || *record.Body.StringValue != "payment failed" {
+		t.Errorf("Body = %+v", record.Body)
+	}
+
+	attributes := map[string]otlpAnyValue{}
+	for _, kv := range record.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+	if _, ok := attributes["service"]; ok {
+		t.Error("Resource metadata should not be repeated on every record")
+	}
+	if v := attributes["attempt"].IntValue; v == nil || *v != "3" {
+		t.Errorf("attempt = %+v, want intValue 3", attributes["attempt"])
+	}
+	if v := attributes["amount"].DoubleValue; v == nil || *v != 12.5 {
+		t.Errorf("amount = %+v, want doubleValue 12.5", attributes["amount"])
+	}
+	if v := attributes["retry"].BoolValue; v == nil || !*v {
+		t.Errorf("retry = %+v, want boolValue true", attributes["retry"])
+	}
+	if v := attributes["tags"].ArrayValue; v == nil || len(v.Values) != 2 {
+		t.Errorf("tags = %+v, want 2 array values", attributes["tags"])
+	}
+	if v := attributes["customer"].KvlistValue; v == nil || v.Values[0].Key != "id" {
+		t.Errorf("customer = %+v, want kvlist", attributes["customer"])
+	}
+	if v := attributes["items"].ArrayValue; v == nil || len(v.Values) != 1 {
+		t.Errorf("items = %+v, want typed slice as array", attributes["items"])
+	}
+}
+
+func TestSender_OTLPProtocol(t *testing.T) {
+	var mu sync.Mutex
+	var path string
+	var request otlpRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&request)
+		mu.Unlock()
+
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Protocol:  ProtocolOTLP,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "hello", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if path != "/v1/logs" {
+		t.Errorf("Path = %s, want /v1/logs", path)
+	}
+	if len(request.ResourceLogs) != 1 || len(request.ResourceLogs[0].ScopeLogs[0].LogRecords) != 1 {
+		t.Fatalf("Unexpected OTLP request: %+v", request)
+	}
+}
+
+func TestSender_OTLPNonComparableDefaultFields(t *testing.T) {
+	var mu sync.Mutex
+	var request otlpRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		json.NewDecoder(r.Body).Decode(&request)
+		mu.Unlock()
+
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	logger, err := NewLogger(Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		Protocol:      ProtocolOTLP,
+		ConsoleFormat: ConsoleDisabled,
+		DefaultFields: map[string]any{
+			"labels": map[string]any{"team": "a"},
+			"zones":  []any{"a", "b"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("hello", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(request.ResourceLogs) != 1 {
+		t.Fatalf("Unexpected OTLP request: %+v", request)
+	}
+	for _, attribute := range request.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Attributes {
+		if attribute.Key == "labels" || attribute.Key == "zones" {
+			t.Errorf("record attribute %q duplicates a resource attribute", attribute.Key)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+// ValidateConfig checks config the way NewLogger does, without starting a
+// sender, creating the WAL directory or sending anything, so CI jobs and
+// startup checks can fail early with one clear error. Settings that work but
+// are probably not intended are returned as warnings.
+func ValidateConfig(config Config) (warnings []string, err error) {
+	config = normalizeConfig(config)
+
+	if err := config.Schema.check(); err != nil {
+		return nil, fmt.Errorf("invalid Schema: %w", err)
+	}
+
+	if config.consoleOnly() {
+		return []string{"ProjectID and Host are not set, so logs are only printed to the console"}, nil
+	}
+
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
+
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.APIKey != "" {
+		if err := validation.ValidateAPIKey(config.APIKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.AuditProjectID != "" && config.Transport == nil {
+		if err := validation.ValidateProjectID(strings.TrimSpace(config.AuditProjectID)); err != nil {
+			return nil, fmt.Errorf("invalid audit project ID: %w", err)
+		}
+	}
+
+	if err := config.checkSender(); err != nil {
+		return nil, err
+	}
+
+	if _, err := newHTTPTransport(&config); err != nil {
+		return nil, err
+	}
+
+	if config.WALDir != "" {
+		switch info, err := os.Stat(config.WALDir); {
+		case errors.Is(err, os.ErrNotExist):
+			warnings = append(warnings, fmt.Sprintf("WALDir %s does not exist yet and will be created", config.WALDir))
+		case err != nil:
+			return nil, fmt.Errorf("invalid WALDir: %w", err)
+		case !info.IsDir():
+			return nil, fmt.Errorf("invalid WALDir: %s is not a directory", config.WALDir)
+		}
+	}
+
+	if config.Transport == nil && (config.APIKey != "" || config.APIKeyProvider != nil) {
+		hosts, _ := senderHosts(&config)
+		for _, host := range hosts {
+			if sendsKeyInClear(host) {
+				warnings = append(warnings, fmt.Sprintf("Host %s uses plain HTTP, so the API key is sent unencrypted; use https://", host))
+			}
+		}
+	}
+
+	if config.BatchFormat == BatchFormatColumnar && !config.NegotiateCapabilities && config.Transport == nil {
+		warnings = append(warnings, "BatchFormat columnar requires NegotiateCapabilities, so batches are sent as rows")
+	}
+
+	if config.AuditProjectID != "" && strings.TrimSpace(config.AuditProjectID) == config.ProjectID {
+		warnings = append(warnings, "AuditProjectID is the same as ProjectID, so audit entries are not kept apart")
+	}
+
+	return warnings, nil
+}
+
+// ValidateConfigOnline runs ValidateConfig, then sends an empty batch to
+// every host, like Ping, to check that it is reachable and accepts the
+// project ID and API key. An unreachable primary host or rejected
+// credentials are errors; unreachable failover hosts are warnings.
+func ValidateConfigOnline(ctx context.Context, config Config) (warnings []string, err error) {
+	warnings, err = ValidateConfig(config)
+	if err != nil {
+		return warnings, err
+	}
+
+	config = normalizeConfig(config)
+	if config.consoleOnly() || config.Transport != nil {
+		return warnings, nil
+	}
+
+	probe, err := newProbeSender(config)
+	if err != nil {
+		return warnings, err
+	}
+
+	hosts, _ := senderHosts(&config)
+	for i, host := range hosts {
+		err := probe.pingHost(ctx, host)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			return warnings, fmt.Errorf("%s rejected the project ID or API key: %w", host, err)
+		case err != nil && i == 0:
+			return warnings, fmt.Errorf("%s is not reachable: %w", host, err)
+		case err != nil:
+			warnings = append(warnings, fmt.Sprintf("Failover host %s is not reachable: %v", host, err))
+		}
+	}
+
+	if config.AuditProjectID != "" {
+		config.ProjectID = strings.TrimSpace(config.AuditProjectID)
+		probe.config = &config
+		if err := probe.pingHost(ctx, hosts[0]); err != nil {
+			return warnings, fmt.Errorf("%s rejected the audit project: %w", hosts[0], err)
+		}
+	}
+
+	return warnings, nil
+}
+
+// normalizeConfig trims config like NewLogger.
+func normalizeConfig(config Config) Config {
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" && len(config.Hosts) > 0 {
+		config.Host = strings.TrimSpace(config.Hosts[0])
+	}
+	config.APIKey = strings.TrimSpace(config.APIKey)
+	return config
+}
+
+func (c *Config) consoleOnly() bool {
+	return c.Transport == nil && (c.ProjectID == "" || c.Host == "")
+}
+
+// newProbeSender returns a sender that can only ping: no queue, workers or
+// WAL, and no diagnostics, since the caller receives the errors.
+func newProbeSender(config Config) (*Sender, error) {
+	config.Silent = true
+	config.ErrorHandler = nil
+
+	transport, err := newHTTPTransport(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sender{
+		config: &config,
+		client: &http.Client{Timeout: httpTimeout, Transport: transport},
+	}, nil
+}
+
+func sendsKeyInClear(host string) bool {
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip == nil || !ip.IsLoopback()
+}
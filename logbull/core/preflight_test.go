@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const preflightProjectID = "12345678-1234-1234-1234-123456789012"
+
+func TestValidateConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		config       Config
+		wantErr      string
+		wantWarnings []string
+	}{
+		{
+			name:   "valid",
+			config: Config{ProjectID: preflightProjectID, Host: "https://logs.example.com", APIKey: "test-api-key"},
+		},
+		{
+			name:         "console only",
+			config:       Config{},
+			wantWarnings: []string{"only printed to the console"},
+		},
+		{
+			name:    "invalid host",
+			config:  Config{ProjectID: preflightProjectID, Host: "logs.example.com"},
+			wantErr: "host",
+		},
+		{
+			name:    "invalid timestamp format",
+			config:  Config{ProjectID: preflightProjectID, Host: "http://localhost:4005", TimestampFormat: "julian"},
+			wantErr: "TimestampFormat",
+		},
+		{
+			name:    "invalid proxy",
+			config:  Config{ProjectID: preflightProjectID, Host: "http://localhost:4005", ProxyURL: "proxy"},
+			wantErr: "proxy",
+		},
+		{
+			name:    "WALDir is a file",
+			config:  Config{ProjectID: preflightProjectID, Host: "http://localhost:4005", WALDir: file},
+			wantErr: "WALDir",
+		},
+		{
+			name:         "missing WALDir",
+			config:       Config{ProjectID: preflightProjectID, Host: "http://localhost:4005", WALDir: filepath.Join(file+"-dir", "wal")},
+			wantWarnings: []string{"will be created"},
+		},
+		{
+			name:         "columnar without negotiation",
+			config:       Config{ProjectID: preflightProjectID, Host: "http://localhost:4005", BatchFormat: BatchFormatColumnar},
+			wantWarnings: []string{"requires NegotiateCapabilities"},
+		},
+		{
+			name:         "API key over plain HTTP",
+			config:       Config{ProjectID: preflightProjectID, Host: "http://logs.example.com", APIKey: "test-api-key"},
+			wantWarnings: []string{"sent unencrypted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := ValidateConfig(tt.config)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ValidateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("ValidateConfig() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("Warning %d = %q, want it to mention %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(file+"-dir", "wal")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("ValidateConfig() created the WAL directory")
+	}
+}
+
+func TestValidateConfigOnline(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	ctx := context.Background()
+
+	warnings, err := ValidateConfigOnline(ctx, Config{ProjectID: preflightProjectID, Hosts: []string{server.URL, down.URL}})
+	if err != nil {
+		t.Fatalf("ValidateConfigOnline() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], down.URL) {
+		t.Errorf("ValidateConfigOnline() warnings = %q, want the unreachable failover host", warnings)
+	}
+
+	if _, err := ValidateConfigOnline(ctx, Config{ProjectID: preflightProjectID, Host: down.URL}); err == nil {
+		t.Error("ValidateConfigOnline() expected error for an unreachable host")
+	}
+
+	status = http.StatusUnauthorized
+	if _, err := ValidateConfigOnline(ctx, Config{ProjectID: preflightProjectID, Host: server.URL}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ValidateConfigOnline() error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+// dialTimeout matches the dialer of http.DefaultTransport.
+const dialTimeout = 30 * time.Second
+
+// newHTTPTransport returns the transport shared by batch and stream requests.
+// Config.ProxyURL takes precedence over HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+// The default transport keeps only two idle connections per host, so one per
+// send worker is kept instead to avoid reconnecting under load.
+func newHTTPTransport(config *Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.MaxIdleConnsPerHost = maxWorkers
+
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+
+	switch {
+	case config.DialContext != nil:
+		transport.DialContext = config.DialContext
+	case config.KeepAlive != 0:
+		dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: config.KeepAlive}
+		transport.DialContext = dialer.DialContext
+	}
+
+	if config.DisableHTTP2 {
+		// A non-nil empty map keeps the transport from negotiating h2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if proxy := strings.TrimSpace(config.ProxyURL); proxy != "" {
+		if err := validation.ValidateProxyURL(proxy); err != nil {
+			return nil, err
+		}
+
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
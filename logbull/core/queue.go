@@ -0,0 +1,124 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Queue priorities, drained highest first.
+const (
+	// priorityHigh holds ERROR and CRITICAL entries
+	priorityHigh = iota
+	// priorityNormal holds WARNING and INFO entries
+	priorityNormal
+	// priorityLow holds DEBUG entries and unknown levels
+	priorityLow
+
+	numPriorities
+)
+
+// logQueues keeps one bounded queue per priority, so a flood of DEBUG
+// entries can neither take the room of ERROR entries nor delay them: batches
+// are filled from the highest priority first. Entries carry their enqueue
+// order, so a batch mixing priorities is still sent in logging order.
+type logQueues struct {
+	queues [numPriorities]chan queuedEntry
+	seq    atomic.Uint64
+}
+
+type queuedEntry struct {
+	LogEntry
+	seq uint64
+}
+
+func newLogQueues(capacity int) *logQueues {
+	q := &logQueues{}
+	for i := range q.queues {
+		q.queues[i] = make(chan queuedEntry, capacity)
+	}
+	return q
+}
+
+func queuePriority(level string) int {
+	switch priority := LogLevel(level).Priority(); {
+	case priority >= ERROR.Priority():
+		return priorityHigh
+	case priority >= INFO.Priority():
+		return priorityNormal
+	default:
+		return priorityLow
+	}
+}
+
+// of returns the queue entry belongs to.
+func (q *logQueues) of(entry LogEntry) chan queuedEntry {
+	return q.queues[queuePriority(entry.Level)]
+}
+
+// wrap stamps entry with its enqueue order.
+func (q *logQueues) wrap(entry LogEntry) queuedEntry {
+	return queuedEntry{LogEntry: entry, seq: q.seq.Add(1)}
+}
+
+// len returns the number of queued entries of every priority.
+func (q *logQueues) len() int {
+	n := 0
+	for _, queue := range q.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// pop removes the oldest entry of the highest non-empty priority.
+func (q *logQueues) pop() (LogEntry, bool) {
+	for _, queue := range q.queues {
+		select {
+		case queued := <-queue:
+			return queued.LogEntry, true
+		default:
+		}
+	}
+	return LogEntry{}, false
+}
+
+// popBatch appends up to max entries to logs, taking them from the highest
+// priorities first, and returns them in enqueue order.
+func (q *logQueues) popBatch(logs []LogEntry, max int) []LogEntry {
+	pooled := orderedBatchPool.Get().(*orderedBatch)
+	batch := *pooled
+	for _, queue := range q.queues {
+		for len(batch) < max {
+			select {
+			case queued := <-queue:
+				batch = append(batch, queued)
+				continue
+			default:
+			}
+			break
+		}
+	}
+
+	sort.Sort(batch)
+	for _, queued := range batch {
+		logs = append(logs, queued.LogEntry)
+	}
+
+	clear(batch)
+	*pooled = batch[:0]
+	orderedBatchPool.Put(pooled)
+	return logs
+}
+
+var orderedBatchPool = sync.Pool{
+	New: func() any {
+		batch := make(orderedBatch, 0, batchSize)
+		return &batch
+	},
+}
+
+type orderedBatch []queuedEntry
+
+func (b orderedBatch) Len() int           { return len(b) }
+func (b orderedBatch) Less(i, j int) bool { return b[i].seq < b[j].seq }
+func (b orderedBatch) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
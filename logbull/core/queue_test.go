@@ -0,0 +1,64 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLogQueues_PopBatch(t *testing.T) {
+	queues := newLogQueues(10)
+	for _, entry := range []LogEntry{
+		{Level: "DEBUG", Message: "d1"},
+		{Level: "ERROR", Message: "e1"},
+		{Level: "DEBUG", Message: "d2"},
+		{Level: "INFO", Message: "i1"},
+		{Level: "CRITICAL", Message: "c1"},
+		{Level: "DEBUG", Message: "d3"},
+	} {
+		queues.of(entry) <- queues.wrap(entry)
+	}
+
+	messages := func(logs []LogEntry) string {
+		var names []string
+		for _, log := range logs {
+			names = append(names, log.Message)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// A full batch takes the highest priorities first, in logging order
+	if got := messages(queues.popBatch(nil, 4)); got != "d1,e1,i1,c1" {
+		t.Errorf("popBatch(4) = %s, want d1,e1,i1,c1", got)
+	}
+	if got := messages(queues.popBatch(nil, 10)); got != "d2,d3" {
+		t.Errorf("popBatch(10) = %s, want d2,d3", got)
+	}
+	if queues.len() != 0 {
+		t.Errorf("len() = %d, want 0", queues.len())
+	}
+}
+
+func TestSender_DebugFloodKeepsRoomForErrors(t *testing.T) {
+	sender := &Sender{
+		config: &Config{},
+		queues: newLogQueues(2),
+		stopCh: make(chan struct{}),
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := sender.TryAddLog(LogEntry{Level: "DEBUG", Message: "noise"}); err != nil {
+			t.Fatalf("TryAddLog(DEBUG) error = %v", err)
+		}
+	}
+	if err := sender.TryAddLog(LogEntry{Level: "DEBUG", Message: "noise"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TryAddLog(DEBUG) error = %v, want ErrQueueFull", err)
+	}
+
+	if err := sender.TryAddLog(LogEntry{Level: "ERROR", Message: "payment failed"}); err != nil {
+		t.Errorf("TryAddLog(ERROR) behind a DEBUG flood error = %v", err)
+	}
+	if got := sender.Stats().QueuedLogs; got != 3 {
+		t.Errorf("Stats().QueuedLogs = %d, want 3", got)
+	}
+}
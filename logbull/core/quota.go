@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SuppressedCountField carries, on the entry reporting a level's rate limit,
+// how many entries of that level were suppressed since the previous report.
+const SuppressedCountField = "suppressed_count"
+
+// levelBucket is a token bucket refilled at rate tokens per second and
+// holding at most one second's worth, so bursts are bounded too.
+type levelBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	suppressed int
+	// lastSuppressed is the timestamp of the latest suppressed entry
+	lastSuppressed string
+}
+
+// rateLimiter enforces Config.RateLimitByLevel. Suppressed entries are
+// counted per level and reported by expire.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[LogLevel]*levelBucket
+}
+
+func checkRateLimits(limits map[LogLevel]int) error {
+	for level, limit := range limits {
+		if level.Priority() == 0 {
+			return fmt.Errorf("unknown level '%s'", level)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("%s limit must be positive, got %d", level, limit)
+		}
+	}
+	return nil
+}
+
+func newRateLimiter(limits map[LogLevel]int, now time.Time) *rateLimiter {
+	r := &rateLimiter{buckets: make(map[LogLevel]*levelBucket, len(limits))}
+	for level, limit := range limits {
+		r.buckets[level] = &levelBucket{rate: float64(limit), tokens: float64(limit), last: now}
+	}
+	return r
+}
+
+// allow reports whether entry fits its level's rate limit, counting it as
+// suppressed otherwise. Levels without a limit are always allowed.
+func (r *rateLimiter) allow(entry LogEntry, now time.Time) bool {
+	bucket, ok := r.buckets[LogLevel(entry.Level)]
+	if !ok {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens = min(bucket.rate, bucket.tokens+elapsed.Seconds()*bucket.rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true
+	}
+
+	bucket.suppressed++
+	bucket.lastSuppressed = entry.Timestamp
+	return false
+}
+
+// expire returns one entry per level that suppressed entries since the last
+// call, carrying their count in SuppressedCountField.
+func (r *rateLimiter) expire() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reports []LogEntry
+	for level, bucket := range r.buckets {
+		if bucket.suppressed == 0 {
+			continue
+		}
+		reports = append(reports, LogEntry{
+			Level:     string(level),
+			Message:   fmt.Sprintf("%d logs suppressed", bucket.suppressed),
+			Timestamp: bucket.lastSuppressed,
+			Fields:    map[string]any{SuppressedCountField: bucket.suppressed},
+		})
+		bucket.suppressed = 0
+	}
+	return reports
+}
+
+// reportSuppressed queues the entries reporting suppressed logs.
+func (s *Sender) reportSuppressed() {
+	if s.limiter == nil {
+		return
+	}
+	for _, report := range s.limiter.expire() {
+		_ = s.add(context.Background(), report, true)
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Now()
+	r := newRateLimiter(map[LogLevel]int{DEBUG: 2}, start)
+
+	debug := LogEntry{Level: "DEBUG", Message: "cache miss", Timestamp: "t1"}
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if r.allow(debug, start) {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Allowed %d of 5 burst entries, want 2", allowed)
+	}
+
+	if !r.allow(LogEntry{Level: "ERROR"}, start) {
+		t.Error("Levels without a limit should always be allowed")
+	}
+
+	// Half a second refills one token
+	if !r.allow(debug, start.Add(500*time.Millisecond)) || r.allow(debug, start.Add(500*time.Millisecond)) {
+		t.Error("Expected exactly one entry after half a second")
+	}
+
+	reports := r.expire()
+	if len(reports) != 1 {
+		t.Fatalf("expire() = %v, want 1 report", reports)
+	}
+	if reports[0].Level != "DEBUG" || reports[0].Fields[SuppressedCountField] != 4 || reports[0].Timestamp != "t1" {
+		t.Errorf("Report = %+v, want 4 suppressed DEBUG entries", reports[0])
+	}
+
+	if reports := r.expire(); len(reports) != 0 {
+		t.Errorf("expire() after a report = %v, want none", reports)
+	}
+}
+
+func TestCheckRateLimits(t *testing.T) {
+	if err := checkRateLimits(map[LogLevel]int{DEBUG: 100, INFO: 1}); err != nil {
+		t.Errorf("checkRateLimits() error = %v", err)
+	}
+	if err := checkRateLimits(map[LogLevel]int{"VERBOSE": 1}); err == nil {
+		t.Error("checkRateLimits() expected error for an unknown level")
+	}
+	if err := checkRateLimits(map[LogLevel]int{DEBUG: 0}); err == nil {
+		t.Error("checkRateLimits() expected error for a zero limit")
+	}
+}
+
+func TestSender_RateLimitByLevel(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, RateLimitByLevel: map[LogLevel]int{DEBUG: 3}})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		sender.AddLog(LogEntry{Level: "DEBUG", Message: "cache miss", Timestamp: GenerateUniqueTimestamp()})
+		sender.AddLog(LogEntry{Level: "ERROR", Message: "payment failed", Timestamp: GenerateUniqueTimestamp()})
+	}
+
+	if suppressed := sender.Stats().SuppressedLogs; suppressed != 17 {
+		t.Errorf("SuppressedLogs = %d, want 17", suppressed)
+	}
+
+	// Shutdown reports the entries suppressed since the last tick
+	sender.Shutdown()
+
+	counts := map[string]int{}
+	var report *LogEntry
+	for _, entry := range transport.all() {
+		if _, ok := entry.Fields[SuppressedCountField]; ok {
+			entry := entry
+			report = &entry
+			continue
+		}
+		counts[entry.Level]++
+	}
+	if counts["DEBUG"] != 3 || counts["ERROR"] != 20 {
+		t.Errorf("Sent = %v, want 3 DEBUG and 20 ERROR entries", counts)
+	}
+	if report == nil || report.Level != "DEBUG" || report.Message != "17 logs suppressed" || report.Fields[SuppressedCountField] != 17 {
+		t.Errorf("Report = %+v", report)
+	}
+}
+
+func TestNewSender_InvalidRateLimitByLevel(t *testing.T) {
+	if _, err := NewSender(&Config{Transport: &captureTransport{}, RateLimitByLevel: map[LogLevel]int{DEBUG: -1}}); err == nil {
+		t.Error("NewSender() expected error for a negative limit")
+	}
+}
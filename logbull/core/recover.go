@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	PanicField = "panic"
+	StackField = "stack"
+
+	defaultRecoverFlushTimeout = 5 * time.Second
+)
+
+type RecoverConfig struct {
+	// Repanic re-raises the panic after it has been logged and flushed, so the
+	// process still crashes.
+	Repanic bool
+	// FlushTimeout bounds the synchronous flush (default 5s).
+	FlushTimeout time.Duration
+}
+
+// RecoverAndLog recovers a panic, logs it at CRITICAL with the stack trace
+// and waits for it to be delivered. It must be deferred directly:
+//
+//	defer logbull.RecoverAndLog(logger)
+func RecoverAndLog(logger *LogBullLogger) {
+	if r := recover(); r != nil {
+		logPanic(logger, r, RecoverConfig{}, nil)
+	}
+}
+
+// RecoverAndLogWithConfig is RecoverAndLog with options. It must be deferred
+// directly.
+func RecoverAndLogWithConfig(logger *LogBullLogger, config RecoverConfig) {
+	if r := recover(); r != nil {
+		logPanic(logger, r, config, nil)
+		if config.Repanic {
+			panic(r)
+		}
+	}
+}
+
+// RecoverMiddleware returns net/http middleware that logs panics from next
+// like RecoverAndLog and responds with 500. The request-scoped logger from
+// the request context is preferred over logger when present.
+func RecoverMiddleware(logger *LogBullLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// http.ErrAbortHandler is how handlers abort a response on purpose
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				requestLogger := LoggerFromContext(r.Context())
+				if requestLogger == nil {
+					requestLogger = logger
+				}
+
+				logPanic(requestLogger, rec, RecoverConfig{}, map[string]any{
+					"http.method": r.Method,
+					"http.path":   r.URL.Path,
+				})
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// recoverLogPanic stores a panic raised while logging in err as an
+// ErrLogPanic, so logging never crashes the application. It must be deferred
+// directly.
+func recoverLogPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrLogPanic, r)
+	}
+}
+
+func logPanic(logger *LogBullLogger, recovered any, config RecoverConfig, extra map[string]any) {
+	if logger == nil {
+		return
+	}
+
+	fields := make(map[string]any, len(extra)+2)
+	for key, value := range extra {
+		fields[key] = value
+	}
+	fields[PanicField] = fmt.Sprint(recovered)
+	fields[StackField] = string(debug.Stack())
+
+	logger.Critical(fmt.Sprintf("panic recovered: %v", recovered), fields)
+
+	timeout := config.FlushTimeout
+	if timeout <= 0 {
+		timeout = defaultRecoverFlushTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := logger.FlushSync(ctx); err != nil {
+		logger.config.reportError(fmt.Errorf("failed to flush logs after panic: %w", err), map[string]any{"operation": "recover"})
+	}
+}
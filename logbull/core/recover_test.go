@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type captureTransport struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (c *captureTransport) Send(_ context.Context, logs []LogEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, logs...)
+	return nil
+}
+
+func (c *captureTransport) all() []LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LogEntry(nil), c.entries...)
+}
+
+func newCaptureLogger(t *testing.T) (*LogBullLogger, *captureTransport) {
+	t.Helper()
+
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		LogLevel:      DEBUG,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	t.Cleanup(logger.Shutdown)
+
+	return logger, transport
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	func() {
+		defer RecoverAndLog(logger)
+		panic("boom")
+	}()
+
+	// RecoverAndLog flushes synchronously, so the entry is already delivered
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "CRITICAL" {
+		t.Errorf("Level = %s, want CRITICAL", entry.Level)
+	}
+	if entry.Fields[PanicField] != "boom" {
+		t.Errorf("%s = %v, want boom", PanicField, entry.Fields[PanicField])
+	}
+	if stack, _ := entry.Fields[StackField].(string); !strings.Contains(stack, "TestRecoverAndLog") {
+		t.Errorf("%s does not contain the panicking function: %q", StackField, stack)
+	}
+}
+
+func TestRecoverAndLogWithConfig_Repanic(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	var repanicked any
+	func() {
+		defer func() { repanicked = recover() }()
+		defer RecoverAndLogWithConfig(logger, RecoverConfig{Repanic: true})
+		panic("boom")
+	}()
+
+	if repanicked != "boom" {
+		t.Errorf("recover() = %v, want boom", repanicked)
+	}
+	if entries := transport.all(); len(entries) != 1 {
+		t.Errorf("Expected 1 log entry before re-panic, got %d", len(entries))
+	}
+}
+
+func TestRecoverAndLog_NoPanic(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	func() {
+		defer RecoverAndLog(logger)
+	}()
+
+	logger.FlushSync(context.Background())
+	if entries := transport.all(); len(entries) != 0 {
+		t.Errorf("Expected no log entries, got %d", len(entries))
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+
+	handler := RecoverMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Response status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if entries[0].Fields["http.path"] != "/orders" {
+		t.Errorf("http.path = %v, want /orders", entries[0].Fields["http.path"])
+	}
+	if entries[0].Fields[PanicField] != "handler failed" {
+		t.Errorf("%s = %v, want handler failed", PanicField, entries[0].Fields[PanicField])
+	}
+
+	t.Run("abort handler is re-raised", func(t *testing.T) {
+		handler := RecoverMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("recover() = %v, want http.ErrAbortHandler", r)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecoverAndLog_IncludeCaller(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, IncludeCaller: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	panicking := func() {
+		defer RecoverAndLog(logger)
+		panic("boom")
+	}
+	panicking()
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	// The caller is the panicking function, not the recovery helper
+	if function, _ := entries[0].Fields[CallerFunctionField].(string); !strings.HasSuffix(function, "TestRecoverAndLog_IncludeCaller.func1") {
+		t.Errorf("%s = %v, want the panicking function", CallerFunctionField, entries[0].Fields[CallerFunctionField])
+	}
+}
+
+func TestLogger_RecoversLoggingPanics(t *testing.T) {
+	transport := &captureTransport{}
+	var reported []error
+	logger, err := NewLogger(Config{
+		Transport:       transport,
+		ConsoleFormat:   ConsoleDisabled,
+		FingerprintFunc: func(LogEntry) string { panic("fingerprint broke") },
+		ErrorHandler:    func(err error, _ map[string]any) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	if err := logger.TryError("payment failed", nil); !errors.Is(err, ErrLogPanic) {
+		t.Errorf("TryError() error = %v, want ErrLogPanic", err)
+	}
+	logger.Error("payment failed", nil)
+	logger.Sender().AddLog(LogEntry{Level: "ERROR", Message: "payment failed", Timestamp: GenerateUniqueTimestamp()})
+
+	if len(reported) != 2 || !errors.Is(reported[0], ErrLogPanic) || !errors.Is(reported[1], ErrLogPanic) {
+		t.Errorf("Reported errors = %v, want two ErrLogPanic", reported)
+	}
+
+	// Field values panicking while converted are replaced, not fatal
+	logger.Info("order placed", map[string]any{"order": panickingValue{}})
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	entries := transport.all()
+	if len(entries) != 1 || entries[0].Fields["order"] != "%!v(PANIC=marshal broke)" {
+		t.Errorf("Entries = %+v, want the order field replaced by the panic", entries)
+	}
+}
+
+func TestSender_AddLogRecoversFieldPanics(t *testing.T) {
+	server := newBatchServer(t, http.StatusOK)
+	sender := newFailoverSender(t, Config{Host: server.URL})
+
+	// Encoding the batch would panic in the worker without the entry fields
+	// being converted on the way in
+	sender.AddLog(LogEntry{Level: "INFO", Message: "order placed", Timestamp: GenerateUniqueTimestamp(), Fields: map[string]any{"order": panickingValue{}}})
+	sender.AddLog(LogEntry{Level: "INFO", Message: "order shipped", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if _, sequences := server.received(); len(sequences) != 2 {
+		t.Errorf("Server received %d entries, want 2", len(sequences))
+	}
+}
+
+type panickingValue struct{}
+
+func (panickingValue) MarshalJSON() ([]byte, error) {
+	panic("marshal broke")
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"sync"
 )
 
@@ -21,6 +22,81 @@ func (r *registry) register(sender *Sender) {
 	r.senders = append(r.senders, sender)
 }
 
+func (r *registry) unregister(sender *Sender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, registered := range r.senders {
+		if registered == sender {
+			r.senders = append(r.senders[:i], r.senders[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *registry) snapshot() []*Sender {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	senders := make([]*Sender, len(r.senders))
+	copy(senders, r.senders)
+	return senders
+}
+
+func (r *registry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.senders)
+}
+
 func registerSender(sender *Sender) {
 	senderRegistry.register(sender)
 }
+
+func unregisterSender(sender *Sender) {
+	senderRegistry.unregister(sender)
+}
+
+// ActiveSenders returns the number of senders, one per logger or handler
+// with credentials, that have been started and not shut down yet. Tests can
+// compare it before and after to catch loggers that are never shut down.
+func ActiveSenders() int {
+	return senderRegistry.len()
+}
+
+// FlushAll starts sending the queued logs of every logger and handler that
+// has not been shut down. Like Flush, it does not wait for delivery.
+func FlushAll() {
+	for _, sender := range senderRegistry.snapshot() {
+		sender.Flush()
+	}
+}
+
+// ShutdownAll shuts down every logger and handler in parallel, delivering
+// their queued logs. It returns ctx.Err() if ctx is done first; the
+// remaining shutdowns continue in the background.
+func ShutdownAll(ctx context.Context) error {
+	senders := senderRegistry.snapshot()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for _, sender := range senders {
+			wg.Add(1)
+			go func(sender *Sender) {
+				defer wg.Done()
+				sender.Shutdown()
+			}(sender)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
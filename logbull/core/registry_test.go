@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdownAll(t *testing.T) {
+	first, firstTransport := newCaptureLogger(t)
+	second, secondTransport := newCaptureLogger(t)
+
+	first.Info("from first", nil)
+	second.Info("from second", nil)
+
+	if err := ShutdownAll(context.Background()); err != nil {
+		t.Fatalf("ShutdownAll() error = %v", err)
+	}
+
+	if got := len(firstTransport.all()); got != 1 {
+		t.Errorf("first logger delivered %d logs, want 1", got)
+	}
+	if got := len(secondTransport.all()); got != 1 {
+		t.Errorf("second logger delivered %d logs, want 1", got)
+	}
+
+	for _, sender := range senderRegistry.snapshot() {
+		if sender == first.sender || sender == second.sender {
+			t.Error("ShutdownAll() left a shut down sender registered")
+		}
+	}
+
+	if err := first.TryInfo("after shutdown", nil); !errors.Is(err, ErrSenderShutdown) {
+		t.Errorf("TryInfo() error = %v, want ErrSenderShutdown", err)
+	}
+}
+
+func TestShutdownAll_ContextDone(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	logger, err := NewLogger(Config{
+		Transport: transportFunc(func(ctx context.Context, logs []LogEntry) error {
+			<-block
+			return nil
+		}),
+		ConsoleFormat: ConsoleDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	logger.Info("stuck", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := ShutdownAll(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ShutdownAll() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+	logger.Info("flushed", nil)
+
+	FlushAll()
+
+	// Well before the 1s batch interval would send it
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for len(transport.all()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := len(transport.all()); got != 1 {
+		t.Errorf("delivered %d logs, want 1", got)
+	}
+}
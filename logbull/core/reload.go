@@ -0,0 +1,143 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// configPollInterval is how often ConfigWatcher checks the file for changes.
+const configPollInterval = 2 * time.Second
+
+// ConfigWatcher reloads a configuration file when it changes or the process
+// receives SIGHUP, and applies its LogLevel to the watched loggers and every
+// logger derived from them. Senders and queued logs are kept; settings other
+// than LogLevel need a new logger.
+type ConfigWatcher struct {
+	path    string
+	loggers []*LogBullLogger
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+
+	signals   chan os.Signal
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchConfigFile starts watching path, a file in the format read by
+// ConfigFromFile. Call Close to stop watching.
+func WatchConfigFile(path string, loggers ...*LogBullLogger) (*ConfigWatcher, error) {
+	return watchConfigFile(path, configPollInterval, loggers)
+}
+
+func watchConfigFile(path string, interval time.Duration, loggers []*LogBullLogger) (*ConfigWatcher, error) {
+	if len(loggers) == 0 {
+		return nil, errors.New("no loggers to reload")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	w := &ConfigWatcher{
+		path:    path,
+		loggers: loggers,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		signals: make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// Subscribe before returning so a SIGHUP sent right after does not
+	// terminate the process
+	if len(reloadSignals) > 0 {
+		signal.Notify(w.signals, reloadSignals...)
+	}
+
+	go w.run(interval)
+	return w, nil
+}
+
+// Reload reads the file now and applies it. On error the loggers keep their
+// current settings.
+func (w *ConfigWatcher) Reload() error {
+	config, err := ConfigFromFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	level := config.LogLevel
+	if level == "" {
+		level = INFO
+	}
+
+	var errs []error
+	for _, logger := range w.loggers {
+		if err := logger.SetLevel(level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops watching. It is safe to call more than once.
+func (w *ConfigWatcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
+
+func (w *ConfigWatcher) run(interval time.Duration) {
+	defer close(w.done)
+	defer signal.Stop(w.signals)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.signals:
+			w.reload()
+		case <-ticker.C:
+			if w.changed() {
+				w.reload()
+			}
+		}
+	}
+}
+
+// changed reports whether the file was modified since the last check. A
+// missing file, e.g. while an editor replaces it, counts as unchanged.
+func (w *ConfigWatcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false
+	}
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return true
+}
+
+func (w *ConfigWatcher) reload() {
+	if err := w.Reload(); err != nil {
+		w.loggers[0].config.reportError(err, map[string]any{"operation": "reload_config", "path": w.path})
+	}
+}
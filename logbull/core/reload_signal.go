@@ -0,0 +1,10 @@
+//go:build !plan9
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+var reloadSignals = []os.Signal{syscall.SIGHUP}
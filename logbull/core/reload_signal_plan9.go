@@ -0,0 +1,8 @@
+//go:build plan9
+
+package core
+
+import "os"
+
+// Plan 9 has no SIGHUP; the file is still polled for changes.
+var reloadSignals []os.Signal
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func waitForLevel(t *testing.T, logger *LogBullLogger, want LogLevel) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for logger.Level() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Level() = %s, want %s", logger.Level(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLogBullLogger_SetLevel(t *testing.T) {
+	logger, transport := newCaptureLogger(t)
+	derived := logger.Named("db")
+
+	if err := logger.SetLevel(ERROR); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	derived.Info("filtered", nil)
+	derived.Error("kept", nil)
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	if entries := transport.all(); len(entries) != 1 || entries[0].Message != "kept" {
+		t.Errorf("Entries = %+v, want only the ERROR entry", entries)
+	}
+
+	if err := logger.SetLevel("LOUD"); err == nil {
+		t.Error("SetLevel() expected error for an invalid level")
+	}
+	if err := logger.Freeze().SetLevel(DEBUG); !errors.Is(err, ErrLoggerFrozen) {
+		t.Errorf("SetLevel() on frozen logger error = %v, want ErrLoggerFrozen", err)
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logbull.yaml")
+	writeConfigFile(t, path, "log_level: info\n")
+
+	logger, transport := newCaptureLogger(t)
+	derived := logger.WithField("component", "db")
+
+	watcher, err := watchConfigFile(path, 10*time.Millisecond, []*LogBullLogger{logger})
+	if err != nil {
+		t.Fatalf("watchConfigFile() error = %v", err)
+	}
+	defer watcher.Close()
+
+	logger.Info("before reload", nil)
+
+	writeConfigFile(t, path, "log_level: warning\n")
+	waitForLevel(t, derived, WARNING)
+
+	// Queued logs survive the reload
+	derived.Info("filtered", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	if entries := transport.all(); len(entries) != 1 || entries[0].Message != "before reload" {
+		t.Errorf("Entries = %+v, want only the entry logged before the reload", entries)
+	}
+
+	writeConfigFile(t, path, "log_level: nonsense\n")
+	if err := watcher.Reload(); err == nil {
+		t.Error("Reload() expected error for an invalid file")
+	}
+	if logger.Level() != WARNING {
+		t.Errorf("Level() = %s, want WARNING kept after a failed reload", logger.Level())
+	}
+
+	watcher.Close()
+	watcher.Close()
+}
+
+func TestWatchConfigFile_Errors(t *testing.T) {
+	logger, _ := newCaptureLogger(t)
+
+	if _, err := WatchConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), logger); err == nil {
+		t.Error("WatchConfigFile() expected error for a missing file")
+	}
+	if _, err := WatchConfigFile("logbull.yaml"); err == nil {
+		t.Error("WatchConfigFile() expected error without loggers")
+	}
+}
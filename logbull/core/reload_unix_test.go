@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher_SIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logbull.yaml")
+	writeConfigFile(t, path, "log_level: info\n")
+
+	logger, _ := newCaptureLogger(t)
+
+	// Polling is too slow to explain a reload within the test
+	watcher, err := watchConfigFile(path, time.Hour, []*LogBullLogger{logger})
+	if err != nil {
+		t.Fatalf("watchConfigFile() error = %v", err)
+	}
+	defer watcher.Close()
+
+	writeConfigFile(t, path, "log_level: error\n")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+
+	waitForLevel(t, logger, ERROR)
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrSchemaViolation is the Kind of the *ValidationError returned for entries
+// that do not match Config.Schema.
+var ErrSchemaViolation = errors.New("log schema violation")
+
+// FieldType is a type constraint of Schema.Types.
+type FieldType string
+
+const (
+	FieldTypeString FieldType = "string"
+	FieldTypeNumber FieldType = "number"
+	FieldTypeBool   FieldType = "bool"
+	FieldTypeObject FieldType = "object"
+	FieldTypeArray  FieldType = "array"
+)
+
+// Schema is a logging schema checked before an entry is queued, so
+// violations surface as errors from the Try methods instead of server
+// rejections. Entries of LogBullLogger are checked with their context
+// fields; DefaultFields and metadata are not part of the check.
+type Schema struct {
+	// Required lists fields every entry must carry.
+	Required []string
+	// Levels restricts entries to these levels. Empty allows every level.
+	Levels []LogLevel
+	// Types constrains the type of fields when they are present.
+	Types map[string]FieldType
+}
+
+// Validate reports every way entry violates the schema in one
+// *ValidationError. A nil Schema accepts everything.
+func (s *Schema) Validate(entry LogEntry) error {
+	if s == nil {
+		return nil
+	}
+
+	var problems []string
+
+	if len(s.Levels) > 0 && !s.allowsLevel(entry.Level) {
+		problems = append(problems, fmt.Sprintf("level %s is not allowed", entry.Level))
+	}
+
+	for _, key := range s.Required {
+		if _, ok := entry.Fields[key]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required field %q", key))
+		}
+	}
+
+	keys := make([]string, 0, len(s.Types))
+	for key := range s.Types {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := entry.Fields[key]
+		if !ok {
+			continue
+		}
+		want := s.Types[key]
+		if got := fieldTypeOf(value); got != want {
+			if got == "" {
+				got = FieldType(fmt.Sprintf("%T", value))
+			}
+			problems = append(problems, fmt.Sprintf("field %q is %s, want %s", key, got, want))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Kind:    ErrSchemaViolation,
+		Message: fmt.Sprintf("log schema violation: %s (message=%q)", strings.Join(problems, "; "), entry.Message),
+	}
+}
+
+func (s *Schema) allowsLevel(level string) bool {
+	for _, allowed := range s.Levels {
+		if allowed.String() == level {
+			return true
+		}
+	}
+	return false
+}
+
+// check rejects schemas with unknown levels or types.
+func (s *Schema) check() error {
+	if s == nil {
+		return nil
+	}
+
+	for _, level := range s.Levels {
+		if level.Priority() == 0 {
+			return fmt.Errorf("unknown level '%s'", level)
+		}
+	}
+
+	for key, fieldType := range s.Types {
+		switch fieldType {
+		case FieldTypeString, FieldTypeNumber, FieldTypeBool, FieldTypeObject, FieldTypeArray:
+		default:
+			return fmt.Errorf("unknown type '%s' for field %q", fieldType, key)
+		}
+	}
+
+	return nil
+}
+
+// fieldTypeOf returns the schema type of value as it is sent in JSON, or ""
+// for null and values that match no FieldType, such as functions.
+func fieldTypeOf(value any) FieldType {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case json.Number:
+		return FieldTypeNumber
+	case []byte:
+		return FieldTypeString
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		if err != nil || len(data) == 0 {
+			return ""
+		}
+		return jsonFieldType(data[0])
+	case encoding.TextMarshaler:
+		return FieldTypeString
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+	if kind == reflect.Pointer {
+		elem := reflect.ValueOf(value)
+		if elem.IsNil() {
+			return ""
+		}
+		kind = elem.Elem().Kind()
+	}
+
+	switch kind {
+	case reflect.String:
+		return FieldTypeString
+	case reflect.Bool:
+		return FieldTypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return FieldTypeNumber
+	case reflect.Map, reflect.Struct:
+		return FieldTypeObject
+	case reflect.Slice, reflect.Array:
+		return FieldTypeArray
+	}
+	return ""
+}
+
+func jsonFieldType(first byte) FieldType {
+	switch first {
+	case '"':
+		return FieldTypeString
+	case 't', 'f':
+		return FieldTypeBool
+	case '{':
+		return FieldTypeObject
+	case '[':
+		return FieldTypeArray
+	case 'n':
+		return ""
+	}
+	return FieldTypeNumber
+}
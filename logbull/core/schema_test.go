@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	schema := &Schema{
+		Required: []string{"service"},
+		Levels:   []LogLevel{INFO, ERROR},
+		Types: map[string]FieldType{
+			"duration_ms": FieldTypeNumber,
+			"retry":       FieldTypeBool,
+			"tags":        FieldTypeArray,
+			"user":        FieldTypeObject,
+			"started_at":  FieldTypeString,
+		},
+	}
+
+	valid := LogEntry{Level: "INFO", Message: "done", Fields: map[string]any{
+		"service":     "checkout",
+		"duration_ms": int64(12),
+		"retry":       false,
+		"tags":        []string{"a"},
+		"user":        map[string]any{"id": 1},
+		"started_at":  time.Now(),
+	}}
+	if err := schema.Validate(valid); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	invalid := LogEntry{Level: "DEBUG", Message: "done", Fields: map[string]any{
+		"duration_ms": "12",
+		"tags":        nil,
+	}}
+	err := schema.Validate(invalid)
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Validate() error = %v, want ErrSchemaViolation", err)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %T, want *ValidationError", err)
+	}
+	for _, want := range []string{
+		"level DEBUG is not allowed",
+		`missing required field "service"`,
+		`field "duration_ms" is string, want number`,
+		`field "tags" is <nil>, want array`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+		}
+	}
+
+	var none *Schema
+	if err := none.Validate(invalid); err != nil {
+		t.Errorf("nil Schema Validate() error = %v", err)
+	}
+}
+
+func TestSchema_Check(t *testing.T) {
+	if _, err := NewLogger(Config{Schema: &Schema{Levels: []LogLevel{"VERBOSE"}}}); err == nil {
+		t.Error("NewLogger() with an unknown schema level expected error")
+	}
+	if _, err := NewLogger(Config{Schema: &Schema{Types: map[string]FieldType{"id": "uuid"}}}); err == nil {
+		t.Error("NewLogger() with an unknown schema type expected error")
+	}
+}
+
+func TestLogBullLogger_Schema(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{
+		Transport:     transport,
+		ConsoleFormat: ConsoleDisabled,
+		Schema:        &Schema{Required: []string{"request_id"}},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	if err := logger.TryInfo("missing", nil); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("TryInfo() error = %v, want ErrSchemaViolation", err)
+	}
+	if err := logger.WithField("request_id", "r1").TryInfo("from context", nil); err != nil {
+		t.Errorf("TryInfo() with context field error = %v", err)
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	entries := transport.all()
+	if len(entries) != 1 || entries[0].Message != "from context" {
+		t.Errorf("Sent entries = %+v, want only the valid one", entries)
+	}
+}
+
+func TestSender_Schema(t *testing.T) {
+	var reported []error
+	sender, err := NewSender(&Config{
+		Transport:    &captureTransport{},
+		Schema:       &Schema{Types: map[string]FieldType{"status": FieldTypeNumber}},
+		ErrorHandler: func(err error, _ map[string]any) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	entry := LogEntry{Level: "INFO", Message: "request", Fields: map[string]any{"status": "200"}}
+	if err := sender.TryAddLog(entry); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("TryAddLog() error = %v, want ErrSchemaViolation", err)
+	}
+
+	sender.AddLog(entry)
+	if len(reported) != 1 || !errors.Is(reported[0], ErrSchemaViolation) {
+		t.Errorf("Reported errors = %v, want one ErrSchemaViolation", reported)
+	}
+}
@@ -2,197 +2,1366 @@ package core
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
 )
 
 const (
 	batchSize     = 1_000
 	batchInterval = 1 * time.Second
 	queueCapacity = 10_000
-	minWorkers    = 1
 	maxWorkers    = 10
 	httpTimeout   = 30 * time.Second
+
+	// maxPendingBatches is how many drained batches may wait for a free
+	// worker. When they are all taken, logs stay in the log queue.
+	maxPendingBatches = 10
+
+	authRetryInterval = 1 * time.Minute
+
+	defaultShutdownTimeout = 10 * time.Second
+
+	defaultFlattenMaxDepth = 5
+	defaultFlattenMaxKeys  = 100
 )
 
+// BatchIDHeader carries the client-generated ID of each batch request, so the
+// server can drop a batch it has already received.
+const BatchIDHeader = "X-Batch-ID"
+
 type Sender struct {
 	config       *Config
-	logQueue     chan LogEntry
+	queues       *logQueues
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
 	shutdownOnce sync.Once
-	client       *http.Client
-	workerSem    chan struct{}
+	// shutdownReport is written once inside shutdownOnce
+	shutdownReport ShutdownReport
+	client         *http.Client
+	inflight       inflightTracker
+
+	// batchSlots bounds drained batches (pending plus being delivered), and
+	// batchQueue hands them to the fixed worker pool of workers goroutines
+	batchSlots  chan struct{}
+	batchQueue  chan []LogEntry
+	workers     int
+	dispatchMu  sync.RWMutex
+	dispatching bool
+	// orderMu keeps batches in batchQueue in the order they were drained
+	// with StrictOrdering
+	orderMu sync.Mutex
+
+	// flushCh wakes the batch processor once a full batch is queued, and
+	// queuedBytes estimates the encoded size of the log queue
+	flushCh     chan struct{}
+	queuedBytes atomic.Int64
+
+	activeWorkers   atomic.Int64
+	enqueuedLogs    atomic.Uint64
+	droppedLogs     atomic.Uint64
+	deferredFlushes atomic.Uint64
+	dedupedLogs     atomic.Uint64
+	suppressedLogs  atomic.Uint64
+	expiredLogs     atomic.Uint64
+	sentBatches     atomic.Uint64
+	sentLogs        atomic.Uint64
+	rejectedLogs    atomic.Uint64
+	sendErrors      atomic.Uint64
+	hedgedRequests  atomic.Uint64
+
+	rejectedFileMu sync.Mutex
+	apiKeyCache    apiKeyCache
+
+	stream  *ndjsonStream
+	dedup   *deduplicator
+	limiter *rateLimiter
+	budget  *budgetWatch
+
+	hosts    atomic.Pointer[hostSet]
+	metadata map[string]any
+	sourceID string
+	sequence atomic.Uint64
+
+	authFailedAt  atomic.Int64
+	lastAuthProbe atomic.Int64
+
+	unreachableSince atomic.Int64
+	// hostFailures counts consecutive failed requests for failOver, and
+	// resendMu orders re-sent batches before later ones
+	hostFailures     atomic.Int32
+	lastPrimaryProbe atomic.Int64
+	resendMu         sync.RWMutex
+	// jsonFallback is set once the server rejected Config.Encoder
+	jsonFallback atomic.Bool
+	// capabilities is set once the server answered the capability probe
+	capabilities atomic.Pointer[ServerCapabilities]
+
+	// wal is set when Config.WALDir is
+	wal *writeAheadLog
+
+	// consoleMirrored is set when the owning LogBullLogger already prints
+	// every entry to the console
+	consoleMirrored bool
+}
+
+// checkSender validates the settings NewSender uses, without side effects.
+func (c *Config) checkSender() error {
+	if err := validation.ValidateLogFields(c.DefaultFields); err != nil {
+		return fmt.Errorf("invalid DefaultFields: %w", err)
+	}
+
+	if level := c.ImmediateFlushLevel; level != "" && level.Priority() == 0 {
+		return fmt.Errorf("invalid ImmediateFlushLevel '%s'", level)
+	}
+
+	switch c.TimestampStrategy {
+	case "", TimestampUnique, TimestampSequence:
+	default:
+		return fmt.Errorf("invalid TimestampStrategy '%s'", c.TimestampStrategy)
+	}
+
+	if !validTimestampFormat(c.TimestampFormat) {
+		return fmt.Errorf("invalid TimestampFormat '%s'", c.TimestampFormat)
+	}
+
+	if c.Encoder != nil && c.Protocol != "" && c.Protocol != ProtocolBatch {
+		return fmt.Errorf("invalid Encoder: requires ProtocolBatch, got '%s'", c.Protocol)
+	}
+
+	if err := c.Schema.check(); err != nil {
+		return fmt.Errorf("invalid Schema: %w", err)
+	}
+
+	if err := checkRateLimits(c.RateLimitByLevel); err != nil {
+		return fmt.Errorf("invalid RateLimitByLevel: %w", err)
+	}
+
+	switch c.BatchFormat {
+	case "", BatchFormatRows, BatchFormatColumnar:
+	default:
+		return fmt.Errorf("invalid BatchFormat '%s'", c.BatchFormat)
+	}
+
+	if err := c.ErrorBudget.check(); err != nil {
+		return fmt.Errorf("invalid ErrorBudget: %w", err)
+	}
+
+	if _, err := senderHosts(c); err != nil {
+		return fmt.Errorf("invalid Hosts: %w", err)
+	}
+
+	if c.HedgeAfter < 0 {
+		return fmt.Errorf("invalid HedgeAfter %v", c.HedgeAfter)
+	}
+	return nil
 }
 
 func NewSender(config *Config) (*Sender, error) {
+	if err := config.checkSender(); err != nil {
+		return nil, err
+	}
+
+	transport, err := newHTTPTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, _ := senderHosts(config)
+
+	var wal *writeAheadLog
+	var walSegments []string
+	if config.WALDir != "" {
+		wal, walSegments, err = openWAL(config, config.WALDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WALDir: %w", err)
+		}
+	}
+
+	workers := maxWorkers
+	if config.StrictOrdering {
+		workers = 1
+	}
+
 	s := &Sender{
-		config:    config,
-		logQueue:  make(chan LogEntry, queueCapacity),
-		stopCh:    make(chan struct{}),
-		client:    &http.Client{Timeout: httpTimeout},
-		workerSem: make(chan struct{}, maxWorkers),
+		config:      config,
+		queues:      newLogQueues(queueCapacity),
+		stopCh:      make(chan struct{}),
+		client:      &http.Client{Timeout: httpTimeout, Transport: transport},
+		batchSlots:  make(chan struct{}, workers+maxPendingBatches),
+		batchQueue:  make(chan []LogEntry, workers+maxPendingBatches),
+		workers:     workers,
+		dispatching: true,
+		flushCh:     make(chan struct{}, 1),
+		metadata:    detectMetadata(config),
+		wal:         wal,
+	}
+
+	s.hosts.Store(&hostSet{hosts: hosts})
+
+	if config.sequenced() {
+		s.sourceID = config.SourceID
+		if s.sourceID == "" {
+			s.sourceID = newSourceID()
+		}
+	}
+
+	if config.Protocol == ProtocolNDJSON && config.Transport == nil {
+		s.stream = newNDJSONStream(s)
+	}
+
+	if config.DedupWindow > 0 {
+		s.dedup = newDeduplicator(config.DedupWindow)
+	}
+
+	if len(config.RateLimitByLevel) > 0 {
+		s.limiter = newRateLimiter(config.RateLimitByLevel, config.clock().Now())
 	}
 
-	for i := 0; i < minWorkers; i++ {
-		s.workerSem <- struct{}{}
+	if config.ErrorBudget != nil {
+		s.budget = newBudgetWatch(*config.ErrorBudget, config.clock().Now())
 	}
 
 	registerSender(s)
+	s.negotiate()
 
-	s.wg.Add(1)
+	s.wg.Add(1 + workers)
 	go s.batchProcessor()
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+
+	if len(walSegments) > 0 {
+		s.wg.Add(1)
+		go s.replayWAL(walSegments)
+	}
 
 	return s, nil
 }
 
 func (s *Sender) AddLog(entry LogEntry) {
+	_ = s.AddLogCtx(context.Background(), entry)
+}
+
+// AddLogCtx is AddLog for callers with a deadline, such as request handlers:
+// with OverflowBlock, it stops waiting for room in a full queue when ctx is
+// done, dropping entry. Like AddLog, it reports a dropped or invalid entry to
+// ErrorHandler; it also returns the error, which wraps both ErrQueueFull and
+// ctx.Err() when ctx ended the wait.
+func (s *Sender) AddLogCtx(ctx context.Context, entry LogEntry) error {
+	err := s.tryAddLog(ctx, entry)
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		s.config.reportError(err, map[string]any{"operation": "enqueue"})
+	case errors.Is(err, ErrSchemaViolation):
+		s.config.reportError(err, map[string]any{"operation": "validate"})
+	case errors.Is(err, ErrLogPanic):
+		s.config.reportError(err, map[string]any{"operation": "enqueue"})
+	}
+	return err
+}
+
+// TryAddLog enqueues entry, returning ErrSchemaViolation for entries that do
+// not match Config.Schema.
+func (s *Sender) TryAddLog(entry LogEntry) error {
+	return s.tryAddLog(context.Background(), entry)
+}
+
+func (s *Sender) tryAddLog(ctx context.Context, entry LogEntry) (err error) {
+	defer recoverLogPanic(&err)
+
+	if err := s.config.Schema.Validate(entry); err != nil {
+		return err
+	}
+	// Fields built by the caller may hold values JSON cannot encode, or
+	// that panic doing so, which would fail the whole batch in the worker
+	entry.Fields = formatting.EnsureFields(entry.Fields, formatting.KeyPolicy{})
+	return s.tryAdd(ctx, entry, true)
+}
+
+// tryAdd enqueues entry. owned reports that entry.Fields was built for this
+// entry alone, so prepareEntry may add fields without copying the map first.
+// With OverflowBlock, ctx bounds the wait for room in a full queue.
+func (s *Sender) tryAdd(ctx context.Context, entry LogEntry, owned bool) error {
 	select {
-	case s.logQueue <- entry:
 	case <-s.stopCh:
+		return ErrSenderShutdown
+	default:
+	}
+
+	if s.dedup != nil {
+		admitted, summary := s.dedup.admit(entry, s.config.clock().Now())
+		if summary != nil {
+			_ = s.add(ctx, *summary, true)
+		}
+		if !admitted {
+			s.dedupedLogs.Add(1)
+			return nil
+		}
+	}
+
+	if s.limiter != nil && !s.limiter.allow(entry, s.config.clock().Now()) {
+		s.suppressedLogs.Add(1)
+		return nil
+	}
+
+	return s.add(ctx, entry, owned)
+}
+
+// add prepares and enqueues an entry that passed deduplication.
+func (s *Sender) add(ctx context.Context, entry LogEntry, owned bool) error {
+	entry = s.prepareEntry(entry, owned)
+	s.appendWAL(&entry)
+	s.stampQueued(&entry)
+
+	immediate := s.flushesImmediately(entry)
+	if immediate && s.dispatchEntry(entry) {
+		return nil
+	}
+
+	select {
+	case s.queues.of(entry) <- s.queues.wrap(entry):
+		s.markEnqueued(entry)
+		if immediate {
+			// Every worker is busy; send it with the next free one
+			s.requestFlush()
+		}
+		return nil
 	default:
-		fmt.Fprintf(os.Stderr, "LogBull: log queue full, dropping log\n")
+	}
+
+	switch s.config.OverflowPolicy {
+	case OverflowDropOldest:
+		return s.addDroppingOldest(entry)
+	case OverflowBlock:
+		return s.addBlocking(ctx, entry, s.config.BlockTimeout)
+	default:
+		s.releaseWAL([]LogEntry{entry})
+		s.drop(entry)
+		return ErrQueueFull
 	}
 }
 
+// addDroppingOldest makes room by dropping the oldest entry of the same
+// priority, so lower priorities never evict ERROR entries.
+func (s *Sender) addDroppingOldest(entry LogEntry) error {
+	queue := s.queues.of(entry)
+	for {
+		select {
+		case queue <- s.queues.wrap(entry):
+			s.markEnqueued(entry)
+			return nil
+		default:
+		}
+
+		select {
+		case oldest := <-queue:
+			s.queuedBytes.Add(-estimateEntrySize(oldest.LogEntry))
+			s.drop(oldest.LogEntry)
+		default:
+			// A worker drained the queue in the meantime; retry the send
+		}
+	}
+}
+
+// addAudit queues an audit entry whatever the OverflowPolicy, waiting for
+// room in a full queue instead of dropping it.
+func (s *Sender) addAudit(entry LogEntry) error {
+	select {
+	case <-s.stopCh:
+		return ErrSenderShutdown
+	default:
+	}
+
+	entry = s.prepareEntry(entry, true)
+	s.appendWAL(&entry)
+
+	select {
+	case s.queues.of(entry) <- s.queues.wrap(entry):
+		s.markEnqueued(entry)
+		return nil
+	default:
+	}
+
+	return s.addBlocking(context.Background(), entry, 0)
+}
+
+// addBlocking waits up to blockTimeout for room in the queue, or until the
+// sender is shut down when blockTimeout is zero, and at most until ctx is
+// done.
+func (s *Sender) addBlocking(ctx context.Context, entry LogEntry, blockTimeout time.Duration) error {
+	// Start sending right away instead of waiting for the next tick
+	s.sendBatch()
+
+	var timeout <-chan time.Time
+	if blockTimeout > 0 {
+		timer := time.NewTimer(blockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case s.queues.of(entry) <- s.queues.wrap(entry):
+		s.markEnqueued(entry)
+		return nil
+	case <-s.stopCh:
+		s.releaseWAL([]LogEntry{entry})
+		return ErrSenderShutdown
+	case <-timeout:
+		s.releaseWAL([]LogEntry{entry})
+		s.drop(entry)
+		return ErrQueueFull
+	case <-ctx.Done():
+		s.releaseWAL([]LogEntry{entry})
+		s.drop(entry)
+		return fmt.Errorf("%w: %w", ErrQueueFull, ctx.Err())
+	}
+}
+
+// markEnqueued counts an accepted entry and wakes the batch processor when
+// the queue holds a full batch, by count or by MaxBatchBytes, so it is sent
+// without waiting for the next tick.
+func (s *Sender) markEnqueued(entry LogEntry) {
+	s.enqueuedLogs.Add(1)
+	queuedBytes := s.queuedBytes.Add(estimateEntrySize(entry))
+
+	full := s.queues.len() >= batchSize
+	if limit := s.maxBatchBytes(); limit > 0 && queuedBytes >= int64(limit) {
+		full = true
+	}
+	if full {
+		s.requestFlush()
+	}
+}
+
+// requestFlush wakes the batch processor to send a batch now.
+func (s *Sender) requestFlush() {
+	select {
+	case s.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Sender) drop(entry LogEntry) {
+	s.droppedLogs.Add(1)
+	if s.config.OnDrop != nil {
+		s.config.OnDrop(entry)
+	}
+	s.writeFallback([]LogEntry{entry})
+}
+
+// SetHost sends logs to host from now on. It replaces Config.Hosts, which
+// disables failover.
+func (s *Sender) SetHost(host string) error {
+	host = strings.TrimSpace(host)
+	if err := validation.ValidateHostURL(host); err != nil {
+		return err
+	}
+
+	previous := s.hosts.Swap(&hostSet{hosts: []string{host}})
+	s.hostChanged(previous.current(), host)
+	return nil
+}
+
+// hostChanged resets what the sender learned about the previous host.
+func (s *Sender) hostChanged(previous, host string) {
+	if previous == host {
+		return
+	}
+
+	if s.negotiates() {
+		// The new host may run another server release
+		s.capabilities.Store(nil)
+		s.jsonFallback.Store(false)
+		s.negotiate()
+	}
+
+	// The stream request was opened against the previous host; the next
+	// write reopens it against the new one
+	if s.stream != nil {
+		s.stream.close()
+	}
+}
+
+// Host returns the host logs are currently sent to.
+func (s *Sender) Host() string {
+	return s.hosts.Load().current()
+}
+
 func (s *Sender) Flush() {
 	s.sendBatch()
 }
 
+// FlushSync sends every queued log and waits until all in-flight batches
+// have been delivered, or until ctx is done.
+func (s *Sender) FlushSync(ctx context.Context) error {
+	for s.queues.len() > 0 {
+		if _, err := s.dispatchBatch(ctx); err != nil {
+			return err
+		}
+	}
+
+	return s.inflight.wait(ctx)
+}
+
 func (s *Sender) Shutdown() {
+	s.ShutdownWithReport()
+}
+
+// ShutdownReport tells what happened to the logs queued when Shutdown was
+// called.
+type ShutdownReport struct {
+	// Flushed counts logs handed to a worker for delivery.
+	Flushed int
+	// Abandoned counts logs still queued after ShutdownTimeout. They are
+	// dropped, reaching OnDrop and FallbackWriter.
+	Abandoned int
+}
+
+// ShutdownWithReport stops the sender like Shutdown and reports how many
+// queued logs were flushed and abandoned. Later calls return the same report.
+func (s *Sender) ShutdownWithReport() ShutdownReport {
 	s.shutdownOnce.Do(func() {
 		close(s.stopCh)
-		s.sendBatch()
+		s.expireDedup(true)
+		s.reportSuppressed()
+		s.shutdownReport = s.drain()
+
+		s.dispatchMu.Lock()
+		s.dispatching = false
+		close(s.batchQueue)
+		s.dispatchMu.Unlock()
+
 		s.wg.Wait()
+
+		if s.wal != nil {
+			s.wal.close()
+		}
+
+		if s.stream != nil {
+			s.stream.close()
+		}
+
+		unregisterSender(s)
 	})
+
+	return s.shutdownReport
+}
+
+// drain dispatches batches until the log queue is empty or ShutdownTimeout
+// has passed, then drops the logs left over.
+func (s *Sender) drain() ShutdownReport {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
+	defer cancel()
+
+	var report ShutdownReport
+	for s.queues.len() > 0 {
+		n, err := s.dispatchBatch(ctx)
+		if err != nil {
+			break
+		}
+		report.Flushed += n
+	}
+
+	for {
+		entry, ok := s.queues.pop()
+		if !ok {
+			break
+		}
+		s.queuedBytes.Add(-estimateEntrySize(entry))
+		s.drop(entry)
+		report.Abandoned++
+	}
+
+	if report.Abandoned > 0 {
+		s.config.reportError(
+			fmt.Errorf("shutdown timed out after %v, abandoning %d queued logs", s.shutdownTimeout(), report.Abandoned),
+			map[string]any{"operation": "shutdown", "abandoned": report.Abandoned},
+		)
+	}
+
+	return report
+}
+
+// expireDedup queues the summaries of closed deduplication windows.
+func (s *Sender) expireDedup(all bool) {
+	if s.dedup == nil {
+		return
+	}
+	for _, summary := range s.dedup.expire(s.config.clock().Now(), all) {
+		_ = s.add(context.Background(), summary, true)
+	}
+}
+
+func (s *Sender) shutdownTimeout() time.Duration {
+	if s.config.ShutdownTimeout > 0 {
+		return s.config.ShutdownTimeout
+	}
+	return defaultShutdownTimeout
+}
+
+// Stats is a point-in-time snapshot of the sender's queues and workers.
+type Stats struct {
+	// QueuedLogs is the number of logs waiting in the log queues.
+	QueuedLogs int
+	// PendingBatches is the number of batches waiting for a free worker.
+	PendingBatches int
+	// ActiveWorkers is the number of batches currently being delivered.
+	ActiveWorkers int
+	MaxWorkers    int
+	// EnqueuedLogs counts logs accepted into the log queue.
+	EnqueuedLogs uint64
+	// DroppedLogs counts logs that will never be delivered: rejected with
+	// ErrQueueFull, held back while credentials are rejected, or larger than
+	// MaxBatchBytes.
+	DroppedLogs uint64
+	// DeferredFlushes counts flushes skipped because every worker and
+	// pending batch slot was taken; the logs stay queued.
+	DeferredFlushes uint64
+	// DedupedLogs counts duplicates collapsed into repeat_count summaries.
+	DedupedLogs uint64
+	// SuppressedLogs counts logs over their level's RateLimitByLevel.
+	SuppressedLogs uint64
+	// ExpiredLogs counts logs dropped after waiting longer than MaxLogAge.
+	ExpiredLogs uint64
+	// SentBatches counts batch requests accepted by the server or Transport,
+	// SentLogs the logs in them and RejectedLogs those the server rejected.
+	SentBatches  uint64
+	SentLogs     uint64
+	RejectedLogs uint64
+	// SendErrors counts batch requests that failed.
+	SendErrors uint64
+	// HedgedRequests counts second copies of slow batch requests sent for
+	// Config.HedgeAfter.
+	HedgedRequests uint64
+}
+
+func (s *Sender) Stats() Stats {
+	return Stats{
+		QueuedLogs:      s.queues.len(),
+		PendingBatches:  len(s.batchQueue),
+		ActiveWorkers:   int(s.activeWorkers.Load()),
+		MaxWorkers:      s.workers,
+		EnqueuedLogs:    s.enqueuedLogs.Load(),
+		DroppedLogs:     s.droppedLogs.Load(),
+		DeferredFlushes: s.deferredFlushes.Load(),
+		DedupedLogs:     s.dedupedLogs.Load(),
+		SuppressedLogs:  s.suppressedLogs.Load(),
+		ExpiredLogs:     s.expiredLogs.Load(),
+		SentBatches:     s.sentBatches.Load(),
+		SentLogs:        s.sentLogs.Load(),
+		RejectedLogs:    s.rejectedLogs.Load(),
+		SendErrors:      s.sendErrors.Load(),
+		HedgedRequests:  s.hedgedRequests.Load(),
+	}
+}
+
+func (s *Sender) prepareEntry(entry LogEntry, owned bool) LogEntry {
+	if s.config.FlattenFields && len(entry.Fields) > 0 {
+		entry.Fields = formatting.Flatten(entry.Fields, s.flattenMaxDepth(), s.flattenMaxKeys())
+		owned = true
+	}
+
+	if s.fingerprinting() {
+		entry, owned = s.addFingerprint(entry, owned)
+	}
+
+	if fields, changed := formatting.TruncateValues(entry.Fields, s.config.MaxFieldValueLength); changed {
+		entry.Fields = fields
+		owned = true
+	}
+
+	entry = s.addSenderFields(entry, owned)
+
+	if s.config.MaxEntryBytes > 0 {
+		entry = limitEntrySize(entry, s.config.MaxEntryBytes)
+	}
+	return entry
+}
+
+// addSenderFields merges the metadata, retention and sequence fields into
+// entry.
+func (s *Sender) addSenderFields(entry LogEntry, owned bool) LogEntry {
+	retention, hasRetention := s.config.RetentionByLevel[LogLevel(entry.Level)]
+	if !s.config.sequenced() && !hasRetention && len(s.metadata) == 0 {
+		return entry
+	}
+
+	fields := entry.Fields
+	if !owned || fields == nil {
+		fields = make(map[string]any, len(entry.Fields)+len(s.metadata)+3)
+		for key, value := range entry.Fields {
+			fields[key] = value
+		}
+	}
+
+	for key, value := range s.metadata {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	if _, ok := fields[RetentionField]; hasRetention && !ok {
+		fields[RetentionField] = retentionSeconds(retention)
+	}
+
+	if s.config.sequenced() {
+		fields["sequence"] = s.sequence.Add(1)
+		fields["source_id"] = s.sourceID
+	}
+
+	entry.Fields = fields
+	return entry
+}
+
+func (s *Sender) flattenMaxDepth() int {
+	if s.config.FlattenMaxDepth > 0 {
+		return s.config.FlattenMaxDepth
+	}
+	return defaultFlattenMaxDepth
+}
+
+func (s *Sender) flattenMaxKeys() int {
+	if s.config.FlattenMaxKeys > 0 {
+		return s.config.FlattenMaxKeys
+	}
+	return defaultFlattenMaxKeys
 }
 
 func (s *Sender) batchProcessor() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(batchInterval)
+	ticker := s.config.clock().NewTicker(batchInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
+			s.expireDedup(false)
+			s.reportSuppressed()
+			s.checkErrorBudget()
+			s.probePrimary()
+			s.sendBatch()
+		case <-s.flushCh:
 			s.sendBatch()
+			// The tick only bounds latency for partial batches
+			ticker.Reset(batchInterval)
 		case <-s.stopCh:
 			return
 		}
 	}
 }
 
+// batchPool recycles batch slices between sends so steady-state logging does
+// not allocate a fresh backing array for every batch.
+var batchPool = sync.Pool{
+	New: func() any {
+		logs := make([]LogEntry, 0, batchSize)
+		return &logs
+	},
+}
+
+func releaseBatch(logs []LogEntry) {
+	clear(logs)
+	logs = logs[:0]
+	batchPool.Put(&logs)
+}
+
 func (s *Sender) sendBatch() {
-	var logs []LogEntry
+	_, _ = s.dispatchBatch(nil)
+}
 
-	for i := 0; i < batchSize; i++ {
+// dispatchBatch drains up to batchSize logs and hands them to the worker
+// pool, returning how many it handed over. With a nil ctx it gives up when no
+// batch slot is free, leaving the logs queued; otherwise it waits for a slot
+// until ctx is done.
+func (s *Sender) dispatchBatch(ctx context.Context) (int, error) {
+	s.dispatchMu.RLock()
+	defer s.dispatchMu.RUnlock()
+
+	if !s.dispatching {
+		if ctx != nil {
+			return 0, ErrSenderShutdown
+		}
+		return 0, nil
+	}
+
+	if ctx == nil {
 		select {
-		case log := <-s.logQueue:
-			logs = append(logs, log)
+		case s.batchSlots <- struct{}{}:
 		default:
-			goto send
+			if s.queues.len() > 0 {
+				s.deferredFlushes.Add(1)
+			}
+			return 0, nil
+		}
+	} else {
+		select {
+		case s.batchSlots <- struct{}{}:
+		case <-ctx.Done():
+			return 0, ctx.Err()
 		}
 	}
 
-send:
-	if len(logs) == 0 {
-		return
+	if s.config.StrictOrdering {
+		s.orderMu.Lock()
+		defer s.orderMu.Unlock()
 	}
 
-	select {
-	case <-s.workerSem:
-		s.wg.Add(1)
-		go func(batch []LogEntry) {
-			defer s.wg.Done()
-			defer func() { s.workerSem <- struct{}{} }()
+	logs := *batchPool.Get().(*[]LogEntry)
+
+	logs = s.queues.popBatch(logs, batchSize)
+
+	var drainedBytes int64
+	for _, log := range logs {
+		drainedBytes += estimateEntrySize(log)
+	}
+
+	s.queuedBytes.Add(-drainedBytes)
+	logs = s.dropExpired(logs)
+
+	paused := len(logs) > 0 && s.deliveryPaused()
+	if len(logs) == 0 || paused {
+		if paused {
+			s.divertPaused(logs)
+		}
+		releaseBatch(logs)
+		<-s.batchSlots
+		return 0, nil
+	}
+
+	s.inflight.add()
+	s.batchQueue <- logs
+	return len(logs), nil
+}
 
-			s.sendHTTPRequest(batch)
-		}(logs)
+func (s *Sender) flushesImmediately(entry LogEntry) bool {
+	threshold := s.config.ImmediateFlushLevel
+	return threshold != "" && LogLevel(entry.Level).Priority() >= threshold.Priority()
+}
+
+// dispatchEntry hands entry to the worker pool as a batch of its own. It
+// returns false when no batch slot is free, so the caller queues it instead.
+func (s *Sender) dispatchEntry(entry LogEntry) bool {
+	s.dispatchMu.RLock()
+	defer s.dispatchMu.RUnlock()
+
+	if !s.dispatching {
+		return false
+	}
+
+	select {
+	case s.batchSlots <- struct{}{}:
 	default:
-		s.wg.Add(1)
-		go func(batch []LogEntry) {
-			defer s.wg.Done()
-			s.sendHTTPRequest(batch)
-		}(logs)
+		return false
 	}
+
+	s.enqueuedLogs.Add(1)
+
+	if s.deliveryPaused() {
+		s.divertPaused([]LogEntry{entry})
+		<-s.batchSlots
+		return true
+	}
+
+	logs := *batchPool.Get().(*[]LogEntry)
+	logs = append(logs, entry)
+
+	if s.config.StrictOrdering {
+		s.orderMu.Lock()
+		defer s.orderMu.Unlock()
+	}
+	s.inflight.add()
+	s.batchQueue <- logs
+	return true
 }
 
-func (s *Sender) sendHTTPRequest(logs []LogEntry) {
-	batch := LogBatch{Logs: logs}
+func (s *Sender) worker() {
+	defer s.wg.Done()
 
-	data, err := json.Marshal(batch)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: failed to marshal batch: %v\n", err)
+	for batch := range s.batchQueue {
+		s.activeWorkers.Add(1)
+		s.deliver(batch)
+		s.activeWorkers.Add(-1)
+
+		releaseBatch(batch)
+		<-s.batchSlots
+		s.inflight.done()
+	}
+}
+
+func (s *Sender) deliver(logs []LogEntry) {
+	if s.config.Transport != nil {
+		batchID := newBatchID()
+		ctx := contextWithBatchID(context.Background(), batchID)
+		if err := s.config.Transport.Send(ctx, logs); err != nil {
+			s.sendErrors.Add(1)
+			s.config.reportError(fmt.Errorf("transport failed: %w", err), map[string]any{"operation": "send", "logs": len(logs), "batch_id": batchID})
+			s.markUnreachable(logs)
+			s.markFailed(logs, err)
+			return
+		}
+		s.markSent(batchID, logs, LogBullResponse{Accepted: len(logs)})
+		return
+	}
+
+	if s.stream != nil {
+		if err := s.stream.write(logs); err != nil {
+			s.sendErrors.Add(1)
+			s.markUnreachable(logs)
+			s.markFailed(logs, err)
+			return
+		}
+		s.markSent(newBatchID(), logs, LogBullResponse{Accepted: len(logs)})
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/v1/logs/receiving/%s", s.config.Host, s.config.ProjectID)
-	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	s.sendHTTPRequest(logs)
+}
+
+func (s *Sender) sendHTTPRequest(logs []LogEntry) {
+	data, err := s.encodeBatch(logs)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: failed to create request: %v\n", err)
+		s.sendErrors.Add(1)
+		s.config.reportError(fmt.Errorf("failed to marshal batch: %w", err), map[string]any{"operation": "encode", "logs": len(logs)})
+		s.markFailed(logs, err)
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "LogBull-Go-Client/1.0")
-	if s.config.APIKey != "" {
-		req.Header.Set("X-API-Key", s.config.APIKey)
+	if limit := s.maxBatchBytes(); limit > 0 && len(data) > limit {
+		if len(logs) == 1 {
+			s.config.reportErrorf(
+				map[string]any{"operation": "encode", "bytes": len(data), "limit": limit},
+				"dropping log exceeding MaxBatchBytes (%s)",
+				formatting.PreviewEntry(logs[0].Message, logs[0].Fields),
+			)
+			s.droppedLogs.Add(1)
+			// Sending it again after a restart would fail the same way
+			s.releaseWAL(logs)
+			s.markFailed(logs, fmt.Errorf("log of %d bytes exceeds MaxBatchBytes (%d)", len(data), limit))
+			return
+		}
+
+		half := len(logs) / 2
+		s.sendHTTPRequest(logs[:half])
+		s.sendHTTPRequest(logs[half:])
+		return
 	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: HTTP request failed: %v\n", err)
+	batchID := newBatchID()
+
+	s.awaitResend()
+	host := s.Host()
+	err = s.postBatch(logs, host, data, batchID, false)
+	if err == nil {
+		// Only answers of the current host end a run of failures
+		s.hostFailures.Store(0)
+		return
+	}
+
+	// Held from before failOver may switch hosts until the re-send is done,
+	// so batches waiting in awaitResend cannot reach the next host first
+	s.resendMu.Lock()
+	next, ok := s.failOver(host)
+	if ok {
+		s.resend(logs, next, data, batchID)
+	}
+	s.resendMu.Unlock()
+	if ok {
 		return
 	}
+
+	s.markUnreachable(logs)
+	s.markFailed(logs, err)
+}
+
+// postBatch sends an encoded batch to host. It returns an error only when
+// host is unreachable (network errors and 5xx responses), leaving the batch
+// to the caller; every other outcome is handled here. resent is set when the
+// batch is sent again to another host after failing.
+func (s *Sender) postBatch(logs []LogEntry, host string, data []byte, batchID string, resent bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := s.newBatchRequest(ctx, host, data)
+	if err != nil {
+		cancel()
+		s.sendErrors.Add(1)
+		s.config.reportError(fmt.Errorf("failed to create request: %w", err), map[string]any{"operation": "send", "logs": len(logs)})
+		s.markFailed(logs, err)
+		return nil
+	}
+
+	req.Header.Set(BatchIDHeader, batchID)
+
+	if s.config.BeforeSend != nil {
+		s.config.BeforeSend(req)
+	}
+
+	resp, cancel, err := s.doHedged(req, cancel)
+	defer cancel()
+	if s.config.AfterSend != nil {
+		s.config.AfterSend(resp, err)
+	}
+	if err != nil {
+		s.sendErrors.Add(1)
+		s.config.reportError(fmt.Errorf("HTTP request failed: %w", err), map[string]any{"operation": "send", "logs": len(logs), "batch_id": batchID, "host": host})
+		return err
+	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "LogBull: failed to close response body: %v\n", err)
+			s.config.reportError(fmt.Errorf("failed to close response body: %w", err), map[string]any{"operation": "send"})
 		}
 	}()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "LogBull: failed to read response: %v\n", err)
-		return
+		s.sendErrors.Add(1)
+		s.config.reportError(fmt.Errorf("failed to read response: %w", err), map[string]any{"operation": "send", "logs": len(logs)})
+		s.markFailed(logs, err)
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		s.sendErrors.Add(1)
+		s.markUnauthorized(resp.StatusCode, body)
+		s.divertPaused(logs)
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusUnsupportedMediaType && s.fallBackToJSON() {
+		if !resent {
+			s.sendHTTPRequest(logs)
+			return nil
+		}
+
+		// Keep the ID of the batch being re-sent
+		data, err := s.encodeBatch(logs)
+		if err != nil {
+			s.sendErrors.Add(1)
+			s.config.reportError(fmt.Errorf("failed to marshal batch: %w", err), map[string]any{"operation": "encode", "logs": len(logs)})
+			s.markFailed(logs, err)
+			return nil
+		}
+		return s.postBatch(logs, host, data, batchID, true)
 	}
 
 	if resp.StatusCode != 200 && resp.StatusCode != 202 {
-		fmt.Fprintf(os.Stderr, "LogBull: server returned status %d: %s\n", resp.StatusCode, string(body))
-		return
+		s.sendErrors.Add(1)
+		s.config.reportErrorf(
+			map[string]any{"operation": "send", "logs": len(logs), "batch_id": batchID, "status": resp.StatusCode, "body": string(body), "host": host},
+			"server returned status %d: %s",
+			resp.StatusCode,
+			string(body),
+		)
+		err := fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode >= 500 {
+			return err
+		}
+		s.markFailed(logs, err)
+		return nil
 	}
 
+	s.markAuthorized()
+
 	var response LogBullResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return
+	if s.config.Protocol == ProtocolOTLP {
+		response = s.handleOTLPResponse(body, len(logs))
+	} else if err := json.Unmarshal(body, &response); err != nil {
+		response = LogBullResponse{Accepted: len(logs)}
 	}
 
-	if response.Rejected > 0 {
+	s.markSent(batchID, logs, response)
+
+	if s.config.Protocol != ProtocolOTLP && response.Rejected > 0 {
 		s.handleRejectedLogs(response, logs)
 	}
+	return nil
+}
+
+func (s *Sender) markSent(batchID string, logs []LogEntry, response LogBullResponse) {
+	s.sentBatches.Add(1)
+	s.sentLogs.Add(uint64(len(logs)))
+	s.rejectedLogs.Add(uint64(response.Rejected))
+	s.unreachableSince.Store(0)
+	s.releaseWAL(logs)
+
+	if s.config.OnSent != nil {
+		s.config.OnSent(batchID, logs)
+	}
+	if s.config.OnDelivered != nil {
+		s.config.OnDelivered(LogBatch{Logs: logs}, response)
+	}
+}
+
+func (s *Sender) markFailed(logs []LogEntry, err error) {
+	if s.config.OnDeliveryFailed != nil {
+		s.config.OnDeliveryFailed(LogBatch{Logs: logs}, err)
+	}
+}
+
+func (s *Sender) encodeBatch(logs []LogEntry) ([]byte, error) {
+	if s.config.Protocol == ProtocolOTLP {
+		return encodeOTLP(logs, s.metadata, s.config.ProjectID)
+	}
+
+	logs = s.wireLogs(logs)
+
+	if s.sendsColumnar() {
+		return s.encoder().(valueEncoder).encodeValue(columnarBatch(logs))
+	}
+
+	batch := LogBatch{Logs: logs}
+	if s.config.CompactBatchFields {
+		batch = compactBatch(logs)
+	}
+	return s.encoder().Encode(batch)
+}
+
+func (s *Sender) batchURL(host string) string {
+	if s.config.Protocol == ProtocolOTLP {
+		return host + "/v1/logs"
+	}
+	return fmt.Sprintf("%s/api/v1/logs/receiving/%s", host, s.config.ProjectID)
+}
+
+func (s *Sender) newBatchRequest(ctx context.Context, host string, data []byte) (*http.Request, error) {
+	gzipped := s.gzipsRequests()
+	if gzipped {
+		var err error
+		if data, err = gzipBody(data); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.batchURL(host), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	apiKey, err := s.apiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/json"
+	if s.config.Protocol != ProtocolOTLP {
+		contentType = s.encoder().ContentType()
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "LogBull-Go-Client/1.0")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	return req, nil
+}
+
+// Ping sends an empty batch to the receiving endpoint to check connectivity
+// and credentials. It returns ErrUnauthorized when the server rejects the
+// project ID or API key. It always succeeds with a custom Transport.
+func (s *Sender) Ping(ctx context.Context) error {
+	if s.config.Transport != nil {
+		return nil
+	}
+	return s.pingHost(ctx, s.Host())
+}
+
+func (s *Sender) pingHost(ctx context.Context, host string) error {
+	empty := []byte(`{"resourceLogs":[]}`)
+	if s.config.Protocol != ProtocolOTLP {
+		var err error
+		if empty, err = s.encoder().Encode(LogBatch{Logs: []LogEntry{}}); err != nil {
+			return fmt.Errorf("ping failed: %w", err)
+		}
+	}
+
+	req, err := s.newBatchRequest(ctx, host, empty)
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		s.markUnauthorized(resp.StatusCode, body)
+		return fmt.Errorf("%w (status %d: %s)", ErrUnauthorized, resp.StatusCode, string(body))
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("ping failed: server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	s.markAuthorized()
+	return nil
+}
+
+// deliveryPaused reports whether batches should be dropped because the server
+// rejected our credentials. One batch per authRetryInterval is let through as
+// a re-authentication probe.
+func (s *Sender) deliveryPaused() bool {
+	if s.authFailedAt.Load() == 0 {
+		return false
+	}
+
+	lastProbe := s.lastAuthProbe.Load()
+	now := s.config.clock().Now().UnixNano()
+	if now-lastProbe < int64(authRetryInterval) {
+		return true
+	}
+
+	return !s.lastAuthProbe.CompareAndSwap(lastProbe, now)
+}
+
+func (s *Sender) markUnauthorized(statusCode int, body []byte) {
+	s.invalidateAPIKey()
+
+	now := s.config.clock().Now().UnixNano()
+	s.lastAuthProbe.Store(now)
+
+	if s.authFailedAt.CompareAndSwap(0, now) {
+		s.config.reportErrorf(
+			map[string]any{"operation": "authenticate", "status": statusCode, "body": string(body)},
+			"%w (status %d: %s). Logs will be written to the console or RejectedLogsFile instead of LogBull server; retrying authentication every %s",
+			ErrUnauthorized,
+			statusCode,
+			string(body),
+			authRetryInterval,
+		)
+	}
+}
+
+// divertPaused keeps logs that cannot be delivered while the server rejects
+// our credentials: they are appended to RejectedLogsFile when set, and
+// printed to the console otherwise, unless the owning LogBullLogger already
+// did.
+func (s *Sender) divertPaused(logs []LogEntry) {
+	s.droppedLogs.Add(uint64(len(logs)))
+	s.markFailed(logs, ErrUnauthorized)
+
+	if s.config.RejectedLogsFile != "" {
+		held := make([]RejectedLogEntry, 0, len(logs))
+		for _, log := range logs {
+			held = append(held, RejectedLogEntry{Entry: log, Reason: ErrUnauthorized.Error()})
+		}
+		s.dumpRejectedLogs(held)
+		return
+	}
+
+	if s.consoleMirrored {
+		return
+	}
+	for _, log := range logs {
+		printConsoleEntry(s.config, "", log)
+	}
+}
+
+func (s *Sender) markAuthorized() {
+	if s.authFailedAt.Swap(0) != 0 {
+		s.config.notice("authentication restored, resuming log delivery")
+	}
 }
 
 func (s *Sender) handleRejectedLogs(response LogBullResponse, sentLogs []LogEntry) {
-	fmt.Fprintf(os.Stderr, "LogBull: Rejected %d log entries\n", response.Rejected)
-
-	if len(response.Errors) > 0 {
-		fmt.Fprintf(os.Stderr, "LogBull: Rejected log details:\n")
-		for _, err := range response.Errors {
-			if err.Index >= 0 && err.Index < len(sentLogs) {
-				log := sentLogs[err.Index]
-				fmt.Fprintf(os.Stderr, "  - Log #%d rejected (%s):\n", err.Index, err.Message)
-				fmt.Fprintf(os.Stderr, "    Level: %s\n", log.Level)
-				fmt.Fprintf(os.Stderr, "    Message: %s\n", log.Message)
-				fmt.Fprintf(os.Stderr, "    Timestamp: %s\n", log.Timestamp)
-				if len(log.Fields) > 0 {
-					fmt.Fprintf(os.Stderr, "    Fields: %v\n", log.Fields)
-				}
+	s.config.reportErrorf(
+		map[string]any{"operation": "send", "rejected": response.Rejected},
+		"server rejected %d log entries",
+		response.Rejected,
+	)
+
+	var rejected []RejectedLogEntry
+
+	for _, err := range response.Errors {
+		if err.Index >= 0 && err.Index < len(sentLogs) {
+			log := sentLogs[err.Index]
+			context := map[string]any{
+				"operation": "send",
+				"index":     err.Index,
+				"level":     log.Level,
+				"message":   log.Message,
+				"timestamp": log.Timestamp,
 			}
+			if len(log.Fields) > 0 {
+				context["fields"] = log.Fields
+			}
+			s.config.reportErrorf(context, "log #%d rejected: %s", err.Index, err.Message)
+
+			rejected = append(rejected, RejectedLogEntry{Entry: log, Reason: err.Message})
 		}
 	}
+
+	if len(rejected) == 0 {
+		return
+	}
+
+	if s.config.RejectedLogsFile != "" {
+		s.dumpRejectedLogs(rejected)
+	}
+
+	if s.config.OnRejected != nil {
+		s.config.OnRejected(rejected)
+	}
+}
+
+func (s *Sender) dumpRejectedLogs(rejected []RejectedLogEntry) {
+	s.rejectedFileMu.Lock()
+	defer s.rejectedFileMu.Unlock()
+
+	file, err := os.OpenFile(s.config.RejectedLogsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		s.config.reportError(fmt.Errorf("failed to open rejected logs file: %w", err), map[string]any{"operation": "dump_rejected"})
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.config.reportError(fmt.Errorf("failed to close rejected logs file: %w", err), map[string]any{"operation": "dump_rejected"})
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range rejected {
+		if err := encoder.Encode(entry); err != nil {
+			s.config.reportError(fmt.Errorf("failed to write rejected log: %w", err), map[string]any{"operation": "dump_rejected"})
+			return
+		}
+	}
+}
+
+// newBatchID returns a random UUID (version 4).
+func newBatchID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	}
+	buf[6] = (buf[6] & 0x0f) | 0x40
+	buf[8] = (buf[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
+}
+
+func newSourceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return hex.EncodeToString(buf)
 }
@@ -1,11 +1,23 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -65,8 +77,9 @@ func TestSender_AddLog(t *testing.T) {
 		Fields:    map[string]any{"key": "value"},
 	})
 
-	sender.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -117,8 +130,9 @@ func TestSender_Batching(t *testing.T) {
 		})
 	}
 
-	sender.Flush()
-	time.Sleep(200 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -178,10 +192,12 @@ func TestSender_LargeBatch(t *testing.T) {
 		})
 	}
 
-	sender.Flush()
-	time.Sleep(200 * time.Millisecond)
-	sender.Flush() // Flush again to send remaining logs
-	time.Sleep(500 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -234,8 +250,9 @@ func TestSender_HTTPHeaders(t *testing.T) {
 		Fields:    map[string]any{},
 	})
 
-	sender.Flush()
-	time.Sleep(200 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -287,8 +304,81 @@ func TestSender_RejectedLogs(t *testing.T) {
 		Fields:    map[string]any{},
 	})
 
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+}
+
+func TestSender_RejectedLogsCallbackAndFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{
+			Accepted: 0,
+			Rejected: 1,
+			Errors: []RejectedLog{
+				{
+					Index:   0,
+					Message: "Invalid log format",
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	rejectedCh := make(chan []RejectedLogEntry, 1)
+	dumpFile := filepath.Join(t.TempDir(), "rejected.jsonl")
+
+	config := &Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		OnRejected: func(entries []RejectedLogEntry) {
+			rejectedCh <- entries
+		},
+		RejectedLogsFile: dumpFile,
+	}
+
+	sender, err := NewSender(config)
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{
+		Level:     "INFO",
+		Message:   "rejected message",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields:    map[string]any{},
+	})
+
 	sender.Flush()
-	time.Sleep(200 * time.Millisecond)
+
+	select {
+	case entries := <-rejectedCh:
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 rejected entry, got %d", len(entries))
+		}
+		if entries[0].Entry.Message != "rejected message" {
+			t.Errorf("Expected rejected message, got %q", entries[0].Entry.Message)
+		}
+		if entries[0].Reason != "Invalid log format" {
+			t.Errorf("Expected rejection reason, got %q", entries[0].Reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnRejected was not called")
+	}
+
+	data, err := os.ReadFile(dumpFile)
+	if err != nil {
+		t.Fatalf("Failed to read rejected logs file: %v", err)
+	}
+
+	var dumped RejectedLogEntry
+	if err := json.Unmarshal(bytes.TrimSpace(data), &dumped); err != nil {
+		t.Fatalf("Failed to decode rejected logs file: %v", err)
+	}
+	if dumped.Entry.Message != "rejected message" {
+		t.Errorf("Expected dumped message, got %q", dumped.Entry.Message)
+	}
 }
 
 func TestSender_ServerError(t *testing.T) {
@@ -316,8 +406,9 @@ func TestSender_ServerError(t *testing.T) {
 		Fields:    map[string]any{},
 	})
 
-	sender.Flush()
-	time.Sleep(200 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestSender_ConcurrentSending(t *testing.T) {
@@ -362,8 +453,9 @@ func TestSender_ConcurrentSending(t *testing.T) {
 	}
 
 	wg.Wait()
-	sender.Flush()
-	time.Sleep(500 * time.Millisecond)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -373,86 +465,1691 @@ func TestSender_ConcurrentSending(t *testing.T) {
 	}
 }
 
-func TestSender_Shutdown(t *testing.T) {
+func TestSender_TryAddLogQueueFull(t *testing.T) {
+	sender := &Sender{
+		config: &Config{},
+		queues: newLogQueues(1),
+		stopCh: make(chan struct{}),
+	}
+
+	entry := LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()}
+
+	if err := sender.TryAddLog(entry); err != nil {
+		t.Fatalf("TryAddLog() error = %v", err)
+	}
+
+	if err := sender.TryAddLog(entry); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TryAddLog() error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestSender_Sequence(t *testing.T) {
+	var received []LogEntry
+	var mu sync.Mutex
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch LogBatch
+		json.Unmarshal(body, &batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
 	}))
 	defer server.Close()
 
-	config := &Config{
-		ProjectID: "12345678-1234-1234-1234-123456789012",
-		Host:      server.URL,
+	sender, err := NewSender(&Config{
+		ProjectID:      "12345678-1234-1234-1234-123456789012",
+		Host:           server.URL,
+		EnableSequence: true,
+		SourceID:       "worker-a",
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
 	}
+	defer sender.Shutdown()
 
-	sender, err := NewSender(config)
+	for i := 0; i < 5; i++ {
+		sender.AddLog(LogEntry{
+			Level:     "INFO",
+			Message:   "sequenced",
+			Timestamp: GenerateUniqueTimestamp(),
+			Fields:    map[string]any{},
+		})
+	}
+
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 5 {
+		t.Fatalf("Expected 5 logs, got %d", len(received))
+	}
+
+	for i, entry := range received {
+		if entry.Fields["source_id"] != "worker-a" {
+			t.Errorf("Entry %d source_id = %v, want worker-a", i, entry.Fields["source_id"])
+		}
+		if entry.Fields["sequence"] != float64(i+1) {
+			t.Errorf("Entry %d sequence = %v, want %d", i, entry.Fields["sequence"], i+1)
+		}
+	}
+}
+
+func TestSender_GeneratedSourceID(t *testing.T) {
+	first, err := NewSender(&Config{
+		ProjectID:      "12345678-1234-1234-1234-123456789012",
+		Host:           "http://localhost:4005",
+		EnableSequence: true,
+	})
 	if err != nil {
 		t.Fatalf("NewSender() error = %v", err)
 	}
+	defer first.Shutdown()
 
-	sender.AddLog(LogEntry{
-		Level:     "INFO",
-		Message:   "before shutdown",
-		Timestamp: GenerateUniqueTimestamp(),
-		Fields:    map[string]any{},
+	second, err := NewSender(&Config{
+		ProjectID:      "12345678-1234-1234-1234-123456789012",
+		Host:           "http://localhost:4005",
+		EnableSequence: true,
 	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer second.Shutdown()
 
-	sender.Shutdown()
-
-	sender.AddLog(LogEntry{
-		Level:     "INFO",
-		Message:   "after shutdown",
-		Timestamp: GenerateUniqueTimestamp(),
-		Fields:    map[string]any{},
-	})
+	if first.sourceID == "" || first.sourceID == second.sourceID {
+		t.Errorf("Expected distinct generated source IDs, got %q and %q", first.sourceID, second.sourceID)
+	}
 }
 
-func TestSender_MultipleShutdowns(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+func TestSender_SetHost(t *testing.T) {
+	var firstCount, secondCount int
+	var mu sync.Mutex
+
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		firstCount++
+		mu.Unlock()
 		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
 	}))
-	defer server.Close()
+	defer first.Close()
 
-	config := &Config{
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		secondCount++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer second.Close()
+
+	sender, err := NewSender(&Config{
 		ProjectID: "12345678-1234-1234-1234-123456789012",
-		Host:      server.URL,
+		Host:      first.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	entry := LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp(), Fields: map[string]any{}}
+
+	sender.AddLog(entry)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
 	}
 
-	sender, err := NewSender(config)
+	if err := sender.SetHost("not a url"); err == nil {
+		t.Error("SetHost() expected error for invalid host")
+	}
+
+	if err := sender.SetHost(" " + second.URL + " "); err != nil {
+		t.Fatalf("SetHost() error = %v", err)
+	}
+	if sender.Host() != second.URL {
+		t.Errorf("Host() = %q, want %q", sender.Host(), second.URL)
+	}
+
+	sender.AddLog(entry)
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if firstCount != 1 || secondCount != 1 {
+		t.Errorf("Expected one request per host, got first=%d second=%d", firstCount, secondCount)
+	}
+}
+
+func TestSender_RetentionByLevel(t *testing.T) {
+	sender := &Sender{
+		config: &Config{
+			RetentionByLevel: map[LogLevel]time.Duration{
+				DEBUG: 7 * 24 * time.Hour,
+			},
+		},
+	}
+
+	debug := sender.prepareEntry(LogEntry{Level: "DEBUG", Message: "debug", Fields: map[string]any{}}, false)
+	if debug.Fields[RetentionField] != int64(7*24*60*60) {
+		t.Errorf("DEBUG retention = %v, want 7 days", debug.Fields[RetentionField])
+	}
+
+	explicit := sender.prepareEntry(LogEntry{
+		Level:   "DEBUG",
+		Message: "audit",
+		Fields:  map[string]any{RetentionField: int64(60)},
+	}, false)
+	if explicit.Fields[RetentionField] != int64(60) {
+		t.Errorf("explicit retention = %v, want 60", explicit.Fields[RetentionField])
+	}
+
+	info := sender.prepareEntry(LogEntry{Level: "INFO", Message: "info", Fields: map[string]any{}}, false)
+	if _, ok := info.Fields[RetentionField]; ok {
+		t.Error("INFO entry should not get a retention hint")
+	}
+}
+
+func TestSender_Metadata(t *testing.T) {
+	t.Run("configured and detected fields", func(t *testing.T) {
+		config := &Config{
+			ServiceName:    "checkout",
+			ServiceVersion: "1.4.2",
+			Environment:    "staging",
+		}
+		sender := &Sender{config: config, metadata: detectMetadata(config)}
+
+		fields := map[string]any{"service": "override"}
+		entry := sender.prepareEntry(LogEntry{Level: "INFO", Message: "test", Fields: fields}, false)
+
+		hostname, _ := os.Hostname()
+		expected := map[string]any{
+			"service":         "override",
+			"service_version": "1.4.2",
+			"environment":     "staging",
+			"pid":             os.Getpid(),
+			"host":            hostname,
+		}
+		for key, value := range expected {
+			if entry.Fields[key] != value {
+				t.Errorf("Fields[%s] = %v, want %v", key, entry.Fields[key], value)
+			}
+		}
+
+		if len(fields) != 1 {
+			t.Errorf("prepareEntry() modified a caller-owned map: %v", fields)
+		}
+	})
+
+	t.Run("service name defaults to executable", func(t *testing.T) {
+		metadata := detectMetadata(&Config{})
+		if metadata["service"] != strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") {
+			t.Errorf("service = %v, want executable name", metadata["service"])
+		}
+		if _, ok := metadata["environment"]; ok {
+			t.Error("environment should be omitted when not configured")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		if metadata := detectMetadata(&Config{DisableMetadata: true}); metadata != nil {
+			t.Errorf("detectMetadata() = %v, want nil", metadata)
+		}
+	})
+
+	t.Run("default fields", func(t *testing.T) {
+		defaults := map[string]any{"region": "eu-west-1", "environment": "prod-eu"}
+		config := &Config{Environment: "production", DefaultFields: defaults}
+		sender := &Sender{config: config, metadata: detectMetadata(config)}
+		defaults["region"] = "changed"
+
+		entry := sender.prepareEntry(LogEntry{Level: "INFO", Message: "test", Fields: map[string]any{"build": "abc"}}, true)
+
+		expected := map[string]any{"region": "eu-west-1", "environment": "prod-eu", "build": "abc"}
+		for key, value := range expected {
+			if entry.Fields[key] != value {
+				t.Errorf("Fields[%s] = %v, want %v", key, entry.Fields[key], value)
+			}
+		}
+	})
+
+	t.Run("default fields without metadata", func(t *testing.T) {
+		metadata := detectMetadata(&Config{DisableMetadata: true, DefaultFields: map[string]any{"region": "eu-west-1"}})
+		if len(metadata) != 1 || metadata["region"] != "eu-west-1" {
+			t.Errorf("detectMetadata() = %v, want only region", metadata)
+		}
+	})
+
+	t.Run("invalid default fields", func(t *testing.T) {
+		if _, err := NewSender(&Config{Transport: &captureTransport{}, DefaultFields: map[string]any{" ": 1}}); err == nil {
+			t.Error("NewSender() expected error for an empty DefaultFields key")
+		}
+	})
+}
+
+func TestSender_MaxBatchBytes(t *testing.T) {
+	const limit = 2048
+
+	var mu sync.Mutex
+	var bodySizes []int
+	var received []LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch LogBatch
+		json.Unmarshal(body, &batch)
+
+		mu.Lock()
+		bodySizes = append(bodySizes, len(body))
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		MaxBatchBytes: limit,
+	})
 	if err != nil {
 		t.Fatalf("NewSender() error = %v", err)
 	}
+	defer sender.Shutdown()
 
-	sender.Shutdown()
-	sender.Shutdown()
-	sender.Shutdown()
+	payload := strings.Repeat("x", 200)
+	for i := 0; i < 20; i++ {
+		sender.AddLog(LogEntry{
+			Level:     "INFO",
+			Message:   fmt.Sprintf("entry %d", i),
+			Timestamp: GenerateUniqueTimestamp(),
+			Fields:    map[string]any{"payload": payload},
+		})
+	}
+	sender.AddLog(LogEntry{
+		Level:     "INFO",
+		Message:   "oversized",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields:    map[string]any{"payload": strings.Repeat("x", limit)},
+	})
+
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(bodySizes) < 2 {
+		t.Errorf("Expected the batch to be split, got %d requests", len(bodySizes))
+	}
+	for _, size := range bodySizes {
+		if size > limit {
+			t.Errorf("Request body of %d bytes exceeds limit %d", size, limit)
+		}
+	}
+	if len(received) != 20 {
+		t.Errorf("Expected 20 entries delivered and the oversized one dropped, got %d", len(received))
+	}
 }
 
-func BenchmarkSender_AddLog(b *testing.B) {
+func TestSender_FlushSync(t *testing.T) {
+	var mu sync.Mutex
+	var received int
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		// Slow server: FlushSync must wait for the response
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		received += len(batch.Logs)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
 	}))
 	defer server.Close()
 
-	config := &Config{
+	sender, err := NewSender(&Config{
 		ProjectID: "12345678-1234-1234-1234-123456789012",
 		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
 	}
-
-	sender, _ := NewSender(config)
 	defer sender.Shutdown()
 
-	entry := LogEntry{
-		Level:     "INFO",
-		Message:   "benchmark message",
-		Timestamp: GenerateUniqueTimestamp(),
-		Fields:    map[string]any{"key": "value"},
+	// More than one batch worth of logs
+	total := batchSize + 500
+	for i := 0; i < total; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()})
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	got := received
+	mu.Unlock()
+
+	if got != total {
+		t.Errorf("Delivered %d logs after FlushSync, want %d", got, total)
+	}
+
+	t.Run("context timeout", func(t *testing.T) {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "slow", Timestamp: GenerateUniqueTimestamp()})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := sender.FlushSync(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("FlushSync() error = %v, want DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestSender_Ping(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantErr    bool
+		wantUnauth bool
+	}{
+		{"accepted", http.StatusOK, false, false},
+		{"unauthorized", http.StatusUnauthorized, true, true},
+		{"forbidden", http.StatusForbidden, true, true},
+		{"not found", http.StatusNotFound, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-API-Key") != "test_api_key" {
+					t.Errorf("X-API-Key = %q", r.Header.Get("X-API-Key"))
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			sender, err := NewSender(&Config{
+				ProjectID: "12345678-1234-1234-1234-123456789012",
+				Host:      server.URL,
+				APIKey:    "test_api_key",
+			})
+			if err != nil {
+				t.Fatalf("NewSender() error = %v", err)
+			}
+			defer sender.Shutdown()
+
+			err = sender.Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUnauthorized) != tt.wantUnauth {
+				t.Errorf("Ping() error = %v, want ErrUnauthorized %v", err, tt.wantUnauth)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		sender, err := NewSender(&Config{
+			ProjectID: "12345678-1234-1234-1234-123456789012",
+			Host:      "http://127.0.0.1:1",
+		})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		if err := sender.Ping(context.Background()); err == nil {
+			t.Error("Ping() expected error for unreachable host")
+		}
+	})
+}
+
+func TestSender_UnauthorizedPausesDelivery(t *testing.T) {
+	var requestCount int
+	var authorized bool
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		ok := authorized
+		mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("invalid API key"))
+			return
+		}
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	send := func() {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+
+	send()
+	if sender.authFailedAt.Load() == 0 {
+		t.Fatal("Expected sender to pause delivery after 401")
+	}
+
+	send()
+	mu.Lock()
+	if requestCount != 1 {
+		t.Errorf("Expected paused sender to skip requests, got %d requests", requestCount)
+	}
+	authorized = true
+	mu.Unlock()
+
+	// Pretend the retry interval has elapsed
+	sender.lastAuthProbe.Store(0)
+
+	send()
+	if sender.authFailedAt.Load() != 0 {
+		t.Error("Expected delivery to resume after successful re-authentication")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requestCount != 2 {
+		t.Errorf("Expected one probe request, got %d requests", requestCount)
+	}
+}
+
+func TestSender_Shutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	config := &Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	}
+
+	sender, err := NewSender(config)
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	sender.AddLog(LogEntry{
+		Level:     "INFO",
+		Message:   "before shutdown",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields:    map[string]any{},
+	})
+
+	sender.Shutdown()
+
+	sender.AddLog(LogEntry{
+		Level:     "INFO",
+		Message:   "after shutdown",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields:    map[string]any{},
+	})
+}
+
+func TestSender_ShutdownDrainsQueue(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	// More than one batch, all queued before the processor runs
+	for i := 0; i < 3*batchSize+10; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "queued", Timestamp: GenerateUniqueTimestamp()})
+	}
+
+	report := sender.ShutdownWithReport()
+
+	if got := len(transport.all()); got != 3*batchSize+10 {
+		t.Errorf("Delivered %d logs, want %d", got, 3*batchSize+10)
+	}
+	if report.Abandoned != 0 {
+		t.Errorf("Abandoned = %d, want 0", report.Abandoned)
+	}
+	if again := sender.ShutdownWithReport(); again != report {
+		t.Errorf("Second ShutdownWithReport() = %+v, want %+v", again, report)
+	}
+}
+
+func TestSender_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var dropped atomic.Int64
+
+	sender, err := NewSender(&Config{
+		Transport: transportFunc(func(ctx context.Context, logs []LogEntry) error {
+			<-release
+			return nil
+		}),
+		ImmediateFlushLevel: ERROR,
+		ShutdownTimeout:     50 * time.Millisecond,
+		OnDrop:              func(LogEntry) { dropped.Add(1) },
+		Silent:              true,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	// Immediate entries take every batch slot while the transport blocks
+	for i := 0; i < maxWorkers+maxPendingBatches; i++ {
+		sender.AddLog(LogEntry{Level: "ERROR", Message: "blocking", Timestamp: GenerateUniqueTimestamp()})
+	}
+	for i := 0; i < 5; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "stuck", Timestamp: GenerateUniqueTimestamp()})
+	}
+
+	time.AfterFunc(200*time.Millisecond, func() { close(release) })
+	report := sender.ShutdownWithReport()
+
+	if report.Abandoned != 5 || report.Flushed != 0 {
+		t.Errorf("ShutdownWithReport() = %+v, want 5 abandoned", report)
+	}
+	if dropped.Load() != 5 {
+		t.Errorf("OnDrop called %d times, want 5", dropped.Load())
+	}
+}
+
+func TestSender_MultipleShutdowns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	config := &Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	}
+
+	sender, err := NewSender(config)
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	sender.Shutdown()
+	sender.Shutdown()
+	sender.Shutdown()
+}
+
+func BenchmarkSender_AddLog(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	config := &Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	}
+
+	sender, _ := NewSender(config)
+	defer sender.Shutdown()
+
+	entry := LogEntry{
+		Level:     "INFO",
+		Message:   "benchmark message",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields:    map[string]any{"key": "value"},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
 		sender.AddLog(entry)
 	}
 }
+
+func TestSender_BoundedWorkers(t *testing.T) {
+	var mu sync.Mutex
+	var active, maxActive, received int
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		active--
+		received += len(batch.Logs)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+	defer unblock()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	added := 0
+	addLogs := func(n int) {
+		for i := 0; i < n; i++ {
+			if err := sender.TryAddLog(LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()}); err == nil {
+				added++
+			}
+		}
+	}
+
+	// Occupy every worker and every pending batch slot
+	for i := 0; i < 2; i++ {
+		addLogs(queueCapacity)
+		for j := 0; j < maxWorkers+maxPendingBatches; j++ {
+			sender.Flush()
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sender.Stats().ActiveWorkers < maxWorkers && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	addLogs(batchSize)
+	sender.Flush()
+
+	stats := sender.Stats()
+	if stats.ActiveWorkers != maxWorkers {
+		t.Errorf("Stats().ActiveWorkers = %d, want %d", stats.ActiveWorkers, maxWorkers)
+	}
+	if stats.PendingBatches != maxPendingBatches {
+		t.Errorf("Stats().PendingBatches = %d, want %d", stats.PendingBatches, maxPendingBatches)
+	}
+	if stats.QueuedLogs == 0 {
+		t.Error("Stats().QueuedLogs = 0, want logs left in the queue")
+	}
+	if stats.DeferredFlushes == 0 {
+		t.Error("Stats().DeferredFlushes = 0, want back-pressure to be reported")
+	}
+
+	// Fill the log queue so further logs are dropped
+	addLogs(queueCapacity)
+	if err := sender.TryAddLog(LogEntry{Level: "INFO", Message: "dropped"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("TryAddLog() error = %v, want ErrQueueFull", err)
+	}
+	if stats := sender.Stats(); stats.DroppedLogs == 0 {
+		t.Error("Stats().DroppedLogs = 0, want dropped logs to be counted")
+	}
+
+	unblock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sender.FlushSync(ctx); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxActive > maxWorkers {
+		t.Errorf("Observed %d concurrent requests, want at most %d", maxActive, maxWorkers)
+	}
+	if received != added {
+		t.Errorf("Delivered %d logs, want %d", received, added)
+	}
+}
+
+// orderTransport records the sequence numbers it receives and the most
+// batches it was sent concurrently.
+type orderTransport struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	sequences []uint64
+}
+
+func (o *orderTransport) Send(_ context.Context, logs []LogEntry) error {
+	o.mu.Lock()
+	o.active++
+	o.maxActive = max(o.maxActive, o.active)
+	o.mu.Unlock()
+
+	// Give other workers the chance to overtake this batch
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.active--
+	for _, log := range logs {
+		o.sequences = append(o.sequences, log.Fields["sequence"].(uint64))
+	}
+	return nil
+}
+
+func TestSender_StrictOrdering(t *testing.T) {
+	transport := &orderTransport{}
+	sender, err := NewSender(&Config{Transport: transport, EnableSequence: true, StrictOrdering: true})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	const n = 8 * batchSize
+	for i := 0; i < n; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()})
+		if i%(batchSize/2) == 0 {
+			sender.Flush()
+		}
+	}
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if stats := sender.Stats(); stats.MaxWorkers != 1 {
+		t.Errorf("Stats().MaxWorkers = %d, want 1", stats.MaxWorkers)
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if transport.maxActive != 1 {
+		t.Errorf("Observed %d concurrent batches, want 1", transport.maxActive)
+	}
+	if len(transport.sequences) != n {
+		t.Fatalf("Delivered %d logs, want %d", len(transport.sequences), n)
+	}
+	for i := 1; i < n; i++ {
+		if transport.sequences[i] <= transport.sequences[i-1] {
+			t.Fatalf("Log %d has sequence %d after %d, want delivery in queue order", i, transport.sequences[i], transport.sequences[i-1])
+		}
+	}
+}
+
+func TestSender_APIKeyProvider(t *testing.T) {
+	var mu sync.Mutex
+	var seenKeys []string
+	validKey := "key-1"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		key := r.Header.Get("X-API-Key")
+		seenKeys = append(seenKeys, key)
+		valid := key == validKey
+		mu.Unlock()
+
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	var calls int
+	providerKey := "key-1"
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		APIKeyProvider: func() (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			return providerKey, nil
+		},
+		APIKeyCacheTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		if err := sender.Ping(context.Background()); err != nil {
+			t.Fatalf("Ping() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("Provider called %d times, want 1 while the key is cached", calls)
+	}
+	// Rotate the key server-side and in the secret store
+	validKey = "key-2"
+	providerKey = "key-2"
+	mu.Unlock()
+
+	if err := sender.Ping(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Ping() error = %v, want ErrUnauthorized with the stale key", err)
+	}
+	if err := sender.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v, want the rotated key to be fetched after rejection", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls != 2 {
+		t.Errorf("Provider called %d times, want 2", calls)
+	}
+	if last := seenKeys[len(seenKeys)-1]; last != "key-2" {
+		t.Errorf("Last X-API-Key = %q, want key-2", last)
+	}
+}
+
+func TestSender_APIKeyProviderError(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	providerErr := errors.New("secret store unavailable")
+	fail := true
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		APIKeyProvider: func() (string, error) {
+			if fail {
+				return "", providerErr
+			}
+			return "key-1", nil
+		},
+		APIKeyCacheTTL: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	if err := sender.Ping(context.Background()); !errors.Is(err, providerErr) {
+		t.Errorf("Ping() error = %v, want provider error", err)
+	}
+	if requests != 0 {
+		t.Errorf("Sent %d requests without an API key, want 0", requests)
+	}
+
+	// A previously fetched key is reused when the provider fails later
+	fail = false
+	if err := sender.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	fail = true
+	if err := sender.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v, want the cached key to be reused", err)
+	}
+}
+
+func TestSender_DeliveryStats(t *testing.T) {
+	var fail bool
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			sender.AddLog(LogEntry{Level: "INFO", Message: "test", Timestamp: GenerateUniqueTimestamp()})
+		}
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+
+	send(5)
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+
+	send(3)
+
+	stats := sender.Stats()
+	if stats.EnqueuedLogs != 8 {
+		t.Errorf("Stats().EnqueuedLogs = %d, want 8", stats.EnqueuedLogs)
+	}
+	if stats.SentBatches != 1 {
+		t.Errorf("Stats().SentBatches = %d, want 1", stats.SentBatches)
+	}
+	if stats.SendErrors != 1 {
+		t.Errorf("Stats().SendErrors = %d, want 1", stats.SendErrors)
+	}
+}
+
+func TestSender_OverflowPolicy(t *testing.T) {
+	// A sender without workers, so the queue only drains when the test reads it
+	newFullSender := func(config *Config) *Sender {
+		s := &Sender{
+			config:     config,
+			queues:     newLogQueues(2),
+			stopCh:     make(chan struct{}),
+			batchSlots: make(chan struct{}),
+		}
+		s.queues.queues[priorityLow] <- s.queues.wrap(LogEntry{Message: "first"})
+		s.queues.queues[priorityLow] <- s.queues.wrap(LogEntry{Message: "second"})
+		return s
+	}
+
+	queued := func(s *Sender) []string {
+		var messages []string
+		for {
+			entry, ok := s.queues.pop()
+			if !ok {
+				return messages
+			}
+			messages = append(messages, entry.Message)
+		}
+	}
+
+	t.Run("drop newest", func(t *testing.T) {
+		var dropped []string
+		s := newFullSender(&Config{OnDrop: func(entry LogEntry) { dropped = append(dropped, entry.Message) }})
+
+		if err := s.TryAddLog(LogEntry{Message: "third"}); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("TryAddLog() error = %v, want ErrQueueFull", err)
+		}
+		if got := strings.Join(dropped, ","); got != "third" {
+			t.Errorf("OnDrop received %q, want third", got)
+		}
+		if got := strings.Join(queued(s), ","); got != "first,second" {
+			t.Errorf("Queue = %q, want first,second", got)
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		var dropped []string
+		s := newFullSender(&Config{
+			OverflowPolicy: OverflowDropOldest,
+			OnDrop:         func(entry LogEntry) { dropped = append(dropped, entry.Message) },
+		})
+
+		if err := s.TryAddLog(LogEntry{Message: "third"}); err != nil {
+			t.Errorf("TryAddLog() error = %v", err)
+		}
+		if got := strings.Join(dropped, ","); got != "first" {
+			t.Errorf("OnDrop received %q, want first", got)
+		}
+		if got := strings.Join(queued(s), ","); got != "second,third" {
+			t.Errorf("Queue = %q, want second,third", got)
+		}
+		if stats := s.Stats(); stats.DroppedLogs != 1 {
+			t.Errorf("Stats().DroppedLogs = %d, want 1", stats.DroppedLogs)
+		}
+	})
+
+	t.Run("block until room", func(t *testing.T) {
+		s := newFullSender(&Config{OverflowPolicy: OverflowBlock})
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			s.queues.pop()
+		}()
+
+		start := time.Now()
+		if err := s.TryAddLog(LogEntry{Message: "third"}); err != nil {
+			t.Errorf("TryAddLog() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("TryAddLog() returned after %v, want it to block until room", elapsed)
+		}
+		if got := strings.Join(queued(s), ","); got != "second,third" {
+			t.Errorf("Queue = %q, want second,third", got)
+		}
+	})
+
+	t.Run("block timeout", func(t *testing.T) {
+		var dropped int
+		s := newFullSender(&Config{
+			OverflowPolicy: OverflowBlock,
+			BlockTimeout:   20 * time.Millisecond,
+			OnDrop:         func(LogEntry) { dropped++ },
+		})
+
+		if err := s.TryAddLog(LogEntry{Message: "third"}); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("TryAddLog() error = %v, want ErrQueueFull", err)
+		}
+		if dropped != 1 {
+			t.Errorf("OnDrop called %d times, want 1", dropped)
+		}
+	})
+
+	t.Run("block interrupted by shutdown", func(t *testing.T) {
+		s := newFullSender(&Config{OverflowPolicy: OverflowBlock})
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(s.stopCh)
+		}()
+
+		if err := s.TryAddLog(LogEntry{Message: "third"}); !errors.Is(err, ErrSenderShutdown) {
+			t.Errorf("TryAddLog() error = %v, want ErrSenderShutdown", err)
+		}
+	})
+
+	t.Run("block bounded by context", func(t *testing.T) {
+		var dropped, reported int
+		s := newFullSender(&Config{
+			OverflowPolicy: OverflowBlock,
+			OnDrop:         func(LogEntry) { dropped++ },
+			ErrorHandler:   func(error, map[string]any) { reported++ },
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := s.AddLogCtx(ctx, LogEntry{Message: "third"})
+		if !errors.Is(err, ErrQueueFull) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("AddLogCtx() error = %v, want ErrQueueFull and context.DeadlineExceeded", err)
+		}
+		if dropped != 1 || reported != 1 {
+			t.Errorf("OnDrop called %d times, ErrorHandler %d times, want 1 each", dropped, reported)
+		}
+		if got := strings.Join(queued(s), ","); got != "first,second" {
+			t.Errorf("Queue = %q, want first,second", got)
+		}
+
+		s.queues.pop()
+		if err := s.AddLogCtx(ctx, LogEntry{Message: "fourth"}); err != nil {
+			t.Errorf("AddLogCtx() with room and a done context error = %v, want nil", err)
+		}
+	})
+}
+
+func TestSender_ProxyURL(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      "http://logbull.internal:4005",
+		ProxyURL:  proxy.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	if err := sender.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if proxiedHost != "logbull.internal:4005" {
+		t.Errorf("Proxy received host %q, want logbull.internal:4005", proxiedHost)
+	}
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		for _, proxyURL := range []string{"ftp://proxy:21", "http://", "://bad"} {
+			if _, err := NewSender(&Config{Host: "http://localhost:4005", ProxyURL: proxyURL}); err == nil {
+				t.Errorf("NewSender(ProxyURL=%q) expected error", proxyURL)
+			}
+		}
+	})
+}
+
+func TestNewHTTPTransport_Tuning(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		transport, err := newHTTPTransport(&Config{})
+		if err != nil {
+			t.Fatalf("newHTTPTransport() error = %v", err)
+		}
+		if transport.MaxIdleConnsPerHost != maxWorkers {
+			t.Errorf("MaxIdleConnsPerHost = %d, want %d", transport.MaxIdleConnsPerHost, maxWorkers)
+		}
+		if !transport.ForceAttemptHTTP2 {
+			t.Error("ForceAttemptHTTP2 = false, want HTTP/2 attempted by default")
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		transport, err := newHTTPTransport(&Config{
+			MaxIdleConnsPerHost: 200,
+			IdleConnTimeout:     5 * time.Minute,
+			DisableHTTP2:        true,
+		})
+		if err != nil {
+			t.Fatalf("newHTTPTransport() error = %v", err)
+		}
+		if transport.MaxIdleConnsPerHost != 200 || transport.MaxIdleConns < 200 {
+			t.Errorf("MaxIdleConnsPerHost = %d, MaxIdleConns = %d", transport.MaxIdleConnsPerHost, transport.MaxIdleConns)
+		}
+		if transport.IdleConnTimeout != 5*time.Minute {
+			t.Errorf("IdleConnTimeout = %v", transport.IdleConnTimeout)
+		}
+	})
+
+	t.Run("http2", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		for _, tt := range []struct {
+			disable bool
+			want    string
+		}{{false, "HTTP/2.0"}, {true, "HTTP/1.1"}} {
+			transport, err := newHTTPTransport(&Config{DisableHTTP2: tt.disable})
+			if err != nil {
+				t.Fatalf("newHTTPTransport() error = %v", err)
+			}
+			transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			transport.CloseIdleConnections()
+
+			if string(body) != tt.want {
+				t.Errorf("DisableHTTP2=%v: server saw %s, want %s", tt.disable, body, tt.want)
+			}
+		}
+	})
+
+	t.Run("dial context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		var dialed atomic.Int64
+		sender, err := NewSender(&Config{
+			ProjectID: "12345678-1234-1234-1234-123456789012",
+			Host:      "http://logbull.internal:4005",
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialed.Add(1)
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		if err := sender.Ping(context.Background()); err != nil {
+			t.Fatalf("Ping() error = %v", err)
+		}
+		if dialed.Load() == 0 {
+			t.Error("Expected the custom DialContext to be used")
+		}
+	})
+}
+
+func TestSender_BatchID(t *testing.T) {
+	batchIDPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	t.Run("http header matches OnSent", func(t *testing.T) {
+		headers := make(chan string, 2)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers <- r.Header.Get(BatchIDHeader)
+			json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+		}))
+		defer server.Close()
+
+		sent := make(chan string, 2)
+		sender, err := NewSender(&Config{
+			ProjectID: "12345678-1234-1234-1234-123456789012",
+			Host:      server.URL,
+			OnSent:    func(batchID string, logs []LogEntry) { sent <- batchID },
+		})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		for i := 0; i < 2; i++ {
+			sender.AddLog(LogEntry{Level: "INFO", Message: "hello", Timestamp: FormatTimestamp(time.Time{})})
+			if err := sender.FlushSync(context.Background()); err != nil {
+				t.Fatalf("FlushSync() error = %v", err)
+			}
+		}
+
+		first, second := <-headers, <-headers
+		if !batchIDPattern.MatchString(first) {
+			t.Errorf("%s = %q, want a UUID", BatchIDHeader, first)
+		}
+		if first == second {
+			t.Errorf("batch IDs are equal: %q", first)
+		}
+		if got := <-sent; got != first {
+			t.Errorf("OnSent batchID = %q, want %q", got, first)
+		}
+	})
+
+	t.Run("transport context", func(t *testing.T) {
+		var mu sync.Mutex
+		var fromContext, fromCallback string
+
+		sender, err := NewSender(&Config{
+			Transport: transportFunc(func(ctx context.Context, logs []LogEntry) error {
+				mu.Lock()
+				defer mu.Unlock()
+				fromContext = BatchIDFromContext(ctx)
+				return nil
+			}),
+			OnSent: func(batchID string, logs []LogEntry) {
+				mu.Lock()
+				defer mu.Unlock()
+				fromCallback = batchID
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		sender.AddLog(LogEntry{Level: "INFO", Message: "hello"})
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !batchIDPattern.MatchString(fromContext) {
+			t.Errorf("BatchIDFromContext() = %q, want a UUID", fromContext)
+		}
+		if fromCallback != fromContext {
+			t.Errorf("OnSent batchID = %q, want %q", fromCallback, fromContext)
+		}
+	})
+}
+
+type transportFunc func(ctx context.Context, logs []LogEntry) error
+
+func (f transportFunc) Send(ctx context.Context, logs []LogEntry) error {
+	return f(ctx, logs)
+}
+
+func TestSender_AdaptiveFlush(t *testing.T) {
+	waitForLogs := func(t *testing.T, transport *captureTransport, want int) {
+		t.Helper()
+
+		// Well before the 1s batch interval would send them
+		deadline := time.Now().Add(500 * time.Millisecond)
+		for len(transport.all()) < want && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := len(transport.all()); got < want {
+			t.Errorf("delivered %d logs before the tick, want %d", got, want)
+		}
+	}
+
+	t.Run("full batch by count", func(t *testing.T) {
+		transport := &captureTransport{}
+		sender, err := NewSender(&Config{Transport: transport})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		for i := 0; i < batchSize; i++ {
+			sender.AddLog(LogEntry{Level: "INFO", Message: "log"})
+		}
+
+		waitForLogs(t, transport, batchSize)
+	})
+
+	t.Run("full batch by bytes", func(t *testing.T) {
+		transport := &captureTransport{}
+		sender, err := NewSender(&Config{Transport: transport, MaxBatchBytes: 4096})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		message := strings.Repeat("x", 1024)
+		for i := 0; i < 4; i++ {
+			sender.AddLog(LogEntry{Level: "INFO", Message: message})
+		}
+
+		waitForLogs(t, transport, 4)
+		if got := sender.queuedBytes.Load(); got != 0 {
+			t.Errorf("queuedBytes = %d after sending everything, want 0", got)
+		}
+	})
+
+	t.Run("partial batch waits for the tick", func(t *testing.T) {
+		transport := &captureTransport{}
+		sender, err := NewSender(&Config{Transport: transport})
+		if err != nil {
+			t.Fatalf("NewSender() error = %v", err)
+		}
+		defer sender.Shutdown()
+
+		sender.AddLog(LogEntry{Level: "INFO", Message: "log"})
+		time.Sleep(100 * time.Millisecond)
+
+		if got := len(transport.all()); got != 0 {
+			t.Errorf("delivered %d logs before the tick, want 0", got)
+		}
+	})
+}
+
+func TestSender_ImmediateFlushLevel(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, ImmediateFlushLevel: ERROR})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "queued"})
+	sender.AddLog(LogEntry{Level: "ERROR", Message: "urgent"})
+	sender.AddLog(LogEntry{Level: "CRITICAL", Message: "fatal"})
+
+	// Well before the 1s batch interval
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for len(transport.all()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sent := transport.all()
+	if len(sent) != 2 {
+		t.Fatalf("delivered %d logs before the tick, want 2", len(sent))
+	}
+	for _, entry := range sent {
+		if entry.Level == "INFO" {
+			t.Errorf("INFO entry bypassed the queue: %+v", entry)
+		}
+	}
+
+	if got := sender.Stats().QueuedLogs; got != 1 {
+		t.Errorf("QueuedLogs = %d, want 1", got)
+	}
+	if got := sender.Stats().EnqueuedLogs; got != 3 {
+		t.Errorf("EnqueuedLogs = %d, want 3", got)
+	}
+}
+
+func TestNewSender_InvalidImmediateFlushLevel(t *testing.T) {
+	for _, level := range []LogLevel{"warn ", "error", "URGENT"} {
+		if _, err := NewSender(&Config{Transport: &captureTransport{}, ImmediateFlushLevel: level}); err == nil {
+			t.Errorf("NewSender() expected error for ImmediateFlushLevel %q", level)
+		}
+	}
+
+	if _, err := NewLogger(Config{Transport: &captureTransport{}, ImmediateFlushLevel: "warn "}); err == nil {
+		t.Error("NewLogger() expected error for an invalid ImmediateFlushLevel")
+	}
+}
+
+func TestSender_SendHooks(t *testing.T) {
+	signatures := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures <- r.Header.Get("X-Signature")
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var signedBody string
+	var statuses []int
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		BeforeSend: func(req *http.Request) {
+			body, _ := req.GetBody()
+			data, _ := io.ReadAll(body)
+
+			mu.Lock()
+			signedBody = string(data)
+			mu.Unlock()
+
+			req.Header.Set("X-Signature", "signed")
+		},
+		AfterSend: func(resp *http.Response, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				statuses = append(statuses, resp.StatusCode)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "hello", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if got := <-signatures; got != "signed" {
+		t.Errorf("X-Signature = %q, want signed", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(signedBody, `"message":"hello"`) {
+		t.Errorf("BeforeSend body = %q, want the batch", signedBody)
+	}
+	if len(statuses) != 1 || statuses[0] != http.StatusOK {
+		t.Errorf("AfterSend statuses = %v, want [200]", statuses)
+	}
+}
+
+func TestSender_DeliveryCallbacks(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		code := status
+		mu.Unlock()
+
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			w.Write([]byte("unavailable"))
+			return
+		}
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1, Rejected: 1, Errors: []RejectedLog{{Index: 1, Message: "bad"}}})
+	}))
+	defer server.Close()
+
+	var delivered []LogBullResponse
+	var deliveredLogs int
+	var failed []error
+	var failedMessages []string
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Silent:    true,
+		OnDelivered: func(batch LogBatch, response LogBullResponse) {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered = append(delivered, response)
+			deliveredLogs += len(batch.Logs)
+		},
+		OnDeliveryFailed: func(batch LogBatch, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, err)
+			for _, log := range batch.Logs {
+				failedMessages = append(failedMessages, log.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "ok", Timestamp: GenerateUniqueTimestamp()})
+	sender.AddLog(LogEntry{Level: "INFO", Message: "rejected", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "failed", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(delivered) != 1 || deliveredLogs != 2 {
+		t.Fatalf("OnDelivered calls = %v with %d logs, want 1 call with 2 logs", delivered, deliveredLogs)
+	}
+	if delivered[0].Accepted != 1 || delivered[0].Rejected != 1 {
+		t.Errorf("OnDelivered response = %+v, want 1 accepted and 1 rejected", delivered[0])
+	}
+	if len(failed) != 1 || !strings.Contains(failed[0].Error(), "503") {
+		t.Errorf("OnDeliveryFailed errors = %v, want one status 503 error", failed)
+	}
+	if len(failedMessages) != 1 || failedMessages[0] != "failed" {
+		t.Errorf("OnDeliveryFailed logs = %v, want [failed]", failedMessages)
+	}
+}
+
+func TestSender_TimestampFormat(t *testing.T) {
+	timestamps := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		timestamps <- batch.Logs[0].Timestamp
+		json.NewEncoder(w).Encode(LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	var sentTimestamp string
+	sender, err := NewSender(&Config{
+		ProjectID:       "12345678-1234-1234-1234-123456789012",
+		Host:            server.URL,
+		TimestampFormat: TimestampEpochMillis,
+		OnSent: func(batchID string, logs []LogEntry) {
+			sentTimestamp = logs[0].Timestamp
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	sender.AddLog(LogEntry{Level: "INFO", Message: "hello", Timestamp: FormatTimestamp(at)})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if got := <-timestamps; got != "1709296200123" {
+		t.Errorf("sent timestamp = %s, want 1709296200123", got)
+	}
+	if sentTimestamp != "2024-03-01T12:30:00.123456789Z" {
+		t.Errorf("OnSent timestamp = %s, want the original entry", sentTimestamp)
+	}
+
+	if _, err := NewSender(&Config{TimestampFormat: "unix"}); err == nil {
+		t.Error("NewSender() expected error for invalid TimestampFormat")
+	}
+}
+
+func TestSender_FlattenFields(t *testing.T) {
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{
+		Transport:       transport,
+		FlattenFields:   true,
+		FlattenMaxDepth: 2,
+		DisableMetadata: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{
+		Level:     "INFO",
+		Message:   "order placed",
+		Timestamp: GenerateUniqueTimestamp(),
+		Fields: map[string]any{
+			"order": map[string]any{
+				"id":       "o1",
+				"shipping": map[string]any{"city": "Berlin"},
+			},
+		},
+	})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	want := map[string]any{
+		"order.id":       "o1",
+		"order.shipping": `{"city":"Berlin"}`,
+	}
+	if !reflect.DeepEqual(entries[0].Fields, want) {
+		t.Errorf("Fields = %v, want %v", entries[0].Fields, want)
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errStreamClosed = errors.New("stream closed by server")
+
+// Pipe writes block until the request body has consumed the data, so the
+// buffer can be reused as soon as write returns.
+var streamBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// ndjsonStream keeps one long-lived chunked POST open against the streaming
+// endpoint and writes each entry as a JSON line into its body. The request is
+// reopened transparently when the server or the network closes it.
+type ndjsonStream struct {
+	sender *Sender
+	client *http.Client
+
+	mu     sync.Mutex
+	writer *io.PipeWriter
+	done   chan struct{}
+}
+
+func newNDJSONStream(sender *Sender) *ndjsonStream {
+	return &ndjsonStream{
+		sender: sender,
+		client: &http.Client{Transport: sender.client.Transport},
+	}
+}
+
+// write sends logs over the stream, reopening it once if it was closed. The
+// error is already reported when write returns it.
+func (st *ndjsonStream) write(logs []LogEntry) error {
+	buf := streamBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		streamBufferPool.Put(buf)
+	}()
+
+	encoder := json.NewEncoder(buf)
+	for _, log := range st.sender.wireLogs(logs) {
+		if err := encoder.Encode(log); err != nil {
+			st.sender.config.reportError(fmt.Errorf("failed to marshal log: %w", err), map[string]any{"operation": "stream"})
+			return err
+		}
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if st.writer == nil {
+			st.open()
+		}
+
+		if _, err = st.writer.Write(buf.Bytes()); err == nil {
+			return nil
+		}
+
+		st.closeLocked()
+	}
+
+	st.sender.config.reportError(fmt.Errorf("stream write failed: %w", err), map[string]any{"operation": "stream", "logs": len(logs)})
+	return err
+}
+
+func (st *ndjsonStream) open() {
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+
+	st.writer = writer
+	st.done = done
+
+	url := fmt.Sprintf("%s/api/v1/logs/receiving/%s/stream", st.sender.Host(), st.sender.config.ProjectID)
+
+	go func() {
+		defer close(done)
+		reader.CloseWithError(st.run(url, reader))
+	}()
+}
+
+func (st *ndjsonStream) run(url string, body io.Reader) error {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := st.sender.apiKey()
+	if err != nil {
+		st.sender.config.reportError(fmt.Errorf("stream request failed: %w", err), map[string]any{"operation": "stream"})
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "LogBull-Go-Client/1.0")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := st.client.Do(req)
+	if err != nil {
+		st.sender.config.reportError(fmt.Errorf("stream request failed: %w", err), map[string]any{"operation": "stream"})
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			st.sender.config.reportError(fmt.Errorf("failed to close response body: %w", err), map[string]any{"operation": "stream"})
+		}
+	}()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 && resp.StatusCode != 202 {
+		st.sender.config.reportErrorf(
+			map[string]any{"operation": "stream", "status": resp.StatusCode, "body": string(respBody)},
+			"stream closed with status %d: %s",
+			resp.StatusCode,
+			string(respBody),
+		)
+	}
+
+	return errStreamClosed
+}
+
+func (st *ndjsonStream) close() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.closeLocked()
+}
+
+func (st *ndjsonStream) closeLocked() {
+	if st.writer == nil {
+		return
+	}
+
+	if err := st.writer.Close(); err != nil {
+		st.sender.config.reportError(fmt.Errorf("failed to close stream: %w", err), map[string]any{"operation": "stream"})
+	}
+
+	select {
+	case <-st.done:
+	case <-time.After(httpTimeout):
+		st.sender.config.reportErrorf(map[string]any{"operation": "stream"}, "timed out waiting for stream to close")
+	}
+
+	st.writer = nil
+	st.done = nil
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSender_NDJSONStream(t *testing.T) {
+	var requests int
+	var contentType string
+	var mu sync.Mutex
+	received := make(chan LogEntry, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		contentType = r.Header.Get("Content-Type")
+		mu.Unlock()
+
+		if !strings.HasSuffix(r.URL.Path, "/stream") {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var entry LogEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Errorf("Invalid NDJSON line %q: %v", scanner.Text(), err)
+				continue
+			}
+			received <- entry
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Protocol:  ProtocolNDJSON,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		sender.AddLog(LogEntry{Level: "INFO", Message: "streamed", Timestamp: GenerateUniqueTimestamp()})
+		sender.Flush()
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case entry := <-received:
+			if entry.Message != "streamed" {
+				t.Errorf("Received message %q", entry.Message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for streamed entry %d", i)
+		}
+	}
+
+	sender.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if requests != 1 {
+		t.Errorf("Expected all entries over 1 streaming request, got %d requests", requests)
+	}
+	if contentType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", contentType)
+	}
+}
+
+func TestSender_NDJSONStreamReconnects(t *testing.T) {
+	var requests int
+	var mu sync.Mutex
+	received := make(chan LogEntry, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		// Accept a single line, then end the stream
+		scanner := bufio.NewScanner(r.Body)
+		if scanner.Scan() {
+			var entry LogEntry
+			json.Unmarshal(scanner.Bytes(), &entry)
+			received <- entry
+		}
+
+		w.Header().Set("Connection", "close")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Protocol:  ProtocolNDJSON,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	for _, message := range []string{"first", "second"} {
+		sender.AddLog(LogEntry{Level: "INFO", Message: message, Timestamp: GenerateUniqueTimestamp()})
+		sender.Flush()
+
+		select {
+		case entry := <-received:
+			if entry.Message != message {
+				t.Errorf("Received message %q, want %q", entry.Message, message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q", message)
+		}
+
+		// Give the client time to observe the closed stream
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if requests < 2 {
+		t.Errorf("Expected the stream to be reopened, got %d requests", requests)
+	}
+}
+
+func TestSender_NDJSONStreamFailureIsNotSent(t *testing.T) {
+	captureDiagnostics(t)
+
+	// Nothing listens on the address, so both write attempts fail
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var sent int
+	var mu sync.Mutex
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		Protocol:  ProtocolNDJSON,
+		OnSent: func(batchID string, logs []LogEntry) {
+			mu.Lock()
+			sent++
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "lost", Timestamp: GenerateUniqueTimestamp()})
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	stats := sender.Stats()
+	if stats.SentBatches != 0 || stats.SendErrors != 1 {
+		t.Errorf("SentBatches = %d, SendErrors = %d, want 0 and 1", stats.SentBatches, stats.SendErrors)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sent != 0 {
+		t.Errorf("OnSent called %d times for a failed write", sent)
+	}
+}
+
+func TestSender_NDJSONStreamSetHost(t *testing.T) {
+	newServer := func(received chan<- string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var entry LogEntry
+				json.Unmarshal(scanner.Bytes(), &entry)
+				received <- entry.Message
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	first, second := make(chan string, 10), make(chan string, 10)
+	firstServer, secondServer := newServer(first), newServer(second)
+	defer firstServer.Close()
+	defer secondServer.Close()
+
+	sender, err := NewSender(&Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      firstServer.URL,
+		Protocol:  ProtocolNDJSON,
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	defer sender.Shutdown()
+
+	expect := func(received <-chan string, message string) {
+		t.Helper()
+
+		sender.AddLog(LogEntry{Level: "INFO", Message: message, Timestamp: GenerateUniqueTimestamp()})
+		sender.Flush()
+
+		select {
+		case got := <-received:
+			if got != message {
+				t.Errorf("Received message %q, want %q", got, message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q", message)
+		}
+	}
+
+	expect(first, "before")
+	if err := sender.SetHost(secondServer.URL); err != nil {
+		t.Fatalf("SetHost() error = %v", err)
+	}
+	expect(second, "after")
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	OperationField  = "operation"
+	DurationMsField = "duration_ms"
+	SuccessField    = "success"
+)
+
+// Timer measures one operation started by TimedOperation.
+type Timer struct {
+	logger  *LogBullLogger
+	name    string
+	start   time.Time
+	stopped atomic.Bool
+}
+
+// TimedOperation starts timing the operation name. Stop logs how long it
+// took:
+//
+//	timer := logger.TimedOperation("charge_card")
+//	err := charge(ctx, card)
+//	timer.Stop(err)
+func (l *LogBullLogger) TimedOperation(name string) *Timer {
+	return &Timer{logger: l, name: name, start: l.config.clock().Now()}
+}
+
+// Stop logs the operation with its name under OperationField, the elapsed
+// milliseconds under DurationMsField and whether err is nil under
+// SuccessField: at INFO when it succeeded, at ERROR with the error fields of
+// WithError otherwise. Only the first call logs; every call returns the
+// elapsed time.
+func (t *Timer) Stop(err error) time.Duration {
+	elapsed := t.logger.config.clock().Now().Sub(t.start)
+	if !t.stopped.CompareAndSwap(false, true) {
+		return elapsed
+	}
+
+	fields := map[string]any{
+		OperationField:  t.name,
+		DurationMsField: float64(elapsed) / float64(time.Millisecond),
+		SuccessField:    err == nil,
+	}
+
+	if err != nil {
+		fields[ErrorField] = err.Error()
+		fields[ErrorTypeField] = errorType(err)
+		t.logger.log(ERROR, t.name+" failed", fields)
+		return elapsed
+	}
+
+	t.logger.log(INFO, t.name+" completed", fields)
+	return elapsed
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogBullLogger_TimedOperation(t *testing.T) {
+	transport := &captureTransport{}
+	clock := &manualClock{now: time.Now(), ticks: make(chan time.Time)}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, Clock: clock})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	timer := logger.TimedOperation("charge_card")
+	clock.advance(1500 * time.Microsecond)
+	if elapsed := timer.Stop(nil); elapsed != 1500*time.Microsecond {
+		t.Errorf("Stop() = %v, want 1.5ms", elapsed)
+	}
+	// Only the first Stop logs
+	timer.Stop(errors.New("late"))
+
+	timer = logger.TimedOperation("refund")
+	clock.advance(20 * time.Millisecond)
+	timer.Stop(errors.New("card expired"))
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 2 {
+		t.Fatalf("Got %d entries, want 2", len(entries))
+	}
+
+	completed, failed := entries[0], entries[1]
+	if completed.Level != string(INFO) || completed.Message != "charge_card completed" ||
+		completed.Fields[OperationField] != "charge_card" || completed.Fields[DurationMsField] != 1.5 ||
+		completed.Fields[SuccessField] != true {
+		t.Errorf("Completed entry = %+v", completed)
+	}
+	if failed.Level != string(ERROR) || failed.Message != "refund failed" ||
+		failed.Fields[DurationMsField] != 20.0 || failed.Fields[SuccessField] != false ||
+		failed.Fields[ErrorField] != "card expired" {
+		t.Errorf("Failed entry = %+v", failed)
+	}
+}
+
+func TestTimer_IncludeCaller(t *testing.T) {
+	transport := &captureTransport{}
+	logger, err := NewLogger(Config{Transport: transport, ConsoleFormat: ConsoleDisabled, IncludeCaller: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.TimedOperation("charge_card").Stop(nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := transport.all()
+	if len(entries) != 1 {
+		t.Fatalf("Got %d entries, want 1", len(entries))
+	}
+	// The caller is the code stopping the timer, not Timer.Stop
+	if function, _ := entries[0].Fields[CallerFunctionField].(string); !strings.HasSuffix(function, ".TestTimer_IncludeCaller") {
+		t.Errorf("%s = %v, want the test function", CallerFunctionField, entries[0].Fields[CallerFunctionField])
+	}
+}
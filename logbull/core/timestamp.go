@@ -1,32 +1,108 @@
 package core
 
 import (
+	"strconv"
 	"sync"
 	"time"
 )
 
-var (
-	timestampMu     sync.Mutex
-	lastTimestampNs int64
+const (
+	timestampLayout      = "2006-01-02T15:04:05.000000000Z"
+	zonedTimestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
 )
 
-func GenerateUniqueTimestamp() string {
-	timestampMu.Lock()
-	defer timestampMu.Unlock()
+// uniqueTimestamps hands out strictly increasing timestamps. Loggers with
+// their own Clock get their own instance, so fake times are not pushed past
+// the real time of other loggers.
+type uniqueTimestamps struct {
+	mu     sync.Mutex
+	lastNs int64
+}
+
+var processTimestamps uniqueTimestamps
+
+func newUniqueTimestamps(config *Config) *uniqueTimestamps {
+	if config.Clock == nil {
+		return &processTimestamps
+	}
+	return &uniqueTimestamps{}
+}
+
+func (u *uniqueTimestamps) next(now time.Time) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	currentNs := time.Now().UnixNano()
-	if currentNs <= lastTimestampNs {
-		currentNs = lastTimestampNs + 1
+	currentNs := now.UnixNano()
+	if currentNs <= u.lastNs {
+		currentNs = u.lastNs + 1
 	}
-	lastTimestampNs = currentNs
+	u.lastNs = currentNs
 
 	return formatTimestamp(currentNs)
 }
 
+func GenerateUniqueTimestamp() string {
+	return processTimestamps.next(time.Now())
+}
+
+// FormatTimestamp formats an event time recorded by another logging library.
+// A zero time falls back to GenerateUniqueTimestamp.
+func FormatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return GenerateUniqueTimestamp()
+	}
+	return t.UTC().Format(timestampLayout)
+}
+
 func formatTimestamp(timestampNs int64) string {
 	seconds := timestampNs / 1_000_000_000
 	nanos := timestampNs % 1_000_000_000
 
 	t := time.Unix(seconds, nanos).UTC()
-	return t.Format("2006-01-02T15:04:05.000000000Z")
+	return t.Format(timestampLayout)
+}
+
+func validTimestampFormat(format TimestampFormat) bool {
+	switch format {
+	case "", TimestampRFC3339Nano, TimestampRFC3339, TimestampEpochMillis:
+		return true
+	}
+	return false
+}
+
+// wireTimestamp converts an entry timestamp, always kept in the UTC
+// nanosecond form internally, to the format sent to the server. Timestamps
+// that do not parse are sent unchanged.
+func wireTimestamp(timestamp string, format TimestampFormat, location *time.Location) string {
+	t, err := time.Parse(timestampLayout, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	if location != nil {
+		t = t.In(location)
+	}
+
+	switch format {
+	case TimestampRFC3339:
+		return t.Format(time.RFC3339)
+	case TimestampEpochMillis:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(zonedTimestampLayout)
+	}
+}
+
+// wireLogs returns logs with timestamps in the configured server format. The
+// batch is copied rather than modified, since callbacks receive it as queued.
+func (s *Sender) wireLogs(logs []LogEntry) []LogEntry {
+	if s.config.TimestampFormat == "" && s.config.TimestampLocation == nil {
+		return logs
+	}
+
+	converted := make([]LogEntry, len(logs))
+	for i, log := range logs {
+		log.Timestamp = wireTimestamp(log.Timestamp, s.config.TimestampFormat, s.config.TimestampLocation)
+		converted[i] = log
+	}
+	return converted
 }
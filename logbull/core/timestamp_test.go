@@ -113,3 +113,32 @@ func BenchmarkGenerateUniqueTimestampParallel(b *testing.B) {
 		}
 	})
 }
+
+func TestWireTimestamp(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	timestamp := "2024-03-01T12:30:00.123456789Z"
+
+	tests := []struct {
+		name     string
+		format   TimestampFormat
+		location *time.Location
+		want     string
+	}{
+		{"default", "", nil, "2024-03-01T12:30:00.123456789Z"},
+		{"rfc3339nano in zone", TimestampRFC3339Nano, berlin, "2024-03-01T13:30:00.123456789+01:00"},
+		{"rfc3339", TimestampRFC3339, nil, "2024-03-01T12:30:00Z"},
+		{"epoch millis", TimestampEpochMillis, berlin, "1709296200123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wireTimestamp(timestamp, tt.format, tt.location); got != tt.want {
+				t.Errorf("wireTimestamp() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := wireTimestamp("not a timestamp", TimestampRFC3339, nil); got != "not a timestamp" {
+		t.Errorf("wireTimestamp() = %s, want the input unchanged", got)
+	}
+}
@@ -0,0 +1,26 @@
+package core
+
+import "context"
+
+// Transport delivers batches in place of the built-in LogBull HTTP client,
+// e.g. to capture logs in tests. When Config.Transport is set, ProjectID and
+// Host are not required.
+//
+// The logs slice is reused after Send returns, so implementations that keep
+// entries must copy them.
+type Transport interface {
+	Send(ctx context.Context, logs []LogEntry) error
+}
+
+type batchIDContextKey struct{}
+
+func contextWithBatchID(ctx context.Context, batchID string) context.Context {
+	return context.WithValue(ctx, batchIDContextKey{}, batchID)
+}
+
+// BatchIDFromContext returns the ID of the batch passed to Transport.Send, so
+// custom transports can forward it for deduplication.
+func BatchIDFromContext(ctx context.Context) string {
+	batchID, _ := ctx.Value(batchIDContextKey{}).(string)
+	return batchID
+}
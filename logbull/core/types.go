@@ -1,5 +1,18 @@
 package core
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
 type LogLevel string
 
 const (
@@ -10,15 +23,111 @@ const (
 	CRITICAL LogLevel = "CRITICAL"
 )
 
+const (
+	RetentionField       = "retention_seconds"
+	ErrorField           = "error"
+	ErrorTypeField       = "error_type"
+	MessageTemplateField = "message_template"
+	LoggerNameField      = "logger"
+	// AuditTypeField is set to AuditType on entries logged with Audit.
+	AuditTypeField = "type"
+	AuditType      = "audit"
+
+	CallerFunctionField = callsite.FunctionField
+	CallerFileField     = callsite.FileField
+	CallerLineField     = callsite.LineField
+)
+
+type ConsoleFormat string
+
+const (
+	ConsoleText     ConsoleFormat = "text"
+	ConsoleJSON     ConsoleFormat = "json"
+	ConsoleDisabled ConsoleFormat = "disabled"
+)
+
+type Protocol string
+
+const (
+	ProtocolBatch  Protocol = "batch"
+	ProtocolNDJSON Protocol = "ndjson"
+	// ProtocolOTLP posts batches in OTLP/HTTP JSON format to {Host}/v1/logs,
+	// e.g. to an OpenTelemetry Collector.
+	ProtocolOTLP Protocol = "otlp"
+)
+
+// BatchFormat selects how ProtocolBatch batches are laid out.
+type BatchFormat string
+
+const (
+	// BatchFormatRows sends a LogBatch, one object per entry.
+	BatchFormatRows BatchFormat = "rows"
+	// BatchFormatColumnar sends a ColumnarBatch, one array per entry
+	// attribute and field key, so keys shared by most entries are sent once
+	// per batch.
+	BatchFormatColumnar BatchFormat = "columnar"
+)
+
+// TimestampFormat selects how entry timestamps are sent to the server.
+type TimestampFormat string
+
+const (
+	// TimestampRFC3339Nano sends RFC 3339 with nine fractional digits, e.g.
+	// "2024-03-01T12:30:00.123456789Z" (default).
+	TimestampRFC3339Nano TimestampFormat = "rfc3339nano"
+	// TimestampRFC3339 sends RFC 3339 with second precision.
+	TimestampRFC3339 TimestampFormat = "rfc3339"
+	// TimestampEpochMillis sends milliseconds since the Unix epoch.
+	TimestampEpochMillis TimestampFormat = "epoch_millis"
+)
+
+// TimestampStrategy decides how entries logged in the same nanosecond are
+// kept in order.
+type TimestampStrategy string
+
+const (
+	// TimestampUnique moves the timestamp of a LogBullLogger entry 1ns past
+	// the previous one when they collide (default). Times can drift forward
+	// under bursts, and uniqueness only holds within one process.
+	TimestampUnique TimestampStrategy = "unique"
+	// TimestampSequence keeps the true time and attaches the "sequence" and
+	// "source_id" fields, as EnableSequence does, for ordering server-side.
+	TimestampSequence TimestampStrategy = "sequence"
+)
+
+// OverflowPolicy decides what happens to a log when the send queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropNewest drops the log being added (default).
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest drops the oldest queued log to make room.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowBlock waits up to Config.BlockTimeout for room in the queue.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// FieldKeyPolicy normalizes field keys; see Config.FieldKeys.
+type FieldKeyPolicy = formatting.KeyPolicy
+
 type LogEntry struct {
 	Level     string         `json:"level"`
 	Message   string         `json:"message"`
 	Timestamp string         `json:"timestamp"`
 	Fields    map[string]any `json:"fields"`
+
+	// wal is the write-ahead log segment holding the entry, if any, and
+	// walIndex the entry's line in it
+	wal      *walSegment
+	walIndex int
+	// queuedAt is when the entry was queued, in Unix nanoseconds, when
+	// Config.MaxLogAge is set
+	queuedAt int64
 }
 
 type LogBatch struct {
-	Logs []LogEntry `json:"logs"`
+	Logs   []LogEntry     `json:"logs"`
+	Fields map[string]any `json:"fields,omitempty"`
 }
 
 type LogBullResponse struct {
@@ -33,11 +142,291 @@ type RejectedLog struct {
 	Message string `json:"message"`
 }
 
+type RejectedLogEntry struct {
+	Entry  LogEntry `json:"entry"`
+	Reason string   `json:"reason"`
+}
+
 type Config struct {
 	ProjectID string
 	Host      string
 	APIKey    string
 	LogLevel  LogLevel
+
+	// Hosts lists LogBull servers to fail over between, primary first, for
+	// batch and OTLP delivery; Host defaults to the first. A batch failing
+	// with a network error or 5xx is re-sent to the next host with the same
+	// batch ID, and after 3 consecutive failures the next host is used for
+	// every batch until the primary answers again (checked every 30s).
+	Hosts []string
+
+	// HedgeAfter sends a second copy of a batch request still unanswered
+	// after this long, with the same batch ID, and keeps the first answer
+	// that is not a network error or 5xx, trading extra requests for lower
+	// tail latency on flaky networks (default: 0, disabled). BeforeSend runs
+	// once, AfterSend only for the kept answer.
+	HedgeAfter time.Duration
+
+	// APIKeyProvider, when set, supplies the API key instead of APIKey, so
+	// short-lived tokens can be rotated without recreating the logger. Its
+	// result is cached for APIKeyCacheTTL (default 5m) and refetched early
+	// when the server rejects the key.
+	APIKeyProvider func() (string, error)
+	APIKeyCacheTTL time.Duration
+
+	// ProxyURL routes requests through an HTTP, HTTPS or SOCKS5 proxy, e.g.
+	// "http://proxy.internal:3128". When empty, HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY are honored.
+	ProxyURL string
+
+	// MaxIdleConnsPerHost is how many idle connections to the server are
+	// kept for reuse (default 10, one per send worker).
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this long (default 90s).
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period of new connections (default
+	// 30s). A negative value disables keep-alive probes.
+	KeepAlive time.Duration
+	// DisableHTTP2 keeps requests on HTTP/1.1. HTTP/2 is attempted by
+	// default with HTTPS servers.
+	DisableHTTP2 bool
+	// DialContext replaces the dialer of the default transport, e.g. to
+	// resolve the host through a service mesh. KeepAlive does not apply then.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Protocol selects the wire format: discrete JSON batch POSTs (default),
+	// newline-delimited JSON streamed over one long-lived request, or OTLP.
+	Protocol Protocol
+	// Encoder serializes ProtocolBatch batches: JSONEncoder (default),
+	// MsgPackEncoder or CBOREncoder.
+	Encoder Encoder
+	// BatchFormat selects the batch layout (default BatchFormatRows).
+	// BatchFormatColumnar is only used once NegotiateCapabilities found a
+	// server announcing it, and only with the built-in encoders.
+	BatchFormat BatchFormat
+
+	// NegotiateCapabilities asks a LogBull server for its version and
+	// capabilities when the sender starts or the host changes, then adapts:
+	// an Encoder the server does not accept falls back to JSON, batches are
+	// kept under the server's size limit, and bodies are gzipped when the
+	// server supports it. Servers without the version endpoint are treated as
+	// accepting plain JSON batches only.
+	NegotiateCapabilities bool
+
+	// TimestampFormat and TimestampLocation control the timestamps sent to
+	// the server (default TimestampRFC3339Nano in UTC). Console output and
+	// custom Transports keep the UTC nanosecond form.
+	TimestampFormat   TimestampFormat
+	TimestampLocation *time.Location
+
+	// ConsoleFormat controls how LogBullLogger echoes entries locally
+	// (default ConsoleText).
+	ConsoleFormat ConsoleFormat
+	// ConsoleColor colorizes levels in ConsoleText output.
+	ConsoleColor bool
+	// ConsoleTimeFormat is a time layout for ConsoleText timestamps.
+	ConsoleTimeFormat string
+	// ConsoleLoggerName prefixes ConsoleText lines with the name set by
+	// LogBullLogger.Named.
+	ConsoleLoggerName bool
+	// ConsoleWriter receives console output for all levels. By default ERROR
+	// and CRITICAL go to stderr and everything else to stdout.
+	ConsoleWriter io.Writer
+
+	// ServiceName, ServiceVersion and Environment are attached to every entry
+	// as "service", "service_version" and "environment". ServiceName defaults
+	// to the executable name and ServiceVersion to the main module version.
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// DisableMetadata turns off the "host", "pid", "service", "service_version"
+	// and "environment" fields.
+	DisableMetadata bool
+	// DefaultFields are attached to every entry sent by the logger or handler,
+	// e.g. region or build SHA. They override the metadata fields above, and
+	// fields of the entry itself override them.
+	DefaultFields map[string]any
+
+	// FlattenFields replaces nested maps and structs in fields with dotted
+	// keys such as "user.address.city", so they become searchable fields.
+	// Values nested deeper than FlattenMaxDepth (default 5) are sent as JSON
+	// strings, and fields beyond FlattenMaxKeys (default 100) are dropped.
+	FlattenFields   bool
+	FlattenMaxDepth int
+	FlattenMaxKeys  int
+
+	// FieldKeys normalizes the top-level keys of logged fields, e.g. to
+	// snake_case, so entries from zap, logrus and slog share field names.
+	// Message templates and Schema see the keys as logged.
+	FieldKeys FieldKeyPolicy
+
+	// IncludeCaller adds the function, file and line that issued each log as
+	// "caller.function", "caller.file" and "caller.line".
+	IncludeCaller bool
+
+	// DisableMessageTemplates turns off {key} placeholder substitution in
+	// LogBullLogger messages. By default placeholders are replaced by field
+	// values and the original message is sent as "message_template".
+	DisableMessageTemplates bool
+
+	// EnableSequence attaches a per-sender "sequence" number and a "source_id"
+	// field to every entry, so streams merged from several senders in one
+	// process can be totally ordered server-side.
+	EnableSequence bool
+	// TimestampStrategy selects how entries with equal timestamps are
+	// ordered (default TimestampUnique).
+	TimestampStrategy TimestampStrategy
+	// SourceID identifies the sender in the "source_id" field. A random ID is
+	// generated when empty.
+	SourceID string
+
+	// StrictOrdering delivers one batch at a time, in the order batches leave
+	// the queue, instead of up to 10 concurrently, so a slow or retried batch
+	// is never overtaken. Entries still leave the queue by priority; combine
+	// it with EnableSequence to restore the logging order server-side.
+	StrictOrdering bool
+
+	// CompactBatchFields hoists fields shared by every entry of a batch into
+	// batch-level "fields". Only enable it for servers that support batch fields.
+	CompactBatchFields bool
+
+	// MaxBatchBytes caps the size of a marshalled batch request body. Larger
+	// batches are split; a single entry above the limit is dropped. Zero
+	// disables the limit.
+	MaxBatchBytes int
+
+	// MaxFieldValueLength cuts strings in fields, including nested ones,
+	// longer than this many bytes; cut values end with "...[truncated]".
+	// Zero disables the limit.
+	MaxFieldValueLength int
+	// MaxEntryBytes caps the encoded size of an entry. The largest field
+	// values of a bigger entry are replaced with "...[truncated]", then the
+	// message is cut, and TruncatedField is set. Zero disables the limit.
+	MaxEntryBytes int
+
+	// Schema rejects entries missing required fields, at disallowed levels
+	// or with mistyped fields before they are queued. The Try methods return
+	// the violation; the others report it to ErrorHandler.
+	Schema *Schema
+
+	// RetentionByLevel sets a default retention hint per level, sent in the
+	// "retention_seconds" field unless the entry already carries one.
+	RetentionByLevel map[LogLevel]time.Duration
+
+	// OverflowPolicy applies when the send queue is full (default
+	// OverflowDropNewest).
+	OverflowPolicy OverflowPolicy
+	// BlockTimeout bounds how long OverflowBlock waits. Zero waits until
+	// there is room or the sender is shut down.
+	BlockTimeout time.Duration
+	// EnableFingerprint adds FingerprintField to ERROR and CRITICAL entries,
+	// a hash of the normalized message, the error type and the calling
+	// function, so LogBull can group recurring errors. FingerprintFunc
+	// replaces DefaultFingerprint and enables it too; an empty result skips
+	// the field.
+	EnableFingerprint bool
+	FingerprintFunc   func(entry LogEntry) string
+
+	// DedupWindow collapses entries with the same level, message and fields
+	// logged within the window: the first is sent, and the duplicates are
+	// sent as one entry with RepeatCountField when the window closes. Zero
+	// disables deduplication.
+	DedupWindow time.Duration
+	// RateLimitByLevel caps the entries sent per second for a level, e.g.
+	// {DEBUG: 100}, allowing bursts of one second's worth; other levels are
+	// not limited. Suppressed entries are counted and reported every second
+	// in one entry per level carrying SuppressedCountField.
+	RateLimitByLevel map[LogLevel]int
+	// MaxLogAge drops entries that waited longer than it in the queue, or,
+	// for entries replayed from WALDir, since their timestamp, so a long
+	// outage does not end in a flood of stale logs. They are counted in
+	// Stats.ExpiredLogs. Audit entries never expire. Zero keeps every entry.
+	MaxLogAge time.Duration
+	// ErrorBudget calls ErrorBudget.OnExceeded, or logs a CRITICAL entry,
+	// when too many logs are dropped or rejected over a window.
+	ErrorBudget *ErrorBudget
+
+	// ShutdownTimeout bounds how long Shutdown keeps sending queued logs
+	// (default 10s). Logs still queued after it are dropped.
+	ShutdownTimeout time.Duration
+	// ShutdownOnGC shuts a LogBullLogger's senders down, delivering their
+	// queued logs, once the logger, every logger derived from it and every
+	// handler created from it with a FromLogger constructor have been garbage
+	// collected, so a forgotten Shutdown does not leak its goroutines. Code
+	// that keeps only the Sender returned by Sender() must keep the logger
+	// reachable too. Handlers created from a Config are not covered.
+	ShutdownOnGC bool
+	// OnDrop is called with every log dropped because the queue was full. It
+	// runs on the logging goroutine and must not block.
+	OnDrop func(LogEntry)
+
+	// FallbackWriter receives logs dropped because the queue was full, and
+	// logs that failed to send once the server has been unreachable for
+	// FallbackAfter (default 1m). See NewSystemFallback.
+	FallbackWriter FallbackWriter
+	FallbackAfter  time.Duration
+
+	// WALDir keeps a write-ahead log in this directory: every accepted entry
+	// is appended and synced to disk before it is queued, and removed once
+	// the server received it. Entries left by a crash, a failed delivery or a
+	// timed-out shutdown are sent by the next sender opening the directory,
+	// so each sender needs a directory of its own. Delivery is at-least-once:
+	// entries delivered just before a crash may be sent again. Empty
+	// disables it.
+	WALDir string
+
+	// AuditProjectID sends entries logged with Audit to a separate LogBull
+	// project, with the same host and credentials. Empty sends them with the
+	// other logs.
+	AuditProjectID string
+
+	// ImmediateFlushLevel sends entries at or above this level in a batch of
+	// their own right away instead of queueing them, so errors logged just
+	// before a crash are not lost. Empty disables it.
+	ImmediateFlushLevel LogLevel
+
+	// Clock replaces the system clock, e.g. with logbulltest.NewClock in
+	// tests.
+	Clock Clock
+
+	// Transport replaces HTTP delivery to the LogBull server.
+	Transport Transport
+
+	// BeforeSend is called with every batch request before it is sent, e.g.
+	// to add signature or tracing headers. The body can be re-read through
+	// req.GetBody.
+	BeforeSend func(req *http.Request)
+	// AfterSend is called with the response or error of every batch
+	// request. It must not read or close the response body.
+	AfterSend func(resp *http.Response, err error)
+
+	// OnRejected is called with the entries the server rejected, so they can be
+	// inspected, fixed or re-submitted. It runs on a sender worker goroutine.
+	OnRejected func([]RejectedLogEntry)
+	// RejectedLogsFile, when set, receives every rejected entry as a JSON line.
+	RejectedLogsFile string
+	// OnSent is called after a batch is delivered, with the ID sent in the
+	// X-Batch-ID header. It runs on a sender worker goroutine, and the logs
+	// slice is reused after it returns.
+	OnSent func(batchID string, logs []LogEntry)
+	// OnDelivered and OnDeliveryFailed report the outcome of every batch,
+	// e.g. to persist failed batches for at-least-once delivery. Batches held
+	// back while credentials are rejected fail with ErrUnauthorized. They may
+	// run on sender goroutines, and batch.Logs is reused after they return.
+	OnDelivered      func(batch LogBatch, response LogBullResponse)
+	OnDeliveryFailed func(batch LogBatch, err error)
+
+	// ErrorHandler receives the client's own errors, such as failed requests
+	// or dropped logs, instead of stderr. The context holds details like
+	// "operation" and "status". It may run on sender goroutines.
+	ErrorHandler func(err error, context map[string]any)
+	// Silent suppresses all diagnostics printed to stdout and stderr. Errors
+	// still reach ErrorHandler when one is set.
+	Silent bool
+
+	// lifetime keeps the logger that returned this Config from being shut
+	// down by ShutdownOnGC
+	lifetime *loggerLifetime
 }
 
 var levelPriority = map[LogLevel]int{
@@ -55,3 +444,35 @@ func (l LogLevel) Priority() int {
 func (l LogLevel) String() string {
 	return string(l)
 }
+
+var levelAliases = map[string]LogLevel{
+	"TRACE": DEBUG,
+	"WARN":  WARNING,
+	"ERR":   ERROR,
+	"FATAL": CRITICAL,
+}
+
+// ParseLevel parses a level name case-insensitively. Besides the LogBull
+// names it accepts the aliases TRACE, WARN, ERR and FATAL.
+func ParseLevel(name string) (LogLevel, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+
+	if level := LogLevel(upper); levelPriority[level] != 0 {
+		return level, nil
+	}
+	if level, ok := levelAliases[upper]; ok {
+		return level, nil
+	}
+
+	return "", fmt.Errorf("invalid log level '%s'", name)
+}
+
+func retentionSeconds(retention time.Duration) int64 {
+	return int64(retention / time.Second)
+}
+
+// sequenced reports whether entries carry the "sequence" and "source_id"
+// fields.
+func (c *Config) sequenced() bool {
+	return c.EnableSequence || c.TimestampStrategy == TimestampSequence
+}
@@ -58,3 +58,38 @@ func TestLogLevel_Ordering(t *testing.T) {
 		t.Error("ERROR should have lower priority than CRITICAL")
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want LogLevel
+	}{
+		{"debug", DEBUG},
+		{"TRACE", DEBUG},
+		{"Info", INFO},
+		{"warning", WARNING},
+		{"warn", WARNING},
+		{"ERROR", ERROR},
+		{"err", ERROR},
+		{" critical ", CRITICAL},
+		{"fatal", CRITICAL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if err != nil {
+				t.Fatalf("ParseLevel(%q) error = %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	for _, name := range []string{"", "verbose", "warnings"} {
+		if _, err := ParseLevel(name); err == nil {
+			t.Errorf("ParseLevel(%q) expected error", name)
+		}
+	}
+}
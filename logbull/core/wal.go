@@ -0,0 +1,377 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// walSegmentBytes is the size at which the write-ahead log starts a new
+	// segment file.
+	walSegmentBytes = 8 << 20
+
+	walExt = ".wal"
+	// ackExt is the suffix of the file next to a segment listing the lines
+	// already delivered, so a replay skips them.
+	ackExt = ".ack"
+)
+
+// writeAheadLog appends every accepted entry to a segment file in
+// Config.WALDir and syncs it before the entry is queued. A segment is removed
+// once it has been rotated out and all its entries were delivered, so the
+// segments found when opening the directory hold the entries a previous
+// sender did not deliver: those are replayed. Until then, the lines of
+// delivered entries are appended to the segment's ack file, without syncing,
+// so a replay skips them unless the crash lost the ack too.
+type writeAheadLog struct {
+	config *Config
+	dir    string
+
+	mu     sync.Mutex
+	file   *os.File
+	active *walSegment
+	size   int64
+	next   uint64
+}
+
+// walSegment counts the entries of one segment file still waiting for
+// delivery. Entries whose delivery failed are never released, keeping their
+// segment for the next replay.
+type walSegment struct {
+	wal  *writeAheadLog
+	path string
+	// lines is the number of lines appended, the index of the next entry
+	lines   int
+	pending atomic.Int64
+	sealed  atomic.Bool
+	removed atomic.Bool
+
+	ackMu   sync.Mutex
+	ackFile *os.File
+}
+
+// openWAL creates dir if needed and returns the write-ahead log along with
+// the segments left over by a previous sender, oldest first.
+func openWAL(config *Config, dir string) (*writeAheadLog, []string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &writeAheadLog{config: config, dir: dir, next: 1}
+
+	var segments []string
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ackExt) {
+			// Left behind by a crash after its segment was removed
+			segment := strings.TrimSuffix(name, ackExt) + walExt
+			if _, err := os.Stat(filepath.Join(dir, segment)); os.IsNotExist(err) {
+				os.Remove(filepath.Join(dir, name))
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, walExt) {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSuffix(name, walExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		w.next = max(w.next, n+1)
+		segments = append(segments, filepath.Join(dir, name))
+	}
+
+	// Names are zero-padded, so they sort in creation order
+	sort.Strings(segments)
+	return w, segments, nil
+}
+
+// append writes entry to the active segment and syncs it, returning the
+// segment to release once entry is delivered and the entry's line in it.
+func (w *writeAheadLog) append(entry LogEntry) (*walSegment, int, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, 0, err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil || w.size+int64(len(line)) > walSegmentBytes && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		// A partial line is skipped on replay
+		return nil, 0, err
+	}
+
+	index := w.active.lines
+	w.active.lines++
+	w.active.pending.Add(1)
+	return w.active, index, nil
+}
+
+// rotate seals the active segment and opens the next one. It is called with
+// w.mu held.
+func (w *writeAheadLog) rotate() error {
+	w.closeActive()
+
+	path := filepath.Join(w.dir, fmt.Sprintf("%020d%s", w.next, walExt))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.next++
+
+	w.file = file
+	w.active = &walSegment{wal: w, path: path}
+	w.size = 0
+	return nil
+}
+
+func (w *writeAheadLog) closeActive() {
+	if w.file == nil {
+		return
+	}
+	if err := w.file.Close(); err != nil {
+		w.config.reportError(fmt.Errorf("failed to close WAL segment: %w", err), map[string]any{"operation": "wal"})
+	}
+	w.active.seal()
+	w.file = nil
+	w.active = nil
+}
+
+// close seals the active segment, removing it when all its entries were
+// delivered.
+func (w *writeAheadLog) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closeActive()
+}
+
+// readWALSegment returns the entries of a segment file that its ack file
+// does not list as delivered, with their walIndex set. Lines that cannot be
+// decoded, such as one cut short by a crash, are skipped and counted.
+func readWALSegment(path string) ([]LogEntry, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	delivered, err := readWALAcks(ackPath(path))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []LogEntry
+	skipped := 0
+	index := 0
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		index++
+		if delivered[index-1] {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			skipped++
+			continue
+		}
+		entry.walIndex = index - 1
+		entries = append(entries, entry)
+	}
+	return entries, skipped, nil
+}
+
+// readWALAcks returns the lines listed in an ack file. A missing file lists
+// none, and a line cut short by a crash is ignored.
+func readWALAcks(path string) (map[int]bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delivered := make(map[int]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if index, err := strconv.Atoi(line); err == nil {
+			delivered[index] = true
+		}
+	}
+	return delivered, nil
+}
+
+func ackPath(segmentPath string) string {
+	return strings.TrimSuffix(segmentPath, walExt) + ackExt
+}
+
+// replayedSegment tracks a segment left over by a previous sender.
+func (w *writeAheadLog) replayedSegment(path string, entries int) *walSegment {
+	segment := &walSegment{wal: w, path: path}
+	segment.pending.Store(int64(entries))
+	segment.seal()
+	return segment
+}
+
+// release marks the entries of the segment at lines as delivered.
+func (seg *walSegment) release(lines []int) {
+	seg.ack(lines)
+	if seg.pending.Add(-int64(len(lines))) == 0 && seg.sealed.Load() {
+		seg.remove()
+	}
+}
+
+// ack appends lines to the segment's ack file. A failed write only means
+// those entries are sent again after a restart.
+func (seg *walSegment) ack(lines []int) {
+	seg.ackMu.Lock()
+	defer seg.ackMu.Unlock()
+
+	if seg.removed.Load() {
+		return
+	}
+
+	var buf []byte
+	for _, line := range lines {
+		buf = strconv.AppendInt(buf, int64(line), 10)
+		buf = append(buf, '\n')
+	}
+
+	var err error
+	if seg.ackFile == nil {
+		seg.ackFile, err = os.OpenFile(ackPath(seg.path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	}
+	if err == nil {
+		_, err = seg.ackFile.Write(buf)
+	}
+	if err != nil {
+		seg.wal.config.reportError(fmt.Errorf("failed to write WAL acks: %w", err), map[string]any{"operation": "wal", "path": seg.path})
+	}
+}
+
+// seal records that no more entries will be appended to the segment.
+func (seg *walSegment) seal() {
+	seg.sealed.Store(true)
+	if seg.pending.Load() == 0 {
+		seg.remove()
+	}
+}
+
+func (seg *walSegment) remove() {
+	seg.ackMu.Lock()
+	defer seg.ackMu.Unlock()
+
+	if !seg.removed.CompareAndSwap(false, true) {
+		return
+	}
+	if seg.ackFile != nil {
+		seg.ackFile.Close()
+		seg.ackFile = nil
+	}
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		seg.wal.config.reportError(fmt.Errorf("failed to remove WAL segment: %w", err), map[string]any{"operation": "wal", "path": seg.path})
+	}
+	// Removed after the segment, so a crash in between cannot replay
+	// delivered entries
+	if err := os.Remove(ackPath(seg.path)); err != nil && !os.IsNotExist(err) {
+		seg.wal.config.reportError(fmt.Errorf("failed to remove WAL acks: %w", err), map[string]any{"operation": "wal", "path": seg.path})
+	}
+}
+
+// appendWAL writes entry to the write-ahead log before it is queued. When
+// the write fails the entry is still queued, without the crash guarantee.
+func (s *Sender) appendWAL(entry *LogEntry) {
+	if s.wal == nil {
+		return
+	}
+
+	segment, index, err := s.wal.append(*entry)
+	if err != nil {
+		s.config.reportError(fmt.Errorf("failed to write WAL: %w", err), map[string]any{"operation": "wal"})
+		return
+	}
+	entry.wal = segment
+	entry.walIndex = index
+}
+
+// releaseWAL marks logs as delivered in the write-ahead log, acking the
+// lines of each segment with one write.
+func (s *Sender) releaseWAL(logs []LogEntry) {
+	var segment *walSegment
+	var lines []int
+	for _, log := range logs {
+		if log.wal == nil {
+			continue
+		}
+		if log.wal != segment {
+			if segment != nil {
+				segment.release(lines)
+			}
+			segment, lines = log.wal, lines[:0]
+		}
+		lines = append(lines, log.walIndex)
+	}
+	if segment != nil {
+		segment.release(lines)
+	}
+}
+
+// replayWAL queues the entries of segments left over by a previous sender,
+// waiting for room in a full queue.
+func (s *Sender) replayWAL(segments []string) {
+	defer s.wg.Done()
+
+	for _, path := range segments {
+		entries, skipped, err := readWALSegment(path)
+		if err != nil {
+			s.config.reportError(fmt.Errorf("failed to read WAL segment: %w", err), map[string]any{"operation": "wal", "path": path})
+			continue
+		}
+		if skipped > 0 {
+			s.config.reportErrorf(map[string]any{"operation": "wal", "path": path, "skipped": skipped}, "skipped %d unreadable WAL entries", skipped)
+		}
+
+		segment := s.wal.replayedSegment(path, len(entries))
+		for _, entry := range entries {
+			entry.wal = segment
+			s.stampReplayed(&entry)
+
+			select {
+			case s.queues.of(entry) <- s.queues.wrap(entry):
+				s.markEnqueued(entry)
+			case <-s.stopCh:
+				// The segment is kept; the next sender replays it
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func walFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+walExt))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	return files
+}
+
+// flushUntil flushes sender until transport received want entries, since
+// replayed entries are queued in the background.
+func flushUntil(t *testing.T, sender *Sender, transport *captureTransport, want int) []LogEntry {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(transport.all()) < want && time.Now().Before(deadline) {
+		if err := sender.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	entries := transport.all()
+	if len(entries) != want {
+		t.Fatalf("Expected %d entries, got %d", want, len(entries))
+	}
+	return entries
+}
+
+func TestSender_WALRemovesDeliveredSegments(t *testing.T) {
+	dir := t.TempDir()
+	transport := &captureTransport{}
+
+	sender, err := NewSender(&Config{Transport: transport, WALDir: dir})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "first", Timestamp: GenerateUniqueTimestamp()})
+	if files := walFiles(t, dir); len(files) != 1 {
+		t.Fatalf("WAL files after AddLog = %v, want 1 segment", files)
+	}
+
+	if err := sender.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	sender.Shutdown()
+
+	if files := walFiles(t, dir); len(files) != 0 {
+		t.Errorf("WAL files after delivery = %v, want none", files)
+	}
+}
+
+func TestSender_WALReplaysUndelivered(t *testing.T) {
+	dir := t.TempDir()
+
+	failing, err := NewSender(&Config{
+		Transport: transportFunc(func(context.Context, []LogEntry) error {
+			return errors.New("server unreachable")
+		}),
+		WALDir:       dir,
+		ErrorHandler: func(error, map[string]any) {},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	failing.AddLog(LogEntry{Level: "INFO", Message: "first", Timestamp: GenerateUniqueTimestamp(), Fields: map[string]any{"order_id": "o1"}})
+	failing.AddLog(LogEntry{Level: "ERROR", Message: "second", Timestamp: GenerateUniqueTimestamp()})
+	if err := failing.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	failing.Shutdown()
+
+	if files := walFiles(t, dir); len(files) != 1 {
+		t.Fatalf("WAL files after failed delivery = %v, want 1 segment", files)
+	}
+
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, WALDir: dir})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "third", Timestamp: GenerateUniqueTimestamp()})
+	entries := flushUntil(t, sender, transport, 3)
+	sender.Shutdown()
+
+	messages := map[string]LogEntry{}
+	for _, entry := range entries {
+		messages[entry.Message] = entry
+	}
+	if messages["first"].Fields["order_id"] != "o1" || messages["second"].Level != "ERROR" {
+		t.Errorf("Replayed entries = %+v", entries)
+	}
+	if files := walFiles(t, dir); len(files) != 0 {
+		t.Errorf("WAL files after replay = %v, want none", files)
+	}
+}
+
+func TestSender_WALReplaysOnlyUndeliveredEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	partial, err := NewSender(&Config{
+		Transport: transportFunc(func(_ context.Context, logs []LogEntry) error {
+			if logs[0].Message == "second" {
+				return errors.New("server unreachable")
+			}
+			return nil
+		}),
+		WALDir:       dir,
+		ErrorHandler: func(error, map[string]any) {},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	// One batch each, so only the second fails
+	for _, message := range []string{"first", "second", "third"} {
+		partial.AddLog(LogEntry{Level: "INFO", Message: message, Timestamp: GenerateUniqueTimestamp()})
+		if err := partial.FlushSync(context.Background()); err != nil {
+			t.Fatalf("FlushSync() error = %v", err)
+		}
+	}
+	partial.Shutdown()
+
+	if files := walFiles(t, dir); len(files) != 1 {
+		t.Fatalf("WAL files after partial delivery = %v, want 1 segment", files)
+	}
+
+	transport := &captureTransport{}
+	sender, err := NewSender(&Config{Transport: transport, WALDir: dir})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+	flushUntil(t, sender, transport, 1)
+	sender.Shutdown()
+
+	if entries := transport.all(); len(entries) != 1 || entries[0].Message != "second" {
+		t.Errorf("Replayed = %+v, want only the undelivered entry", entries)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("Files after replay = %v, want none", files)
+	}
+}
+
+func TestSender_WALSkipsTruncatedEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	// A crash in the middle of the second write
+	segment := `{"level":"INFO","message":"saved","timestamp":"2024-03-01T12:30:00Z","fields":null}` + "\n" + `{"level":"INFO","mess`
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000007.wal"), []byte(segment), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var reported []map[string]any
+	transport := &captureTransport{}
+
+	sender, err := NewSender(&Config{
+		Transport: transport,
+		WALDir:    dir,
+		ErrorHandler: func(err error, context map[string]any) {
+			mu.Lock()
+			reported = append(reported, context)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSender() error = %v", err)
+	}
+
+	entries := flushUntil(t, sender, transport, 1)
+	if entries[0].Message != "saved" {
+		t.Errorf("Replayed = %+v, want the complete entry", entries[0])
+	}
+
+	sender.AddLog(LogEntry{Level: "INFO", Message: "new", Timestamp: GenerateUniqueTimestamp()})
+	if files := walFiles(t, dir); len(files) != 1 || filepath.Base(files[0]) != "00000000000000000008.wal" {
+		t.Errorf("WAL files = %v, want the next segment number", files)
+	}
+	sender.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || reported[0]["skipped"] != 1 {
+		t.Errorf("Reported = %v, want 1 skipped entry", reported)
+	}
+}
+
+func TestNewSender_InvalidWALDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := NewSender(&Config{Transport: &captureTransport{}, WALDir: file}); err == nil {
+		t.Error("NewSender() expected error for a WALDir that is a file")
+	}
+}
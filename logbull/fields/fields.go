@@ -0,0 +1,89 @@
+// Package fields provides typed field constructors for the LogBullLogger
+// variadic methods, as an alternative to map literals:
+//
+//	logger.InfoF("payment captured",
+//		fields.String("order_id", id),
+//		fields.Int("attempt", attempt),
+//		fields.Duration("elapsed", time.Since(start)),
+//	)
+package fields
+
+import (
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+// Field is a single key/value pair. A Field with an empty key is skipped.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration sends value in its string form, e.g. "1.5s".
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Time sends value in RFC 3339 format with nanoseconds.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value.Format(time.RFC3339Nano)}
+}
+
+// Err sends err's message as the "error" field. A nil err is skipped.
+func Err(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Object sends a struct as a nested object keyed by its json field names.
+// Fields tagged json:"-" or logbull:"-", e.g. passwords, are left out.
+func Object(key string, value any) Field {
+	return Field{Key: key, Value: formatting.ObjectValue(value)}
+}
+
+// ToMap converts fields to the map form used by LogEntry. Later fields with
+// the same key win.
+func ToMap(fields []Field) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	m := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if field.Key != "" {
+			m[field.Key] = field.Value
+		}
+	}
+	return m
+}
@@ -0,0 +1,62 @@
+package fields
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToMap(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	got := ToMap([]Field{
+		String("user", "alice"),
+		Int("attempt", 3),
+		Int64("bytes", 1024),
+		Uint64("id", 7),
+		Float64("ratio", 0.5),
+		Bool("retry", true),
+		Duration("elapsed", 1500*time.Millisecond),
+		Time("at", at),
+		Err(errors.New("boom")),
+		Err(nil),
+		Any("tags", []string{"a"}),
+		String("user", "bob"),
+	})
+
+	want := map[string]any{
+		"user":    "bob",
+		"attempt": 3,
+		"bytes":   int64(1024),
+		"id":      uint64(7),
+		"ratio":   0.5,
+		"retry":   true,
+		"elapsed": "1.5s",
+		"at":      "2024-03-01T12:30:00Z",
+		"error":   "boom",
+		"tags":    []string{"a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToMap() = %v, want %v", got, want)
+	}
+}
+
+func TestObject(t *testing.T) {
+	type user struct {
+		ID       string `json:"id"`
+		Password string `logbull:"-"`
+	}
+
+	got := Object("user", user{ID: "u1", Password: "secret"})
+	want := Field{Key: "user", Value: map[string]any{"id": "u1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Object() = %v, want %v", got, want)
+	}
+}
+
+func TestToMap_Empty(t *testing.T) {
+	if got := ToMap(nil); got != nil {
+		t.Errorf("ToMap(nil) = %v, want nil", got)
+	}
+}
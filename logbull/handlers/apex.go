@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/apex/log"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/internal/diag"
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+// ApexHandler is a github.com/apex/log Handler sending entries to LogBull.
+// Entries below Config.LogLevel are ignored, in addition to the apex logger's
+// own level.
+type ApexHandler struct {
+	config   *core.Config
+	sender   *core.Sender
+	minLevel core.LogLevel
+	shared   bool
+}
+
+func NewApexHandler(config core.Config) (*ApexHandler, error) {
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" && len(config.Hosts) > 0 {
+		config.Host = strings.TrimSpace(config.Hosts[0])
+	}
+	config.APIKey = strings.TrimSpace(config.APIKey)
+
+	if config.LogLevel == "" {
+		config.LogLevel = core.INFO
+	}
+
+	// Check if credentials are provided
+	if config.Transport == nil && (config.ProjectID == "" || config.Host == "") {
+		// No credentials: do nothing (apex will print through its other handlers)
+		diag.Notice(config.Silent, "No credentials provided for ApexHandler. Handler is disabled. Logs will not be sent to LogBull server.")
+		return &ApexHandler{
+			config:   &config,
+			sender:   nil,
+			minLevel: config.LogLevel,
+		}, nil
+	}
+
+	// A custom transport does not talk to a LogBull server
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
+
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.APIKey != "" {
+		if err := validation.ValidateAPIKey(config.APIKey); err != nil {
+			return nil, err
+		}
+	}
+
+	sender, err := core.NewSender(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApexHandler{
+		config:   &config,
+		sender:   sender,
+		minLevel: config.LogLevel,
+	}, nil
+}
+
+// NewApexHandlerFromLogger creates a handler that sends through logger's
+// sender instead of starting its own, using the logger's configuration.
+func NewApexHandlerFromLogger(logger *core.LogBullLogger) *ApexHandler {
+	config, sender := fromLogger(logger)
+	return &ApexHandler{
+		config:   config,
+		sender:   sender,
+		minLevel: config.LogLevel,
+		shared:   true,
+	}
+}
+
+func (h *ApexHandler) HandleLog(entry *log.Entry) error {
+	// If handler is disabled, do nothing
+	if h.sender == nil {
+		return nil
+	}
+	defer recoverHandlerPanic(h.config, "handle")
+
+	level := convertApexLevel(entry.Level)
+	if level.Priority() < h.minLevel.Priority() {
+		return nil
+	}
+
+	fields := make(map[string]any, len(entry.Fields))
+	for key, value := range entry.Fields {
+		fields[key] = value
+	}
+
+	if h.config.IncludeCaller {
+		if frame, ok := callsite.Capture(0); ok {
+			callsite.AddFields(fields, frame.Function, frame.File, frame.Line)
+		}
+	}
+
+	logEntry := core.LogEntry{
+		Level:     level.String(),
+		Message:   formatting.FormatMessage(entry.Message),
+		Timestamp: core.FormatTimestamp(entry.Timestamp),
+		Fields:    formatting.EnsureFields(fields, h.config.FieldKeys),
+	}
+
+	h.sender.AddLog(logEntry)
+	return nil
+}
+
+func (h *ApexHandler) Flush() {
+	if h.sender != nil {
+		h.sender.Flush()
+	}
+}
+
+func (h *ApexHandler) FlushSync(ctx context.Context) error {
+	if h.sender == nil {
+		return nil
+	}
+	return h.sender.FlushSync(ctx)
+}
+
+func (h *ApexHandler) Stats() core.Stats {
+	if h.sender == nil {
+		return core.Stats{}
+	}
+	return h.sender.Stats()
+}
+
+func (h *ApexHandler) Shutdown() {
+	if h.sender == nil {
+		return
+	}
+	if h.shared {
+		h.sender.Flush()
+		return
+	}
+	h.sender.Shutdown()
+}
+
+func convertApexLevel(level log.Level) core.LogLevel {
+	switch level {
+	case log.DebugLevel:
+		return core.DEBUG
+	case log.InfoLevel:
+		return core.INFO
+	case log.WarnLevel:
+		return core.WARNING
+	case log.ErrorLevel:
+		return core.ERROR
+	case log.FatalLevel:
+		return core.CRITICAL
+	default:
+		return core.INFO
+	}
+}
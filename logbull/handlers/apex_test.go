@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func newApexRecorder(t *testing.T, config core.Config) (*log.Logger, *ApexHandler, *logbulltest.Recorder) {
+	t.Helper()
+
+	recorder := logbulltest.NewRecorder()
+	config.Transport = recorder
+
+	handler, err := NewApexHandler(config)
+	if err != nil {
+		t.Fatalf("NewApexHandler() error = %v", err)
+	}
+	t.Cleanup(handler.Shutdown)
+
+	return &log.Logger{Handler: handler, Level: log.DebugLevel}, handler, recorder
+}
+
+func TestNewApexHandler(t *testing.T) {
+	t.Run("valid configuration", func(t *testing.T) {
+		handler, err := NewApexHandler(core.Config{
+			ProjectID: "12345678-1234-1234-1234-123456789012",
+			Host:      "http://localhost:4005",
+		})
+		if err != nil {
+			t.Errorf("NewApexHandler() error = %v", err)
+		}
+		if handler != nil {
+			defer handler.Shutdown()
+		}
+	})
+
+	t.Run("invalid project ID", func(t *testing.T) {
+		_, err := NewApexHandler(core.Config{
+			ProjectID: "invalid",
+			Host:      "http://localhost:4005",
+		})
+		if err == nil {
+			t.Error("NewApexHandler() expected error for invalid project ID")
+		}
+	})
+
+	t.Run("no credentials disables the handler", func(t *testing.T) {
+		handler, err := NewApexHandler(core.Config{Silent: true})
+		if err != nil {
+			t.Fatalf("NewApexHandler() error = %v", err)
+		}
+		if err := handler.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "ignored"}); err != nil {
+			t.Errorf("HandleLog() error = %v", err)
+		}
+	})
+}
+
+func TestApexHandler_HandleLog(t *testing.T) {
+	logger, handler, recorder := newApexRecorder(t, core.Config{LogLevel: core.DEBUG})
+
+	logger.WithFields(log.Fields{"user_id": "12345", "attempt": 2}).Info("User logged in")
+	logger.WithError(errors.New("connection timeout")).Error("Payment failed")
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if entries[0].Level != "INFO" || entries[0].Message != "User logged in" {
+		t.Errorf("entry = %s %q, want INFO %q", entries[0].Level, entries[0].Message, "User logged in")
+	}
+	if entries[0].Fields["user_id"] != "12345" || entries[0].Fields["attempt"] != 2 {
+		t.Errorf("Fields = %v", entries[0].Fields)
+	}
+
+	if entries[1].Level != "ERROR" {
+		t.Errorf("Level = %s, want ERROR", entries[1].Level)
+	}
+	if entries[1].Fields["error"] != "connection timeout" {
+		t.Errorf("Fields[error] = %v, want connection timeout", entries[1].Fields["error"])
+	}
+}
+
+func TestApexHandler_LevelsAndTimestamp(t *testing.T) {
+	_, handler, recorder := newApexRecorder(t, core.Config{LogLevel: core.WARNING})
+
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	levels := []log.Level{log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel}
+	for _, level := range levels {
+		if err := handler.HandleLog(&log.Entry{Level: level, Message: level.String(), Timestamp: timestamp}); err != nil {
+			t.Fatalf("HandleLog() error = %v", err)
+		}
+	}
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	want := []string{"WARNING", "ERROR", "CRITICAL"}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Level != want[i] {
+			t.Errorf("entries[%d].Level = %s, want %s", i, entry.Level, want[i])
+		}
+		if entry.Timestamp != core.FormatTimestamp(timestamp) {
+			t.Errorf("entries[%d].Timestamp = %s, want %s", i, entry.Timestamp, core.FormatTimestamp(timestamp))
+		}
+	}
+}
+
+func TestApexHandler_IncludeCaller(t *testing.T) {
+	logger, handler, recorder := newApexRecorder(t, core.Config{IncludeCaller: true})
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.WithField("k", "v").Info("with caller")
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	checkCaller(t, entries[0], "TestApexHandler_IncludeCaller", line+1)
+}
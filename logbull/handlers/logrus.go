@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/internal/diag"
 	"github.com/logbull/logbull-go/logbull/internal/formatting"
 	"github.com/logbull/logbull-go/logbull/internal/validation"
 )
@@ -14,11 +18,15 @@ type LogrusHook struct {
 	config *core.Config
 	sender *core.Sender
 	levels []logrus.Level
+	shared bool
 }
 
 func NewLogrusHook(config core.Config) (*LogrusHook, error) {
 	config.ProjectID = strings.TrimSpace(config.ProjectID)
 	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" && len(config.Hosts) > 0 {
+		config.Host = strings.TrimSpace(config.Hosts[0])
+	}
 	config.APIKey = strings.TrimSpace(config.APIKey)
 
 	if config.LogLevel == "" {
@@ -28,11 +36,9 @@ func NewLogrusHook(config core.Config) (*LogrusHook, error) {
 	levels := levelsFromConfig(config.LogLevel)
 
 	// Check if credentials are provided
-	if config.ProjectID == "" || config.Host == "" {
+	if config.Transport == nil && (config.ProjectID == "" || config.Host == "") {
 		// No credentials: do nothing (Logrus will print)
-		println(
-			"LogBull: No credentials provided for LogrusHook. Handler is disabled. Logs will not be sent to LogBull server.",
-		)
+		diag.Notice(config.Silent, "No credentials provided for LogrusHook. Handler is disabled. Logs will not be sent to LogBull server.")
 		return &LogrusHook{
 			config: &config,
 			sender: nil,
@@ -40,12 +46,15 @@ func NewLogrusHook(config core.Config) (*LogrusHook, error) {
 		}, nil
 	}
 
-	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
-		return nil, err
-	}
+	// A custom transport does not talk to a LogBull server
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
 
-	if err := validation.ValidateHostURL(config.Host); err != nil {
-		return nil, err
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
 	}
 
 	if config.APIKey != "" {
@@ -66,6 +75,18 @@ func NewLogrusHook(config core.Config) (*LogrusHook, error) {
 	}, nil
 }
 
+// NewLogrusHookFromLogger creates a hook that sends through logger's sender
+// instead of starting its own, using the logger's configuration.
+func NewLogrusHookFromLogger(logger *core.LogBullLogger) *LogrusHook {
+	config, sender := fromLogger(logger)
+	return &LogrusHook{
+		config: config,
+		sender: sender,
+		levels: levelsFromConfig(config.LogLevel),
+		shared: true,
+	}
+}
+
 func (h *LogrusHook) Levels() []logrus.Level {
 	return h.levels
 }
@@ -75,20 +96,35 @@ func (h *LogrusHook) Fire(entry *logrus.Entry) error {
 	if h.sender == nil {
 		return nil
 	}
+	defer recoverHandlerPanic(h.config, "fire")
 
 	level := convertLogrusLevel(entry.Level)
 	message := entry.Message
 
-	fields := make(map[string]any)
+	fields := make(map[string]any, len(entry.Data)+2)
 	for key, value := range entry.Data {
 		fields[key] = value
 	}
 
+	// Same keys as logrus' own formatters when SetReportCaller is enabled
+	if entry.HasCaller() {
+		fields[logrus.FieldKeyFunc] = entry.Caller.Function
+		fields[logrus.FieldKeyFile] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+
+	if h.config.IncludeCaller {
+		if entry.HasCaller() {
+			callsite.AddFields(fields, entry.Caller.Function, entry.Caller.File, entry.Caller.Line)
+		} else if frame, ok := callsite.Capture(0); ok {
+			callsite.AddFields(fields, frame.Function, frame.File, frame.Line)
+		}
+	}
+
 	logEntry := core.LogEntry{
 		Level:     level.String(),
 		Message:   formatting.FormatMessage(message),
-		Timestamp: core.GenerateUniqueTimestamp(),
-		Fields:    formatting.EnsureFields(fields),
+		Timestamp: core.FormatTimestamp(entry.Time),
+		Fields:    formatting.EnsureFields(fields, h.config.FieldKeys),
 	}
 
 	h.sender.AddLog(logEntry)
@@ -101,10 +137,29 @@ func (h *LogrusHook) Flush() {
 	}
 }
 
+func (h *LogrusHook) FlushSync(ctx context.Context) error {
+	if h.sender == nil {
+		return nil
+	}
+	return h.sender.FlushSync(ctx)
+}
+
+func (h *LogrusHook) Stats() core.Stats {
+	if h.sender == nil {
+		return core.Stats{}
+	}
+	return h.sender.Stats()
+}
+
 func (h *LogrusHook) Shutdown() {
-	if h.sender != nil {
-		h.sender.Shutdown()
+	if h.sender == nil {
+		return
+	}
+	if h.shared {
+		h.sender.Flush()
+		return
 	}
+	h.sender.Shutdown()
 }
 
 func convertLogrusLevel(level logrus.Level) core.LogLevel {
@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
 )
 
 func TestNewLogrusHook(t *testing.T) {
@@ -91,8 +98,9 @@ func TestLogrusHook_Fire(t *testing.T) {
 		"action":  "login",
 	}).Info("User logged in")
 
-	hook.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogrusHook_AllLevels(t *testing.T) {
@@ -122,8 +130,9 @@ func TestLogrusHook_AllLevels(t *testing.T) {
 	logger.Warn("warning message")
 	logger.Error("error message")
 
-	hook.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestLogrusHook_WithFields(t *testing.T) {
@@ -153,8 +162,9 @@ func TestLogrusHook_WithFields(t *testing.T) {
 		"complex": map[string]string{"nested": "value"},
 	}).Info("Complex fields test")
 
-	hook.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestConvertLogrusLevel(t *testing.T) {
@@ -282,6 +292,95 @@ func TestLogrusHook_LevelFiltering(t *testing.T) {
 	logger.Warn("should be filtered")
 	logger.Error("should pass")
 
-	hook.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+}
+
+func TestLogrusHook_EntryMetadata(t *testing.T) {
+	var mu sync.Mutex
+	var received []core.LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch core.LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	hook, err := NewLogrusHook(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewLogrusHook() error = %v", err)
+	}
+	defer hook.Shutdown()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetReportCaller(true)
+	logger.AddHook(hook)
+
+	eventTime := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	logger.WithTime(eventTime).
+		WithError(errors.New("connection refused")).
+		Error("Payment failed")
+
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(received))
+	}
+
+	entry := received[0]
+	if entry.Timestamp != "2024-03-01T12:30:00.123456789Z" {
+		t.Errorf("Timestamp = %s, want the logrus entry time", entry.Timestamp)
+	}
+	if entry.Fields[logrus.ErrorKey] != "connection refused" {
+		t.Errorf("error = %v, want the error message", entry.Fields[logrus.ErrorKey])
+	}
+	if file, _ := entry.Fields[logrus.FieldKeyFile].(string); !strings.Contains(file, "logrus_test.go:") {
+		t.Errorf("file = %v, want logrus_test.go with line", entry.Fields[logrus.FieldKeyFile])
+	}
+	if fn, _ := entry.Fields[logrus.FieldKeyFunc].(string); !strings.HasSuffix(fn, "TestLogrusHook_EntryMetadata") {
+		t.Errorf("func = %v", entry.Fields[logrus.FieldKeyFunc])
+	}
+}
+
+func TestLogrusHook_IncludeCaller(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	hook, err := NewLogrusHook(core.Config{Transport: recorder, IncludeCaller: true})
+	if err != nil {
+		t.Fatalf("NewLogrusHook() error = %v", err)
+	}
+	defer hook.Shutdown()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.WithField("k", "v").Info("with caller")
+
+	if err := hook.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	checkCaller(t, entries[0], "TestLogrusHook_IncludeCaller", line+1)
 }
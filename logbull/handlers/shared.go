@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/diag"
+)
+
+// fromLogger returns the configuration and sender of logger for the
+// FromLogger constructors. Handlers created this way share the logger's
+// queue and workers; their Shutdown only flushes, and logger.Shutdown stops
+// delivery for all of them. The sender is nil in console-only mode, which
+// disables the handler.
+func fromLogger(logger *core.LogBullLogger) (*core.Config, *core.Sender) {
+	config := logger.Config()
+	return &config, logger.Sender()
+}
+
+// recoverHandlerPanic reports a panic raised while handling an entry, e.g. by
+// a zap ObjectMarshaler or a ReplaceAttr function, to config's ErrorHandler
+// as a core.ErrLogPanic instead of crashing the application. The entry is
+// dropped. It must be deferred directly.
+func recoverHandlerPanic(config *core.Config, operation string) {
+	if r := recover(); r != nil {
+		err := fmt.Errorf("%w: %v", core.ErrLogPanic, r)
+		diag.Report(config.ErrorHandler, config.Silent, err, map[string]any{"operation": operation})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func TestFromLogger_SharesSender(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{LogLevel: core.INFO})
+
+	slogHandler := NewSlogHandlerFromLogger(logger)
+	zapCore := NewZapCoreFromLogger(logger)
+	logrusHook := NewLogrusHookFromLogger(logger)
+	apexHandler := NewApexHandlerFromLogger(logger)
+	stdWriter := NewStdLogWriterFromLogger(logger)
+
+	logger.Info("from logger", nil)
+	slog.New(slogHandler).Info("from slog")
+	slog.New(slogHandler).Debug("filtered by the logger level")
+	zap.New(zapCore).Info("from zap")
+
+	logrusLogger := logrus.New()
+	logrusLogger.AddHook(logrusHook)
+	logrusLogger.Info("from logrus")
+
+	(&log.Logger{Handler: apexHandler, Level: log.DebugLevel}).Info("from apex")
+	stdWriter.Logger("").Print("from stdlog")
+
+	for _, shutdown := range []func(){slogHandler.Shutdown, zapCore.Shutdown, logrusHook.Shutdown, apexHandler.Shutdown, stdWriter.Shutdown} {
+		shutdown()
+	}
+
+	// The handlers must not have shut down the shared sender
+	if err := logger.TryInfo("after handler shutdown", nil); err != nil {
+		t.Fatalf("TryInfo() error = %v", err)
+	}
+
+	for _, message := range []string{"from logger", "from slog", "from zap", "from logrus", "from apex", "from stdlog", "after handler shutdown"} {
+		if !recorder.Contains("", message) {
+			t.Errorf("Expected %q to be recorded", message)
+		}
+	}
+	if recorder.Contains("", "filtered") {
+		t.Error("Expected the logger level to apply to shared handlers")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+	stats := logger.Stats()
+	if stats.EnqueuedLogs != 7 {
+		t.Errorf("EnqueuedLogs = %d, want 7 through one sender", stats.EnqueuedLogs)
+	}
+	if handlerStats := zapCore.Stats(); handlerStats != stats {
+		t.Errorf("handler Stats() = %+v, want the logger's %+v", handlerStats, stats)
+	}
+}
+
+func TestFromLogger_ConsoleOnly(t *testing.T) {
+	logger, err := core.NewLogger(core.Config{ConsoleFormat: core.ConsoleDisabled, Silent: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	handler := NewSlogHandlerFromLogger(logger)
+	slog.New(handler).Info("ignored")
+	handler.Shutdown()
+
+	if stats := handler.Stats(); stats != (core.Stats{}) {
+		t.Errorf("Stats() = %+v, want zero for a console-only logger", stats)
+	}
+}
+
+// panickingObject panics when zap encodes it.
+type panickingObject struct{}
+
+func (panickingObject) MarshalLogObject(zapcore.ObjectEncoder) error {
+	panic("object broke")
+}
+
+func TestHandlers_RecoverPanics(t *testing.T) {
+	var mu sync.Mutex
+	var operations []string
+
+	logger, recorder := logbulltest.NewLogger(t, core.Config{
+		FingerprintFunc: func(entry core.LogEntry) string {
+			if strings.Contains(entry.Message, "fingerprint") {
+				panic("fingerprint broke")
+			}
+			return "ok"
+		},
+		ErrorHandler: func(err error, context map[string]any) {
+			if !errors.Is(err, core.ErrLogPanic) {
+				t.Errorf("ErrorHandler() error = %v, want ErrLogPanic", err)
+			}
+			mu.Lock()
+			operations = append(operations, fmt.Sprint(context["operation"]))
+			mu.Unlock()
+		},
+	})
+
+	slogHandler := NewSlogHandlerFromLogger(logger).WithOptions(&slog.HandlerOptions{
+		ReplaceAttr: func([]string, slog.Attr) slog.Attr { panic("replace broke") },
+	})
+	slog.New(slogHandler).Info("from slog")
+	zap.New(NewZapCoreFromLogger(logger)).Info("from zap", zap.Object("user", panickingObject{}))
+
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(io.Discard)
+	logrusLogger.AddHook(NewLogrusHookFromLogger(logger))
+	logrusLogger.Error("fingerprint from logrus")
+
+	(&log.Logger{Handler: NewApexHandlerFromLogger(logger), Level: log.DebugLevel}).Error("fingerprint from apex")
+	NewStdLogWriterFromLogger(logger).Logger("").Print("ERROR: fingerprint from stdlog")
+	logger.Error("fingerprint from logger", nil)
+
+	logger.Info("after panics", nil)
+
+	mu.Lock()
+	got := strings.Join(operations, ",")
+	mu.Unlock()
+	if want := "handle,write,enqueue,enqueue,log,log"; got != want {
+		t.Errorf("Reported operations = %s, want %s", got, want)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Message != "after panics" {
+		t.Errorf("Entries() = %+v, want only the entry logged after the panics", entries)
+	}
+}
+
+func FuzzSlogHandler(f *testing.F) {
+	f.Add("order placed", "user_id", "12345", false)
+	f.Add("", "", "\xff", true)
+
+	f.Fuzz(func(t *testing.T, message, key, value string, panics bool) {
+		logger, _ := logbulltest.NewLogger(t, core.Config{ErrorHandler: func(error, map[string]any) {}})
+		handler := NewSlogHandlerFromLogger(logger)
+
+		var attr any = value
+		if panics {
+			attr = panickingObject{}
+		}
+		slog.New(handler).Info(message, key, attr, slog.Group(key, key, value))
+	})
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/internal/diag"
 	"github.com/logbull/logbull-go/logbull/internal/formatting"
 	"github.com/logbull/logbull-go/logbull/internal/validation"
 )
@@ -15,11 +19,17 @@ type SlogHandler struct {
 	sender *core.Sender
 	attrs  []slog.Attr
 	group  string
+	shared bool
+	// options is set by WithOptions
+	options slog.HandlerOptions
 }
 
 func NewSlogHandler(config core.Config) (*SlogHandler, error) {
 	config.ProjectID = strings.TrimSpace(config.ProjectID)
 	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" && len(config.Hosts) > 0 {
+		config.Host = strings.TrimSpace(config.Hosts[0])
+	}
 	config.APIKey = strings.TrimSpace(config.APIKey)
 
 	if config.LogLevel == "" {
@@ -27,11 +37,9 @@ func NewSlogHandler(config core.Config) (*SlogHandler, error) {
 	}
 
 	// Check if credentials are provided
-	if config.ProjectID == "" || config.Host == "" {
+	if config.Transport == nil && (config.ProjectID == "" || config.Host == "") {
 		// No credentials: do nothing (slog will print)
-		println(
-			"LogBull: No credentials provided for SlogHandler. Handler is disabled. Logs will not be sent to LogBull server.",
-		)
+		diag.Notice(config.Silent, "No credentials provided for SlogHandler. Handler is disabled. Logs will not be sent to LogBull server.")
 		return &SlogHandler{
 			config: &config,
 			sender: nil,
@@ -40,12 +48,15 @@ func NewSlogHandler(config core.Config) (*SlogHandler, error) {
 		}, nil
 	}
 
-	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
-		return nil, err
-	}
+	// A custom transport does not talk to a LogBull server
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
 
-	if err := validation.ValidateHostURL(config.Host); err != nil {
-		return nil, err
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
 	}
 
 	if config.APIKey != "" {
@@ -67,61 +78,138 @@ func NewSlogHandler(config core.Config) (*SlogHandler, error) {
 	}, nil
 }
 
+// NewSlogHandlerFromLogger creates a handler that sends through logger's
+// sender instead of starting its own, using the logger's configuration.
+func NewSlogHandlerFromLogger(logger *core.LogBullLogger) *SlogHandler {
+	config, sender := fromLogger(logger)
+	return &SlogHandler{
+		config: config,
+		sender: sender,
+		attrs:  []slog.Attr{},
+		shared: true,
+	}
+}
+
+// WithOptions returns a handler applying opts like slog.NewJSONHandler does:
+// opts.Level replaces Config.LogLevel, opts.AddSource adds the caller as
+// "file:line" under slog.SourceKey, and opts.ReplaceAttr rewrites or drops
+// attributes. ReplaceAttr also sees the time, level and message; replacing
+// them with a value of the same kind (or a level name) changes the entry,
+// while dropping them keeps the original, since every entry needs them. A
+// nil opts restores the defaults.
+func (h *SlogHandler) WithOptions(opts *slog.HandlerOptions) *SlogHandler {
+	clone := *h
+	clone.options = slog.HandlerOptions{}
+	if opts != nil {
+		clone.options = *opts
+	}
+	return &clone
+}
+
 func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.options.Level != nil {
+		return level >= h.options.Level.Level()
+	}
+
 	logbullLevel := convertSlogLevel(level)
 	return logbullLevel.Priority() >= h.config.LogLevel.Priority()
 }
 
-func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+// Handle sends record. With OverflowBlock, ctx also bounds the wait for room
+// in a full send queue.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
 	// If handler is disabled, do nothing
 	if h.sender == nil {
 		return nil
 	}
+	defer recoverHandlerPanic(h.config, "handle")
 
 	level := convertSlogLevel(record.Level)
 	message := record.Message
+	timestamp := record.Time
+	if h.options.ReplaceAttr != nil {
+		level, message, timestamp = h.replaceBuiltins(record)
+	}
 
 	fields := make(map[string]any)
 
+	var groups []string
+	if h.group != "" {
+		groups = []string{h.group}
+	}
+
 	for _, attr := range h.attrs {
-		h.addAttrToFields(fields, attr, h.group)
+		h.addAttrToFields(fields, attr, groups)
 	}
 
 	record.Attrs(func(attr slog.Attr) bool {
-		h.addAttrToFields(fields, attr, h.group)
+		h.addAttrToFields(fields, attr, groups)
 		return true
 	})
 
+	if h.options.AddSource {
+		// Same lookup as IncludeCaller below
+		frame, ok := callsite.FrameForPC(record.PC)
+		if !ok {
+			frame, ok = callsite.Capture(0)
+		}
+		if ok {
+			source := &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+			h.addAttrToFields(fields, slog.Any(slog.SourceKey, source), nil)
+		}
+	}
+
+	if h.config.IncludeCaller {
+		// slog.Logger records the caller's PC; fall back to walking the stack
+		// when the handler is called directly
+		frame, ok := callsite.FrameForPC(record.PC)
+		if !ok {
+			frame, ok = callsite.Capture(0)
+		}
+		if ok {
+			callsite.AddFields(fields, frame.Function, frame.File, frame.Line)
+		}
+	}
+
 	entry := core.LogEntry{
 		Level:     level.String(),
 		Message:   formatting.FormatMessage(message),
-		Timestamp: core.GenerateUniqueTimestamp(),
-		Fields:    formatting.EnsureFields(fields),
+		Timestamp: core.FormatTimestamp(timestamp),
+		Fields:    formatting.EnsureFields(fields, h.config.FieldKeys),
 	}
 
-	h.sender.AddLog(entry)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_ = h.sender.AddLogCtx(ctx, entry)
 	return nil
 }
 
+// WithAttrs returns a new handler; the receiver is never modified, so both
+// handlers are safe to share across goroutines.
 func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	newAttrs := make([]slog.Attr, len(h.attrs)+len(attrs))
 	copy(newAttrs, h.attrs)
 	copy(newAttrs[len(h.attrs):], attrs)
 
 	return &SlogHandler{
-		config: h.config,
-		sender: h.sender,
-		attrs:  newAttrs,
-		group:  h.group,
+		config:  h.config,
+		sender:  h.sender,
+		attrs:   newAttrs,
+		group:   h.group,
+		shared:  h.shared,
+		options: h.options,
 	}
 }
 
 func (h *SlogHandler) WithGroup(name string) slog.Handler {
 	return &SlogHandler{
-		config: h.config,
-		sender: h.sender,
-		attrs:  h.attrs,
-		group:  name,
+		config:  h.config,
+		sender:  h.sender,
+		attrs:   h.attrs,
+		group:   name,
+		shared:  h.shared,
+		options: h.options,
 	}
 }
 
@@ -131,20 +219,107 @@ func (h *SlogHandler) Flush() {
 	}
 }
 
+func (h *SlogHandler) FlushSync(ctx context.Context) error {
+	if h.sender == nil {
+		return nil
+	}
+	return h.sender.FlushSync(ctx)
+}
+
+func (h *SlogHandler) Stats() core.Stats {
+	if h.sender == nil {
+		return core.Stats{}
+	}
+	return h.sender.Stats()
+}
+
 func (h *SlogHandler) Shutdown() {
-	if h.sender != nil {
-		h.sender.Shutdown()
+	if h.sender == nil {
+		return
 	}
+	if h.shared {
+		h.sender.Flush()
+		return
+	}
+	h.sender.Shutdown()
 }
 
-func (h *SlogHandler) addAttrToFields(fields map[string]any, attr slog.Attr, group string) {
+// replaceBuiltins passes the record's time, level and message through
+// ReplaceAttr, keeping the originals when the results cannot stand in for
+// them.
+func (h *SlogHandler) replaceBuiltins(record slog.Record) (core.LogLevel, string, time.Time) {
+	level := convertSlogLevel(record.Level)
+	message := record.Message
+	timestamp := record.Time
+
+	if !record.Time.IsZero() {
+		if attr := h.options.ReplaceAttr(nil, slog.Time(slog.TimeKey, record.Time)); attr.Value.Kind() == slog.KindTime {
+			timestamp = attr.Value.Time()
+		}
+	}
+
+	attr := h.options.ReplaceAttr(nil, slog.Any(slog.LevelKey, record.Level))
+	switch value := attr.Value.Any().(type) {
+	case slog.Level:
+		level = convertSlogLevel(value)
+	case string:
+		if parsed, err := core.ParseLevel(value); err == nil {
+			level = parsed
+		}
+	}
+
+	if attr := h.options.ReplaceAttr(nil, slog.String(slog.MessageKey, record.Message)); attr.Value.Kind() == slog.KindString {
+		message = attr.Value.String()
+	}
+
+	return level, message, timestamp
+}
+
+func (h *SlogHandler) addAttrToFields(fields map[string]any, attr slog.Attr, groups []string) {
+	addSlogAttr(fields, attr, groups, h.options.ReplaceAttr)
+}
+
+// addSlogAttr resolves LogValuers, applies replace when set, flattens groups
+// into dotted keys and converts times and durations into JSON-friendly
+// strings.
+func addSlogAttr(fields map[string]any, attr slog.Attr, groups []string, replace func([]string, slog.Attr) slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if replace != nil && attr.Value.Kind() != slog.KindGroup {
+		attr = replace(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	group := strings.Join(groups, ".")
 	key := attr.Key
-	if group != "" {
+	if group != "" && key != "" {
 		key = group + "." + key
+	} else if key == "" {
+		key = group
 	}
 
-	value := attr.Value.Any()
-	fields[key] = value
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		// A group with an empty key is inlined into the parent
+		if attr.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], attr.Key)
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			addSlogAttr(fields, groupAttr, groups, replace)
+		}
+	case slog.KindTime:
+		fields[key] = attr.Value.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		fields[key] = attr.Value.Duration().String()
+	default:
+		if source, ok := attr.Value.Any().(*slog.Source); ok {
+			fields[key] = fmt.Sprintf("%s:%d", source.File, source.Line)
+			return
+		}
+		fields[key] = attr.Value.Any()
+	}
 }
 
 func convertSlogLevel(level slog.Level) core.LogLevel {
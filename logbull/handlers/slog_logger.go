@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+)
+
+// loggerHandler is the slog.Handler returned by AsSlogHandler.
+type loggerHandler struct {
+	logger *core.LogBullLogger
+	config *core.Config
+	// fields holds the attributes added with WithAttrs, already flattened
+	// under the groups open at the time
+	fields map[string]any
+	groups []string
+}
+
+// AsSlogHandler returns a slog.Handler logging through logger itself, so
+// slog records take the same path as the logger's own calls: its context
+// fields, current level, Schema, message templates, key policy, console
+// output, deduplication and rate limits. Fields of the record's context are
+// added like WithCtx does. Unlike NewSlogHandlerFromLogger, which only
+// shares the sender, there is no second configuration to keep in sync, and
+// shutting the logger down is all the cleanup needed.
+func AsSlogHandler(logger *core.LogBullLogger) slog.Handler {
+	config := logger.Config()
+	return &loggerHandler{logger: logger, config: &config}
+}
+
+func (h *loggerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return convertSlogLevel(level).Priority() >= h.logger.Level().Priority()
+}
+
+func (h *loggerHandler) Handle(ctx context.Context, record slog.Record) error {
+	defer recoverHandlerPanic(h.config, "handle")
+
+	fields := make(map[string]any, len(h.fields)+record.NumAttrs())
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, attr, h.groups, nil)
+		return true
+	})
+
+	// The logger walks the stack for the caller otherwise, which also works
+	// when the handler is called directly
+	if h.config.IncludeCaller {
+		if frame, ok := callsite.FrameForPC(record.PC); ok {
+			callsite.AddFields(fields, frame.Function, frame.File, frame.Line)
+		}
+	}
+
+	logger := h.logger
+	if ctx != nil {
+		logger = logger.WithCtx(ctx)
+	}
+	logger.LogAt(record.Time, convertSlogLevel(record.Level), record.Message, fields)
+	return nil
+}
+
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make(map[string]any, len(h.fields)+len(attrs))
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	for _, attr := range attrs {
+		addSlogAttr(fields, attr, h.groups, nil)
+	}
+
+	clone := *h
+	clone.fields = fields
+	return &clone
+}
+
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &clone
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func TestAsSlogHandler(t *testing.T) {
+	base, recorder := logbulltest.NewLogger(t, core.Config{LogLevel: core.INFO})
+	logger := base.WithField("tenant", "acme")
+
+	slogger := slog.New(AsSlogHandler(logger)).With("user_id", "u-1").WithGroup("order")
+	ctx := core.ContextWithFields(context.Background(), map[string]any{"request_path": "/checkout"})
+
+	slogger.InfoContext(ctx, "order placed", "id", 42, slog.Group("payment", "method", "card"))
+	slogger.Debug("filtered by the logger level")
+
+	// The handler follows the logger's level as it changes
+	if err := base.SetLevel(core.DEBUG); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	slogger.Debug("after SetLevel")
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Entries() = %+v, want 2", entries)
+	}
+
+	entry := entries[0]
+	if entry.Level != "INFO" || entry.Message != "order placed" {
+		t.Errorf("Entry = %s %q, want INFO \"order placed\"", entry.Level, entry.Message)
+	}
+	want := map[string]any{
+		"tenant":               "acme",
+		"user_id":              "u-1",
+		"order.id":             int64(42),
+		"order.payment.method": "card",
+		"request_path":         "/checkout",
+	}
+	for key, value := range want {
+		if entry.Fields[key] != value {
+			t.Errorf("Fields[%q] = %v (%T), want %v", key, entry.Fields[key], entry.Fields[key], value)
+		}
+	}
+
+	if entries[1].Level != "DEBUG" || entries[1].Message != "after SetLevel" {
+		t.Errorf("Second entry = %s %q, want the debug entry logged after SetLevel", entries[1].Level, entries[1].Message)
+	}
+}
+
+func TestAsSlogHandler_IncludeCaller(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{IncludeCaller: true})
+
+	slog.New(AsSlogHandler(logger)).Info("with caller")
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Entries() = %+v, want 1", entries)
+	}
+	if file, _ := entries[0].Fields[callsite.FileField].(string); !strings.HasSuffix(file, "slog_logger_test.go") {
+		t.Errorf("%s = %q, want this test file", callsite.FileField, file)
+	}
+}
@@ -3,13 +3,20 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
 )
 
 func TestNewSlogHandler(t *testing.T) {
@@ -95,8 +102,9 @@ func TestSlogHandler_Handle(t *testing.T) {
 		slog.Int("count", 42),
 	)
 
-	handler.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestSlogHandler_WithAttrs(t *testing.T) {
@@ -125,8 +133,9 @@ func TestSlogHandler_WithAttrs(t *testing.T) {
 		slog.String("action", "test"),
 	)
 
-	handler.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestSlogHandler_WithGroup(t *testing.T) {
@@ -154,8 +163,9 @@ func TestSlogHandler_WithGroup(t *testing.T) {
 		slog.Int("status", 200),
 	)
 
-	handler.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestSlogHandler_Groups(t *testing.T) {
@@ -184,8 +194,9 @@ func TestSlogHandler_Groups(t *testing.T) {
 		),
 	)
 
-	handler.Flush()
-	time.Sleep(100 * time.Millisecond)
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestConvertSlogLevel(t *testing.T) {
@@ -209,3 +220,331 @@ func TestConvertSlogLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestSlogHandler_ConcurrentWithAttrs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	handler, err := NewSlogHandler(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer handler.Shutdown()
+
+	base := slog.New(handler).With(slog.String("service", "api"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			base.With(slog.Int("worker", id)).WithGroup("req").Info("derived", slog.Int("step", id))
+			base.Info("base")
+		}(i)
+	}
+	wg.Wait()
+
+	if len(handler.attrs) != 0 {
+		t.Errorf("base handler attrs were modified: %v", handler.attrs)
+	}
+}
+
+func TestSlogHandler_RecordTime(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	handler, err := NewSlogHandler(core.Config{Transport: recorder})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer handler.Shutdown()
+
+	record := slog.NewRecord(time.Date(2024, 3, 1, 12, 30, 0, 5, time.UTC), slog.LevelInfo, "replayed", 0)
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Timestamp != "2024-03-01T12:30:00.000000005Z" {
+		t.Errorf("Timestamp = %s, want the record time", entries[0].Timestamp)
+	}
+}
+
+type userValuer struct {
+	id   int
+	name string
+}
+
+func (u userValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", u.id), slog.String("name", u.name))
+}
+
+type secretValuer string
+
+func (secretValuer) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED]")
+}
+
+func TestSlogHandler_ValueResolution(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	handler, err := NewSlogHandler(core.Config{Transport: recorder, DisableMetadata: true})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer handler.Shutdown()
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	slog.New(handler).WithGroup("req").Info("resolved",
+		slog.Any("user", userValuer{id: 7, name: "ann"}),
+		slog.Any("token", secretValuer("s3cret")),
+		slog.Time("at", at),
+		slog.Duration("took", 1500*time.Millisecond),
+		slog.Group("db", slog.Group("pool", slog.Int("size", 4))),
+		slog.Group("", slog.String("inlined", "yes")),
+		slog.Group("empty"),
+	)
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	fields := entries[0].Fields
+	expected := map[string]any{
+		"req.user.id":      int64(7),
+		"req.user.name":    "ann",
+		"req.token":        "[REDACTED]",
+		"req.at":           "2024-03-01T12:30:00Z",
+		"req.took":         "1.5s",
+		"req.db.pool.size": int64(4),
+		"req.inlined":      "yes",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, fields[key], value)
+		}
+	}
+	if _, ok := fields["req.empty"]; ok {
+		t.Error("Expected empty group to be omitted")
+	}
+	if len(fields) != len(expected) {
+		t.Errorf("Fields = %v, want only %d keys", fields, len(expected))
+	}
+}
+
+func checkCaller(t *testing.T, entry core.LogEntry, function string, line int) {
+	t.Helper()
+
+	if got, _ := entry.Fields[core.CallerFunctionField].(string); !strings.HasSuffix(got, "."+function) {
+		t.Errorf("%s = %v, want %s", core.CallerFunctionField, entry.Fields[core.CallerFunctionField], function)
+	}
+	if got, _ := entry.Fields[core.CallerFileField].(string); !strings.HasSuffix(got, "_test.go") {
+		t.Errorf("%s = %v, want the test file", core.CallerFileField, entry.Fields[core.CallerFileField])
+	}
+	if got, _ := entry.Fields[core.CallerLineField].(int); got != line {
+		t.Errorf("%s = %v, want %d", core.CallerLineField, entry.Fields[core.CallerLineField], line)
+	}
+}
+
+func TestSlogHandler_IncludeCaller(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	handler, err := NewSlogHandler(core.Config{Transport: recorder, IncludeCaller: true})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer handler.Shutdown()
+
+	_, _, line, _ := runtime.Caller(0)
+	slog.New(handler).With("k", "v").Info("with caller")
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	checkCaller(t, entries[0], "TestSlogHandler_IncludeCaller", line+1)
+}
+
+func TestSlogHandler_WithOptions(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	base, err := NewSlogHandler(core.Config{Transport: recorder})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer base.Shutdown()
+
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	handler := base.WithOptions(&slog.HandlerOptions{
+		Level:     level,
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case a.Key == "password":
+				return slog.String("password", "[redacted]")
+			case a.Key == "debug_only":
+				return slog.Attr{}
+			case a.Key == "id" && len(groups) == 1 && groups[0] == "user":
+				return slog.String("user_id", a.Value.String())
+			case a.Key == slog.LevelKey && len(groups) == 0:
+				return slog.String(slog.LevelKey, "WARNING")
+			case a.Key == slog.MessageKey:
+				return slog.String(slog.MessageKey, "[app] "+a.Value.String())
+			}
+			return a
+		},
+	})
+
+	if !handler.Enabled(context.Background(), slog.LevelDebug) || base.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("Enabled() should follow HandlerOptions.Level")
+	}
+
+	_, file, line, _ := runtime.Caller(0)
+	slog.New(handler).Debug("login",
+		slog.String("password", "hunter2"),
+		slog.Bool("debug_only", true),
+		slog.Group("user", slog.Int("id", 7)),
+	)
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != string(core.WARNING) || entry.Message != "[app] login" {
+		t.Errorf("Level = %s, Message = %q", entry.Level, entry.Message)
+	}
+	if entry.Fields["password"] != "[redacted]" || entry.Fields["user.user_id"] != "7" {
+		t.Errorf("Fields = %v", entry.Fields)
+	}
+	if _, ok := entry.Fields["debug_only"]; ok {
+		t.Error("Dropped attribute was sent")
+	}
+	if source := fmt.Sprintf("%s:%d", file, line+1); entry.Fields[slog.SourceKey] != source {
+		t.Errorf("%s = %v, want %s", slog.SourceKey, entry.Fields[slog.SourceKey], source)
+	}
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSlogHandler_UnauthorizedFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("invalid API key"))
+	}))
+	defer server.Close()
+
+	t.Run("console", func(t *testing.T) {
+		console := &lockedBuffer{}
+		handler, err := NewSlogHandler(core.Config{
+			ProjectID:     "12345678-1234-1234-1234-123456789012",
+			Host:          server.URL,
+			ConsoleWriter: console,
+			Silent:        true,
+		})
+		if err != nil {
+			t.Fatalf("NewSlogHandler() error = %v", err)
+		}
+		defer handler.Shutdown()
+
+		logger := slog.New(handler)
+
+		logger.Info("rejected by server")
+		handler.FlushSync(context.Background())
+		logger.Info("sent while paused")
+		handler.FlushSync(context.Background())
+
+		output := console.String()
+		for _, message := range []string{"rejected by server", "sent while paused"} {
+			if !strings.Contains(output, message) {
+				t.Errorf("console output %q does not contain %q", output, message)
+			}
+		}
+		if stats := handler.Stats(); stats.DroppedLogs != 2 {
+			t.Errorf("DroppedLogs = %d, want 2", stats.DroppedLogs)
+		}
+	})
+
+	t.Run("rejected logs file", func(t *testing.T) {
+		console := &lockedBuffer{}
+		dumpFile := filepath.Join(t.TempDir(), "rejected.jsonl")
+		handler, err := NewSlogHandler(core.Config{
+			ProjectID:        "12345678-1234-1234-1234-123456789012",
+			Host:             server.URL,
+			ConsoleWriter:    console,
+			RejectedLogsFile: dumpFile,
+			Silent:           true,
+		})
+		if err != nil {
+			t.Fatalf("NewSlogHandler() error = %v", err)
+		}
+		defer handler.Shutdown()
+
+		logger := slog.New(handler)
+
+		logger.Info("rejected by server")
+		handler.FlushSync(context.Background())
+		logger.Info("sent while paused")
+		handler.FlushSync(context.Background())
+
+		data, err := os.ReadFile(dumpFile)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 entries in %s, got %d", dumpFile, len(lines))
+		}
+		var held core.RejectedLogEntry
+		if err := json.Unmarshal([]byte(lines[1]), &held); err != nil {
+			t.Fatalf("Invalid rejected entry: %v", err)
+		}
+		if held.Entry.Message != "sent while paused" || held.Reason != core.ErrUnauthorized.Error() {
+			t.Errorf("Unexpected rejected entry: %+v", held)
+		}
+		if console.String() != "" {
+			t.Errorf("Expected no console output with RejectedLogsFile set, got %q", console.String())
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+// stdLogTimestamp matches the date/time prefix written by log.LstdFlags,
+// optionally with microseconds. It becomes the entry timestamp instead of
+// part of the message.
+var stdLogTimestamp = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)? `)
+
+// Fractional seconds written by log.Lmicroseconds are accepted when parsing
+// even though the layout does not mention them.
+const stdLogTimeLayout = "2006/01/02 15:04:05"
+
+var stdLogLevelPrefixes = []struct {
+	prefix string
+	level  core.LogLevel
+}{
+	{"DEBUG", core.DEBUG},
+	{"TRACE", core.DEBUG},
+	{"INFO", core.INFO},
+	{"WARNING", core.WARNING},
+	{"WARN", core.WARNING},
+	{"ERROR", core.ERROR},
+	{"CRITICAL", core.CRITICAL},
+	{"FATAL", core.CRITICAL},
+	{"PANIC", core.CRITICAL},
+}
+
+// StdLogWriter is an io.Writer that turns standard library log output into
+// LogBull entries, one per line. Lines starting with a level prefix such as
+// "ERROR:" or "[WARN]" are logged at that level, others at INFO.
+type StdLogWriter struct {
+	logger *core.LogBullLogger
+	shared bool
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func NewStdLogWriter(config core.Config) (*StdLogWriter, error) {
+	logger, err := core.NewLogger(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StdLogWriter{logger: logger}, nil
+}
+
+// NewStdLogWriterFromLogger creates a writer that logs through logger, so it
+// shares its sender, context and console output.
+func NewStdLogWriterFromLogger(logger *core.LogBullLogger) *StdLogWriter {
+	return &StdLogWriter{logger: logger, shared: true}
+}
+
+// Logger returns a *log.Logger writing to w. Flags default to 0 because
+// LogBull timestamps every entry itself.
+func (w *StdLogWriter) Logger(prefix string) *log.Logger {
+	return log.New(w, prefix, 0)
+}
+
+func (w *StdLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.partial = append(w.partial, p...)
+
+	var lines []string
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	if len(w.partial) == 0 {
+		w.partial = nil
+	}
+	w.mu.Unlock()
+
+	for _, line := range lines {
+		w.writeLine(line)
+	}
+
+	return len(p), nil
+}
+
+func (w *StdLogWriter) writeLine(line string) {
+	var timestamp time.Time
+	if prefix := stdLogTimestamp.FindString(line); prefix != "" {
+		// The standard logger writes local time unless log.LUTC is set
+		timestamp, _ = time.ParseInLocation(stdLogTimeLayout, strings.TrimSpace(prefix), time.Local)
+		line = line[len(prefix):]
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	level, message := parseStdLogLevel(line)
+	w.logger.LogAt(timestamp, level, message, nil)
+}
+
+// Flush logs any buffered partial line and sends queued logs.
+func (w *StdLogWriter) Flush() {
+	w.writePartial()
+	w.logger.Flush()
+}
+
+func (w *StdLogWriter) FlushSync(ctx context.Context) error {
+	w.writePartial()
+	return w.logger.FlushSync(ctx)
+}
+
+func (w *StdLogWriter) Stats() core.Stats {
+	return w.logger.Stats()
+}
+
+func (w *StdLogWriter) Shutdown() {
+	w.Flush()
+	if !w.shared {
+		w.logger.Shutdown()
+	}
+}
+
+func (w *StdLogWriter) writePartial() {
+	w.mu.Lock()
+	partial := string(w.partial)
+	w.partial = nil
+	w.mu.Unlock()
+
+	w.writeLine(partial)
+}
+
+func parseStdLogLevel(line string) (core.LogLevel, string) {
+	for _, candidate := range stdLogLevelPrefixes {
+		if len(line) < len(candidate.prefix)+1 {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := len(candidate.prefix) + 1
+			if len(line) > end && strings.EqualFold(line[1:end], candidate.prefix) && line[end] == ']' {
+				return candidate.level, strings.TrimSpace(line[end+1:])
+			}
+			continue
+		}
+
+		if strings.EqualFold(line[:len(candidate.prefix)], candidate.prefix) && line[len(candidate.prefix)] == ':' {
+			return candidate.level, strings.TrimSpace(line[len(candidate.prefix)+1:])
+		}
+	}
+
+	return core.INFO, line
+}
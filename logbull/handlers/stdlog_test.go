@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func TestNewStdLogWriter(t *testing.T) {
+	t.Run("valid configuration", func(t *testing.T) {
+		writer, err := NewStdLogWriter(core.Config{
+			ProjectID: "12345678-1234-1234-1234-123456789012",
+			Host:      "http://localhost:4005",
+		})
+		if err != nil {
+			t.Errorf("NewStdLogWriter() error = %v", err)
+		}
+		if writer != nil {
+			defer writer.Shutdown()
+		}
+	})
+
+	t.Run("invalid project ID", func(t *testing.T) {
+		_, err := NewStdLogWriter(core.Config{
+			ProjectID: "invalid",
+			Host:      "http://localhost:4005",
+		})
+		if err == nil {
+			t.Error("NewStdLogWriter() expected error for invalid project ID")
+		}
+	})
+}
+
+func TestStdLogWriter_Write(t *testing.T) {
+	var mu sync.Mutex
+	var received []core.LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch core.LogBatch
+		json.Unmarshal(body, &batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	writer, err := NewStdLogWriter(core.Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		LogLevel:      core.DEBUG,
+		ConsoleFormat: core.ConsoleDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewStdLogWriter() error = %v", err)
+	}
+	defer writer.Shutdown()
+
+	stdLogger := log.New(writer, "", log.LstdFlags)
+	stdLogger.Print("server started")
+	stdLogger.Print("ERROR: database unavailable")
+	stdLogger.Print("[warn] slow query")
+
+	// Partial writes are joined until a newline arrives
+	writer.Write([]byte("DEBUG: cache "))
+	writer.Write([]byte("miss\n"))
+
+	if err := writer.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := []struct {
+		level   string
+		message string
+	}{
+		{"INFO", "server started"},
+		{"ERROR", "database unavailable"},
+		{"WARNING", "slow query"},
+		{"DEBUG", "cache miss"},
+	}
+
+	if len(received) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(received))
+	}
+	for i, want := range expected {
+		if received[i].Level != want.level || received[i].Message != want.message {
+			t.Errorf("Entry %d = %s %q, want %s %q",
+				i, received[i].Level, received[i].Message, want.level, want.message)
+		}
+	}
+}
+
+func TestParseStdLogLevel(t *testing.T) {
+	tests := []struct {
+		line    string
+		level   core.LogLevel
+		message string
+	}{
+		{"plain message", core.INFO, "plain message"},
+		{"ERROR: failed", core.ERROR, "failed"},
+		{"error: failed", core.ERROR, "failed"},
+		{"WARN: careful", core.WARNING, "careful"},
+		{"WARNING: careful", core.WARNING, "careful"},
+		{"[FATAL] down", core.CRITICAL, "down"},
+		{"[debug] details", core.DEBUG, "details"},
+		{"INFORMATION: not a prefix", core.INFO, "INFORMATION: not a prefix"},
+		{"ERRORS happen", core.INFO, "ERRORS happen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			level, message := parseStdLogLevel(tt.line)
+			if level != tt.level || message != tt.message {
+				t.Errorf("parseStdLogLevel() = %s %q, want %s %q", level, message, tt.level, tt.message)
+			}
+		})
+	}
+}
+
+func TestStdLogWriter_Timestamp(t *testing.T) {
+	var mu sync.Mutex
+	var received []core.LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch core.LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	writer, err := NewStdLogWriter(core.Config{
+		ProjectID:     "12345678-1234-1234-1234-123456789012",
+		Host:          server.URL,
+		ConsoleFormat: core.ConsoleDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewStdLogWriter() error = %v", err)
+	}
+	defer writer.Shutdown()
+
+	writer.Write([]byte("2023/11/05 08:00:00.250000 ERROR: replayed\n"))
+
+	if err := writer.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(received))
+	}
+
+	want := time.Date(2023, 11, 5, 8, 0, 0, 250000000, time.Local).UTC().Format("2006-01-02T15:04:05.000000000Z")
+	if received[0].Timestamp != want {
+		t.Errorf("Timestamp = %s, want %s", received[0].Timestamp, want)
+	}
+	if received[0].Message != "replayed" || received[0].Level != "ERROR" {
+		t.Errorf("Entry = %s %q", received[0].Level, received[0].Message)
+	}
+}
+
+func TestStdLogWriter_IncludeCaller(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	writer, err := NewStdLogWriter(core.Config{
+		Transport:     recorder,
+		ConsoleFormat: core.ConsoleDisabled,
+		IncludeCaller: true,
+	})
+	if err != nil {
+		t.Fatalf("NewStdLogWriter() error = %v", err)
+	}
+	defer writer.Shutdown()
+
+	_, _, line, _ := runtime.Caller(0)
+	writer.Logger("").Println("with caller")
+
+	if err := writer.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	checkCaller(t, entries[0], "TestStdLogWriter_IncludeCaller", line+1)
+}
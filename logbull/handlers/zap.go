@@ -1,25 +1,42 @@
 package handlers
 
 import (
+	"context"
 	"strings"
 
 	"go.uber.org/zap/zapcore"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/internal/diag"
 	"github.com/logbull/logbull-go/logbull/internal/formatting"
 	"github.com/logbull/logbull-go/logbull/internal/validation"
 )
 
 type ZapCore struct {
-	config   *core.Config
-	sender   *core.Sender
-	fields   []zapcore.Field
-	minLevel zapcore.Level
+	config        *core.Config
+	sender        *core.Sender
+	fields        []zapcore.Field
+	enabler       zapcore.LevelEnabler
+	encoderConfig zapcore.EncoderConfig
+	shared        bool
+}
+
+// defaultZapEncoderConfig uses the same keys as zap's production encoder, so
+// caller and stack fields match what a console core next to it prints.
+var defaultZapEncoderConfig = zapcore.EncoderConfig{
+	NameKey:       "logger",
+	CallerKey:     "caller",
+	StacktraceKey: "stacktrace",
+	EncodeCaller:  zapcore.ShortCallerEncoder,
 }
 
 func NewZapCore(config core.Config) (*ZapCore, error) {
 	config.ProjectID = strings.TrimSpace(config.ProjectID)
 	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" && len(config.Hosts) > 0 {
+		config.Host = strings.TrimSpace(config.Hosts[0])
+	}
 	config.APIKey = strings.TrimSpace(config.APIKey)
 
 	if config.LogLevel == "" {
@@ -27,25 +44,27 @@ func NewZapCore(config core.Config) (*ZapCore, error) {
 	}
 
 	// Check if credentials are provided
-	if config.ProjectID == "" || config.Host == "" {
+	if config.Transport == nil && (config.ProjectID == "" || config.Host == "") {
 		// No credentials: do nothing (Zap will print)
-		println(
-			"LogBull: No credentials provided for ZapCore. Handler is disabled. Logs will not be sent to LogBull server.",
-		)
+		diag.Notice(config.Silent, "No credentials provided for ZapCore. Handler is disabled. Logs will not be sent to LogBull server.")
 		return &ZapCore{
-			config:   &config,
-			sender:   nil,
-			fields:   []zapcore.Field{},
-			minLevel: convertLogLevelToZap(config.LogLevel),
+			config:        &config,
+			sender:        nil,
+			fields:        []zapcore.Field{},
+			enabler:       convertLogLevelToZap(config.LogLevel),
+			encoderConfig: defaultZapEncoderConfig,
 		}, nil
 	}
 
-	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
-		return nil, err
-	}
+	// A custom transport does not talk to a LogBull server
+	if config.Transport == nil {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
 
-	if err := validation.ValidateHostURL(config.Host); err != nil {
-		return nil, err
+		if err := validation.ValidateHostURL(config.Host); err != nil {
+			return nil, err
+		}
 	}
 
 	if config.APIKey != "" {
@@ -60,27 +79,77 @@ func NewZapCore(config core.Config) (*ZapCore, error) {
 	}
 
 	return &ZapCore{
-		config:   &config,
-		sender:   sender,
-		fields:   []zapcore.Field{},
-		minLevel: convertLogLevelToZap(config.LogLevel),
+		config:        &config,
+		sender:        sender,
+		fields:        []zapcore.Field{},
+		enabler:       convertLogLevelToZap(config.LogLevel),
+		encoderConfig: defaultZapEncoderConfig,
 	}, nil
 }
 
+// NewZapCoreFromLogger creates a core that sends through logger's sender
+// instead of starting its own, using the logger's configuration.
+func NewZapCoreFromLogger(logger *core.LogBullLogger) *ZapCore {
+	config, sender := fromLogger(logger)
+	return &ZapCore{
+		config:        config,
+		sender:        sender,
+		fields:        []zapcore.Field{},
+		enabler:       convertLogLevelToZap(config.LogLevel),
+		encoderConfig: defaultZapEncoderConfig,
+		shared:        true,
+	}
+}
+
+// NewZapCoreWithLevel creates a core filtered by enabler instead of
+// Config.LogLevel, e.g. a zap.AtomicLevel shared with other cores in a Tee.
+func NewZapCoreWithLevel(config core.Config, enabler zapcore.LevelEnabler) (*ZapCore, error) {
+	z, err := NewZapCore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	z.enabler = enabler
+	return z, nil
+}
+
+// WithEncoderConfig returns a core that names the logger, caller and
+// stacktrace fields after cfg and formats callers with cfg.EncodeCaller. An
+// empty key omits that field, like in zap's own encoders.
+func (z *ZapCore) WithEncoderConfig(cfg zapcore.EncoderConfig) *ZapCore {
+	clone := z.clone(z.fields)
+	clone.encoderConfig = cfg
+	return clone
+}
+
 func (z *ZapCore) Enabled(level zapcore.Level) bool {
-	return level >= z.minLevel
+	return z.enabler.Enabled(level)
+}
+
+// Level reports the minimum enabled level, so zapcore.LevelOf works on a Tee
+// containing this core.
+func (z *ZapCore) Level() zapcore.Level {
+	return zapcore.LevelOf(z.enabler)
 }
 
+// With returns a new core; the receiver is never modified, so both cores are
+// safe to share across goroutines.
 func (z *ZapCore) With(fields []zapcore.Field) zapcore.Core {
 	newFields := make([]zapcore.Field, len(z.fields)+len(fields))
 	copy(newFields, z.fields)
 	copy(newFields[len(z.fields):], fields)
 
+	return z.clone(newFields)
+}
+
+func (z *ZapCore) clone(fields []zapcore.Field) *ZapCore {
 	return &ZapCore{
-		config:   z.config,
-		sender:   z.sender,
-		fields:   newFields,
-		minLevel: z.minLevel,
+		config:        z.config,
+		sender:        z.sender,
+		fields:        fields,
+		enabler:       z.enabler,
+		encoderConfig: z.encoderConfig,
+		shared:        z.shared,
 	}
 }
 
@@ -96,18 +165,29 @@ func (z *ZapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
 	if z.sender == nil {
 		return nil
 	}
+	defer recoverHandlerPanic(z.config, "write")
 
 	allFields := make([]zapcore.Field, len(z.fields)+len(fields))
 	copy(allFields, z.fields)
 	copy(allFields[len(z.fields):], fields)
 
 	extractedFields := z.extractFields(allFields)
+	z.addEntryFields(extractedFields, entry)
+
+	if z.config.IncludeCaller {
+		// zap.AddCaller fills entry.Caller; without it, walk the stack
+		if entry.Caller.Defined {
+			callsite.AddFields(extractedFields, entry.Caller.Function, entry.Caller.File, entry.Caller.Line)
+		} else if frame, ok := callsite.Capture(0); ok {
+			callsite.AddFields(extractedFields, frame.Function, frame.File, frame.Line)
+		}
+	}
 
 	logEntry := core.LogEntry{
 		Level:     convertZapLevel(entry.Level).String(),
 		Message:   formatting.FormatMessage(entry.Message),
-		Timestamp: core.GenerateUniqueTimestamp(),
-		Fields:    formatting.EnsureFields(extractedFields),
+		Timestamp: core.FormatTimestamp(entry.Time),
+		Fields:    formatting.EnsureFields(extractedFields, z.config.FieldKeys),
 	}
 
 	z.sender.AddLog(logEntry)
@@ -121,25 +201,87 @@ func (z *ZapCore) Sync() error {
 	return nil
 }
 
+func (z *ZapCore) FlushSync(ctx context.Context) error {
+	if z.sender == nil {
+		return nil
+	}
+	return z.sender.FlushSync(ctx)
+}
+
+func (z *ZapCore) Stats() core.Stats {
+	if z.sender == nil {
+		return core.Stats{}
+	}
+	return z.sender.Stats()
+}
+
 func (z *ZapCore) Shutdown() {
-	if z.sender != nil {
-		z.sender.Shutdown()
+	if z.sender == nil {
+		return
+	}
+	if z.shared {
+		z.sender.Flush()
+		return
 	}
+	z.sender.Shutdown()
 }
 
+// extractFields flattens zap.Namespace into dotted keys, like slog groups:
+// fields after zap.Namespace("http") are sent as "http.method". Object and
+// array marshalers keep the nested structure they encode.
 func (z *ZapCore) extractFields(fields []zapcore.Field) map[string]any {
-	result := make(map[string]any)
+	result := make(map[string]any, len(fields))
 	enc := zapcore.NewMapObjectEncoder()
+	prefix := ""
 
 	for _, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			prefix += field.Key + "."
+			continue
+		}
+
 		field.AddTo(enc)
+		for key, value := range enc.Fields {
+			result[prefix+key] = value
+		}
+		clear(enc.Fields)
+	}
+
+	return result
+}
+
+func (z *ZapCore) addEntryFields(fields map[string]any, entry zapcore.Entry) {
+	cfg := z.encoderConfig
+
+	if cfg.NameKey != "" && entry.LoggerName != "" {
+		fields[cfg.NameKey] = entry.LoggerName
 	}
 
-	for key, value := range enc.Fields {
-		result[key] = value
+	if cfg.CallerKey != "" && entry.Caller.Defined {
+		fields[cfg.CallerKey] = encodeZapCaller(cfg.EncodeCaller, entry.Caller)
 	}
 
-	return result
+	if cfg.StacktraceKey != "" && entry.Stack != "" {
+		fields[cfg.StacktraceKey] = entry.Stack
+	}
+}
+
+// encodeZapCaller runs a zap CallerEncoder and returns what it appended.
+func encodeZapCaller(encode zapcore.CallerEncoder, caller zapcore.EntryCaller) any {
+	if encode == nil {
+		return caller.TrimmedPath()
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	_ = enc.AddArray("caller", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		encode(caller, arr)
+		return nil
+	}))
+
+	if values, ok := enc.Fields["caller"].([]any); ok && len(values) == 1 {
+		return values[0]
+	}
+	return caller.TrimmedPath()
 }
 
 func convertZapLevel(level zapcore.Level) core.LogLevel {
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
 )
 
 func TestNewZapCore(t *testing.T) {
@@ -95,8 +100,9 @@ func TestZapCore_Write(t *testing.T) {
 		zap.Int("count", 42),
 	)
 
-	zapCore.Sync()
-	time.Sleep(100 * time.Millisecond)
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestZapCore_With(t *testing.T) {
@@ -125,8 +131,9 @@ func TestZapCore_With(t *testing.T) {
 		zap.String("action", "test"),
 	)
 
-	zapCore.Sync()
-	time.Sleep(100 * time.Millisecond)
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestZapCore_Check(t *testing.T) {
@@ -180,8 +187,6 @@ func TestZapCore_Sync(t *testing.T) {
 	if err != nil {
 		t.Errorf("Sync() error = %v", err)
 	}
-
-	time.Sleep(100 * time.Millisecond)
 }
 
 func TestZapCore_AllLevels(t *testing.T) {
@@ -208,8 +213,9 @@ func TestZapCore_AllLevels(t *testing.T) {
 	logger.Warn("warning message")
 	logger.Error("error message")
 
-	zapCore.Sync()
-	time.Sleep(100 * time.Millisecond)
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
 }
 
 func TestConvertZapLevel(t *testing.T) {
@@ -284,6 +290,295 @@ func TestZapCore_ComplexFields(t *testing.T) {
 		zap.Strings("array", []string{"a", "b", "c"}),
 	)
 
-	zapCore.Sync()
-	time.Sleep(100 * time.Millisecond)
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+}
+
+func TestZapCore_ConcurrentWith(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: 1})
+	}))
+	defer server.Close()
+
+	zapCore, err := NewZapCore(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	base := zap.New(zapCore).With(zap.String("service", "api"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			base.With(zap.Int("worker", id)).Info("derived", zap.Int("step", id))
+			base.Info("base")
+		}(i)
+	}
+	wg.Wait()
+
+	if len(zapCore.fields) != 0 {
+		t.Errorf("base core fields were modified: %v", zapCore.fields)
+	}
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time                         { return c.now }
+func (c fixedClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+func TestZapCore_EntryMetadata(t *testing.T) {
+	var mu sync.Mutex
+	var received []core.LogEntry
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch core.LogBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		received = append(received, batch.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	defer server.Close()
+
+	level := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	zapCore, err := NewZapCoreWithLevel(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+	}, level)
+	if err != nil {
+		t.Fatalf("NewZapCoreWithLevel() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	eventTime := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	logger := zap.New(
+		zapcore.NewTee(zapCore),
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.WithClock(fixedClock{now: eventTime}),
+	).Named("billing")
+
+	logger.Info("filtered by atomic level")
+	logger.Error("charge failed")
+
+	if zapcore.LevelOf(zapcore.NewTee(zapCore)) != zapcore.WarnLevel {
+		t.Errorf("LevelOf() = %v, want warn", zapcore.LevelOf(zapCore))
+	}
+
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(received))
+	}
+
+	entry := received[0]
+	if entry.Timestamp != "2024-03-01T12:30:00.123456789Z" {
+		t.Errorf("Timestamp = %s, want the zap entry time", entry.Timestamp)
+	}
+	if entry.Fields["logger"] != "billing" {
+		t.Errorf("logger = %v, want billing", entry.Fields["logger"])
+	}
+	if caller, _ := entry.Fields["caller"].(string); !strings.HasPrefix(caller, "handlers/zap_test.go:") {
+		t.Errorf("caller = %v, want short caller in zap_test.go", entry.Fields["caller"])
+	}
+	if stack, _ := entry.Fields["stacktrace"].(string); !strings.Contains(stack, "TestZapCore_EntryMetadata") {
+		t.Errorf("stacktrace = %v", entry.Fields["stacktrace"])
+	}
+}
+
+func TestZapCore_WithEncoderConfig(t *testing.T) {
+	zapCore, err := NewZapCore(core.Config{})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+
+	custom := zapCore.WithEncoderConfig(zapcore.EncoderConfig{
+		CallerKey:    "source",
+		EncodeCaller: zapcore.FullCallerEncoder,
+	})
+
+	entry := zapcore.Entry{
+		LoggerName: "billing",
+		Caller:     zapcore.NewEntryCaller(0, "/src/app/billing/charge.go", 42, true),
+		Stack:      "goroutine 1",
+	}
+
+	fields := map[string]any{}
+	custom.addEntryFields(fields, entry)
+
+	if fields["source"] != "/src/app/billing/charge.go:42" {
+		t.Errorf("source = %v, want full caller path", fields["source"])
+	}
+	if len(fields) != 1 {
+		t.Errorf("Expected empty keys to be omitted, got %v", fields)
+	}
+
+	defaults := map[string]any{}
+	zapCore.addEntryFields(defaults, entry)
+	if defaults["caller"] != "billing/charge.go:42" {
+		t.Errorf("caller = %v, want short caller", defaults["caller"])
+	}
+}
+
+func TestZapCore_IncludeCaller(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	zapCore, err := NewZapCore(core.Config{Transport: recorder, IncludeCaller: true})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	_, _, line, _ := runtime.Caller(0)
+	zap.New(zapCore).Info("without AddCaller")
+	zap.New(zapCore, zap.AddCaller()).Sugar().Infow("with AddCaller")
+
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	checkCaller(t, entries[0], "TestZapCore_IncludeCaller", line+1)
+	checkCaller(t, entries[1], "TestZapCore_IncludeCaller", line+2)
+}
+
+func TestZapCore_DefaultFields(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	zapCore, err := NewZapCore(core.Config{
+		Transport:     recorder,
+		DefaultFields: map[string]any{"region": "eu-west-1", "cluster": "blue"},
+	})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	zap.New(zapCore).Info("deployed", zap.String("cluster", "green"))
+
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if got := entries[0].Fields["region"]; got != "eu-west-1" {
+		t.Errorf("Fields[region] = %v, want eu-west-1", got)
+	}
+	if got := entries[0].Fields["cluster"]; got != "green" {
+		t.Errorf("Fields[cluster] = %v, want the entry's own value", got)
+	}
+}
+
+type zapItem struct {
+	ID    string
+	Price float64
+}
+
+func (i zapItem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", i.ID)
+	enc.AddFloat64("price", i.Price)
+	return nil
+}
+
+type zapItems []zapItem
+
+func (items zapItems) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, item := range items {
+		if err := enc.AppendObject(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestZapCore_NamespaceAndMarshalers(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	zapCore, err := NewZapCore(core.Config{Transport: recorder})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	logger := zap.New(zapCore).With(zap.String("service", "shop"), zap.Namespace("http"))
+	logger.Info("order placed",
+		zap.String("method", "POST"),
+		zap.Namespace("order"),
+		zap.Object("item", zapItem{ID: "a", Price: 1.5}),
+		zap.Array("items", zapItems{{ID: "a", Price: 1.5}, {ID: "b", Price: 2}}),
+	)
+
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].Fields
+
+	if fields["service"] != "shop" || fields["http.method"] != "POST" {
+		t.Errorf("Fields = %v, want service and http.method", fields)
+	}
+	if _, ok := fields["http"]; ok {
+		t.Error("Namespace should be flattened, not nested")
+	}
+	item, _ := fields["http.order.item"].(map[string]any)
+	if item["id"] != "a" || item["price"] != 1.5 {
+		t.Errorf("http.order.item = %v", fields["http.order.item"])
+	}
+	items, _ := fields["http.order.items"].([]any)
+	if len(items) != 2 {
+		t.Fatalf("http.order.items = %v, want 2 objects", fields["http.order.items"])
+	}
+	if second, _ := items[1].(map[string]any); second["id"] != "b" || second["price"] != float64(2) {
+		t.Errorf("http.order.items[1] = %v", items[1])
+	}
+}
+
+func TestZapCore_FieldKeys(t *testing.T) {
+	recorder := logbulltest.NewRecorder()
+
+	zapCore, err := NewZapCore(core.Config{Transport: recorder, FieldKeys: core.FieldKeyPolicy{SnakeCase: true}})
+	if err != nil {
+		t.Fatalf("NewZapCore() error = %v", err)
+	}
+	defer zapCore.Shutdown()
+
+	zap.New(zapCore).With(zap.String("requestId", "r-1")).Info("signed in", zap.String("userID", "u-1"))
+
+	if err := zapCore.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if fields := entries[0].Fields; fields["request_id"] != "r-1" || fields["user_id"] != "u-1" {
+		t.Errorf("Fields = %v, want snake_case keys", fields)
+	}
 }
@@ -0,0 +1,615 @@
+// Package integrationtest runs a fake LogBull server for end-to-end tests of
+// log delivery, including the failures a real deployment sees: rejected
+// entries, throttling, server errors and revoked credentials.
+//
+//	server := integrationtest.NewServer(t, integrationtest.Config{})
+//	logger, _ := logbull.NewLogger(server.LoggerConfig())
+//	server.FailNext(2, http.StatusServiceUnavailable)
+//	checkout(logger)
+//	logs, err := server.WaitForLogs(ctx, 1)
+//
+// Unlike logbulltest.Recorder, entries go through the real sender: encoding,
+// batching, HTTP, retries and failover.
+package integrationtest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+// DefaultProjectID is the project served when Config.ProjectID is empty.
+const DefaultProjectID = "12345678-1234-1234-1234-123456789012"
+
+// Mode is how the server answers log batches.
+type Mode int
+
+const (
+	// Accept stores every entry, unless RejectWhere rejects it.
+	Accept Mode = iota
+	// Reject answers 200 with every entry rejected, like entries failing
+	// server-side validation.
+	Reject
+	// Throttle answers 429 Too Many Requests with a Retry-After header.
+	Throttle
+	// Fail answers 500 Internal Server Error.
+	Fail
+	// Unauthorized answers 401, like a revoked API key.
+	Unauthorized
+)
+
+type Config struct {
+	// ProjectID is the only project logs are accepted for (default:
+	// DefaultProjectID).
+	ProjectID string
+	// APIKey, when set, is required in the X-API-Key header.
+	APIKey string
+	// Capabilities, when set, are served by the version endpoint used by
+	// Config.NegotiateCapabilities. Without them the server answers 404, like
+	// releases predating the endpoint.
+	Capabilities *core.ServerCapabilities
+	// DisableTail answers 404 on the live-tail endpoint used by logbulltail,
+	// like servers with it turned off.
+	DisableTail bool
+}
+
+// Batch is one accepted batch request.
+type Batch struct {
+	// ID is the X-Batch-ID header.
+	ID          string
+	ContentType string
+	Gzipped     bool
+	// Columnar is set for core.ColumnarBatch bodies.
+	Columnar bool
+	Logs     []core.LogEntry
+}
+
+// wireBatch decodes both a core.LogBatch and a core.ColumnarBatch.
+type wireBatch struct {
+	Format string          `json:"format"`
+	Logs   []core.LogEntry `json:"logs"`
+	Fields map[string]any  `json:"fields"`
+
+	Levels     []string         `json:"levels"`
+	Messages   []string         `json:"messages"`
+	Timestamps []string         `json:"timestamps"`
+	Columns    map[string][]any `json:"columns"`
+}
+
+// logs returns the entries of the batch with the batch-level fields of
+// Config.CompactBatchFields and columnar batches merged in.
+func (b wireBatch) logs() []core.LogEntry {
+	if b.Format == string(core.BatchFormatColumnar) {
+		return core.ColumnarBatch{
+			Levels:     b.Levels,
+			Messages:   b.Messages,
+			Timestamps: b.Timestamps,
+			Columns:    b.Columns,
+			Fields:     b.Fields,
+		}.Logs()
+	}
+
+	if len(b.Fields) == 0 {
+		return b.Logs
+	}
+	for i, entry := range b.Logs {
+		fields := make(map[string]any, len(b.Fields)+len(entry.Fields))
+		for key, value := range b.Fields {
+			fields[key] = value
+		}
+		for key, value := range entry.Fields {
+			fields[key] = value
+		}
+		b.Logs[i].Fields = fields
+	}
+	return b.Logs
+}
+
+type Server struct {
+	// URL is the server's base URL, for Config.Host.
+	URL string
+
+	config Config
+	server *httptest.Server
+
+	mu          sync.Mutex
+	mode        Mode
+	failures    []int
+	latency     time.Duration
+	rejectWhere func(core.LogEntry) (string, bool)
+	batches     []Batch
+	logs        []core.LogEntry
+	requests    int
+	// changed is closed and replaced whenever logs are stored
+	changed chan struct{}
+	// closed ends live-tail streams when the test ends
+	closed chan struct{}
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB, config Config) *Server {
+	t.Helper()
+
+	if config.ProjectID == "" {
+		config.ProjectID = DefaultProjectID
+	}
+
+	s := &Server{config: config, changed: make(chan struct{}), closed: make(chan struct{})}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/logs/receiving/", s.handleReceiving)
+	mux.HandleFunc("/api/v1/logs/query/", s.handleQuery)
+	mux.HandleFunc("/api/v1/logs/tail/", s.handleTail)
+	mux.HandleFunc("/api/v1/system/version", s.handleVersion)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.server.Close)
+	// Runs first, so that Close does not wait for open streams
+	t.Cleanup(func() { close(s.closed) })
+	return s
+}
+
+// LoggerConfig returns a Config sending to the server.
+func (s *Server) LoggerConfig() core.Config {
+	return core.Config{Host: s.URL, ProjectID: s.config.ProjectID, APIKey: s.config.APIKey}
+}
+
+// SetMode changes how later batches are answered.
+func (s *Server) SetMode(mode Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = mode
+}
+
+// FailNext answers the next n batch requests with status, whatever the mode,
+// e.g. to check that failover or the fallback writer take over.
+func (s *Server) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// SetLatency delays every answer by d, e.g. to fill the sender's queue.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// RejectWhere rejects, in Accept mode, the entries fn returns true for, with
+// the reason it returns. A nil fn accepts every entry again.
+func (s *Server) RejectWhere(fn func(entry core.LogEntry) (reason string, rejected bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectWhere = fn
+}
+
+// Logs returns the accepted entries in arrival order.
+func (s *Server) Logs() []core.LogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]core.LogEntry(nil), s.logs...)
+}
+
+// Batches returns the accepted batch requests in arrival order. Entries
+// streamed with ProtocolNDJSON are in Logs only.
+func (s *Server) Batches() []Batch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Batch(nil), s.batches...)
+}
+
+// Requests returns how many batch and stream requests arrived, including
+// failed ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Reset forgets the received logs and restores Accept mode.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = Accept
+	s.failures = nil
+	s.latency = 0
+	s.rejectWhere = nil
+	s.batches = nil
+	s.logs = nil
+	s.requests = 0
+}
+
+// WaitForLogs waits until at least n entries were accepted and returns them,
+// or returns ctx.Err() with the entries accepted so far.
+func (s *Server) WaitForLogs(ctx context.Context, n int) ([]core.LogEntry, error) {
+	for {
+		s.mu.Lock()
+		logs := append([]core.LogEntry(nil), s.logs...)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(logs) >= n {
+			return logs, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		}
+	}
+}
+
+// answer returns the status a batch request gets before its body is read,
+// or 0 to read it.
+func (s *Server) answer(w http.ResponseWriter, r *http.Request) int {
+	s.mu.Lock()
+	s.requests++
+	latency := s.latency
+	mode := s.mode
+	status := 0
+	if len(s.failures) > 0 {
+		status = s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+		}
+	}
+
+	if status != 0 {
+		return status
+	}
+	switch mode {
+	case Throttle:
+		w.Header().Set("Retry-After", "1")
+		return http.StatusTooManyRequests
+	case Fail:
+		return http.StatusInternalServerError
+	case Unauthorized:
+		return http.StatusUnauthorized
+	}
+	return 0
+}
+
+// authorize writes an error and returns false when r is for another project
+// or lacks the API key.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	if projectID != s.config.ProjectID {
+		http.Error(w, `{"error":"project not found"}`, http.StatusNotFound)
+		return false
+	}
+	if s.config.APIKey != "" && r.Header.Get("X-API-Key") != s.config.APIKey {
+		http.Error(w, `{"error":"invalid API key"}`, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleReceiving(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/logs/receiving/")
+	projectID, stream := strings.CutSuffix(path, "/stream")
+	if !s.authorize(w, r, projectID) {
+		return
+	}
+
+	if status := s.answer(w, r); status != 0 {
+		http.Error(w, fmt.Sprintf(`{"error":"injected status %d"}`, status), status)
+		return
+	}
+
+	if stream {
+		s.receiveStream(w, r)
+		return
+	}
+	s.receiveBatch(w, r)
+}
+
+func (s *Server) receiveBatch(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(r.Body)
+	gzipped := r.Header.Get("Content-Encoding") == "gzip"
+	if gzipped {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer reader.Close()
+		body = reader
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	var batch wireBatch
+	switch contentType {
+	case core.JSONEncoder.ContentType():
+		err = json.Unmarshal(data, &batch)
+	case core.MsgPackEncoder.ContentType():
+		decoder := msgpack.NewDecoder(bytes.NewReader(data))
+		decoder.SetCustomStructTag("json")
+		err = decoder.Decode(&batch)
+	case core.CBOREncoder.ContentType():
+		err = cbor.Unmarshal(data, &batch)
+	default:
+		http.Error(w, fmt.Sprintf(`{"error":"unsupported content type %q"}`, contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := s.store(batch.logs(), func(accepted []core.LogEntry) {
+		s.batches = append(s.batches, Batch{
+			ID:          r.Header.Get(core.BatchIDHeader),
+			ContentType: contentType,
+			Gzipped:     gzipped,
+			Columnar:    batch.Format == string(core.BatchFormatColumnar),
+			Logs:        accepted,
+		})
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// receiveStream stores NDJSON entries as they arrive. Streams cannot report
+// rejected entries, so those are dropped.
+func (s *Server) receiveStream(w http.ResponseWriter, r *http.Request) {
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry core.LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.store([]core.LogEntry{entry}, nil)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// store keeps the entries that are not rejected, calling record with them
+// under the lock, and returns the server's answer.
+func (s *Server) store(logs []core.LogEntry, record func(accepted []core.LogEntry)) core.LogBullResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var response core.LogBullResponse
+	var accepted []core.LogEntry
+	for i, entry := range logs {
+		reason, rejected := "", s.mode == Reject
+		if rejected {
+			reason = "rejected by integrationtest.Server"
+		} else if s.rejectWhere != nil {
+			reason, rejected = s.rejectWhere(entry)
+		}
+
+		if rejected {
+			response.Rejected++
+			response.Errors = append(response.Errors, core.RejectedLog{Index: i, Message: reason})
+			continue
+		}
+		accepted = append(accepted, entry)
+	}
+
+	response.Accepted = len(accepted)
+	if record != nil {
+		record(accepted)
+	}
+	if len(accepted) > 0 {
+		s.logs = append(s.logs, accepted...)
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	return response
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if s.config.Capabilities == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.config.Capabilities)
+}
+
+// queryRequest is the body of the search API used by logbullquery.
+type queryRequest struct {
+	Levels  []core.LogLevel `json:"levels"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Fields  map[string]any  `json:"fields"`
+	Message string          `json:"message"`
+	Limit   int             `json:"limit"`
+	Cursor  string          `json:"cursor"`
+}
+
+const defaultQueryLimit = 100
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(w, r, strings.TrimPrefix(r.URL.Path, "/api/v1/logs/query/")) {
+		return
+	}
+
+	var query queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if query.Cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(query.Cursor); err != nil {
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+
+	var matches []core.LogEntry
+	for _, entry := range s.Logs() {
+		if query.matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+
+	result := struct {
+		Logs       []core.LogEntry `json:"logs"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}{Logs: []core.LogEntry{}}
+	if offset < len(matches) {
+		end := min(offset+limit, len(matches))
+		result.Logs = matches[offset:end]
+		if end < len(matches) {
+			result.NextCursor = strconv.Itoa(end)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (q queryRequest) matches(entry core.LogEntry) bool {
+	if len(q.Levels) > 0 {
+		found := false
+		for _, level := range q.Levels {
+			found = found || string(level) == entry.Level
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.Message != "" && !strings.Contains(entry.Message, q.Message) {
+		return false
+	}
+
+	for key, want := range q.Fields {
+		if fmt.Sprint(entry.Fields[key]) != fmt.Sprint(want) {
+			return false
+		}
+	}
+
+	// Entries with timestamps in another format are not filtered by time
+	if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		if from, err := time.Parse(time.RFC3339Nano, q.From); err == nil && t.Before(from) {
+			return false
+		}
+		if to, err := time.Parse(time.RFC3339Nano, q.To); err == nil && !t.Before(to) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleTail streams the entries accepted after the subscription that match
+// its filter, one JSON entry per message.
+func (s *Server) handleTail(w http.ResponseWriter, r *http.Request) {
+	if s.config.DisableTail {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.authorize(w, r, strings.TrimPrefix(r.URL.Path, "/api/v1/logs/tail/")) {
+		return
+	}
+
+	// Stream the logs accepted from before the client's dial returns
+	s.mu.Lock()
+	sent := len(s.logs)
+	s.mu.Unlock()
+
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var filter queryRequest
+	if err := conn.ReadJSON(&filter); err != nil {
+		return
+	}
+
+	// The client sends nothing after subscribing; reading notices it leaving
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		s.mu.Lock()
+		// Reset may have forgotten logs
+		sent = min(sent, len(s.logs))
+		logs := append([]core.LogEntry(nil), s.logs[sent:]...)
+		sent = len(s.logs)
+		changed := s.changed
+		s.mu.Unlock()
+
+		for _, entry := range logs {
+			if !filter.matches(entry) {
+				continue
+			}
+			if err := conn.WriteJSON(entry); err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-gone:
+			return
+		case <-s.closed:
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	logbullquery "github.com/logbull/logbull-go/logbull/query"
+)
+
+func newLogger(t *testing.T, config core.Config) *core.LogBullLogger {
+	t.Helper()
+	config.ConsoleFormat = core.ConsoleDisabled
+	logger, err := core.NewLogger(config)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	t.Cleanup(logger.Shutdown)
+	return logger
+}
+
+func TestServer_Accept(t *testing.T) {
+	for _, encoder := range []core.Encoder{core.JSONEncoder, core.MsgPackEncoder, core.CBOREncoder} {
+		t.Run(encoder.ContentType(), func(t *testing.T) {
+			server := NewServer(t, Config{APIKey: "test-api-key"})
+			config := server.LoggerConfig()
+			config.Encoder = encoder
+			logger := newLogger(t, config)
+
+			logger.Info("order placed", map[string]any{"order_id": "A-1"})
+			logger.Warning("stock low", nil)
+			logger.Flush()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			logs, err := server.WaitForLogs(ctx, 2)
+			if err != nil {
+				t.Fatalf("WaitForLogs() error = %v, got %d logs", err, len(logs))
+			}
+			if logs[0].Message != "order placed" || logs[0].Fields["order_id"] != "A-1" || logs[1].Level != string(core.WARNING) {
+				t.Errorf("Logs() = %+v", logs)
+			}
+
+			batches := server.Batches()
+			if len(batches) == 0 || batches[0].ContentType != encoder.ContentType() || batches[0].ID == "" {
+				t.Errorf("Batches() = %+v", batches)
+			}
+		})
+	}
+}
+
+func TestServer_Reject(t *testing.T) {
+	server := NewServer(t, Config{})
+	server.RejectWhere(func(entry core.LogEntry) (string, bool) {
+		return "message too long", strings.HasPrefix(entry.Message, "bad")
+	})
+
+	var mu sync.Mutex
+	var rejected []core.RejectedLogEntry
+	config := server.LoggerConfig()
+	config.OnRejected = func(entries []core.RejectedLogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		rejected = append(rejected, entries...)
+	}
+	logger := newLogger(t, config)
+
+	logger.Info("good", nil)
+	logger.Info("bad", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if logs := server.Logs(); len(logs) != 1 || logs[0].Message != "good" {
+		t.Errorf("Logs() = %+v, want only the good entry", logs)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(rejected) != 1 || rejected[0].Entry.Message != "bad" || rejected[0].Reason != "message too long" {
+		t.Errorf("OnRejected got %+v", rejected)
+	}
+}
+
+func TestServer_FailoverOnErrors(t *testing.T) {
+	primary := NewServer(t, Config{})
+	secondary := NewServer(t, Config{})
+	primary.SetMode(Fail)
+
+	config := primary.LoggerConfig()
+	config.Hosts = []string{primary.URL, secondary.URL}
+	logger := newLogger(t, config)
+
+	logger.Info("charged", nil)
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if primary.Requests() == 0 || len(primary.Logs()) != 0 {
+		t.Errorf("Primary got %d requests and %d logs, want failed requests only", primary.Requests(), len(primary.Logs()))
+	}
+	if logs := secondary.Logs(); len(logs) != 1 || logs[0].Message != "charged" {
+		t.Errorf("Secondary Logs() = %+v", logs)
+	}
+}
+
+func TestServer_FailNext(t *testing.T) {
+	server := NewServer(t, Config{})
+	server.SetMode(Throttle)
+	server.FailNext(1, http.StatusBadGateway)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	url := server.URL + "/api/v1/logs/receiving/" + DefaultProjectID
+	for _, want := range []int{http.StatusBadGateway, http.StatusTooManyRequests} {
+		resp, err := client.Post(url, "application/json", strings.NewReader(`{"logs":[]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, want)
+		}
+		if want == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" {
+			t.Error("Throttle mode did not set Retry-After")
+		}
+	}
+
+	server.Reset()
+	if server.Requests() != 0 {
+		t.Errorf("Requests() = %d after Reset, want 0", server.Requests())
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	server := NewServer(t, Config{APIKey: "test-api-key"})
+
+	config := server.LoggerConfig()
+	config.APIKey = "revoked-api-key"
+	if _, err := core.ValidateConfigOnline(context.Background(), config); err == nil {
+		t.Error("ValidateConfigOnline() expected error for a wrong API key")
+	}
+
+	server.SetMode(Unauthorized)
+	if _, err := core.ValidateConfigOnline(context.Background(), server.LoggerConfig()); err == nil {
+		t.Error("ValidateConfigOnline() expected error in Unauthorized mode")
+	}
+}
+
+func TestServer_Query(t *testing.T) {
+	server := NewServer(t, Config{})
+	logger := newLogger(t, server.LoggerConfig())
+
+	logger.Info("user signed in", map[string]any{"user_id": "42"})
+	logger.Error("payment failed", map[string]any{"user_id": "42"})
+	logger.Error("payment failed", map[string]any{"user_id": "7"})
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	client, err := logbullquery.NewClient(logbullquery.Config{Host: server.URL, ProjectID: DefaultProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	logs, err := client.All(context.Background(), logbullquery.Query{
+		Levels: []core.LogLevel{core.ERROR},
+		Fields: map[string]any{"user_id": "42"},
+		Limit:  1,
+	})
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if len(logs) != 1 || logs[0].Message != "payment failed" {
+		t.Errorf("All() = %+v", logs)
+	}
+}
+
+func TestServer_ColumnarBatches(t *testing.T) {
+	server := NewServer(t, Config{Capabilities: &core.ServerCapabilities{
+		ContentTypes: []string{core.MsgPackEncoder.ContentType()},
+		Compression:  []string{"gzip"},
+		BatchFormats: []string{string(core.BatchFormatColumnar)},
+	}})
+
+	config := server.LoggerConfig()
+	config.Encoder = core.MsgPackEncoder
+	config.BatchFormat = core.BatchFormatColumnar
+	config.NegotiateCapabilities = true
+	logger := newLogger(t, config)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := logger.Sender().Capabilities(); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Capability probe did not complete")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	logger.Info("first", map[string]any{"service": "checkout", "step": 1})
+	logger.Info("second", map[string]any{"service": "checkout", "step": 2})
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	batches := server.Batches()
+	if len(batches) != 1 || !batches[0].Columnar || !batches[0].Gzipped {
+		t.Fatalf("Batches() = %+v, want one gzipped columnar batch", batches)
+	}
+	logs := batches[0].Logs
+	if len(logs) != 2 || logs[1].Message != "second" || logs[1].Fields["service"] != "checkout" || logs[1].Fields["step"] == nil {
+		t.Errorf("Logs = %+v", logs)
+	}
+}
@@ -0,0 +1,91 @@
+// Package callsite finds the application code that issued a log call.
+package callsite
+
+import (
+	"runtime"
+	"strings"
+)
+
+const (
+	FunctionField = "caller.function"
+	FileField     = "caller.file"
+	LineField     = "caller.line"
+
+	maxDepth = 32
+)
+
+// internalPrefixes are functions skipped while walking the stack: the
+// LogBull logger, panic recovery, handlers and integrations, and the
+// libraries they plug into. Skipping the runtime makes a recovered panic point at the
+// panicking function.
+var internalPrefixes = []string{
+	"github.com/logbull/logbull-go/logbull/core.(*LogBullLogger).",
+	"github.com/logbull/logbull-go/logbull/core.(*Sender).",
+	"github.com/logbull/logbull-go/logbull/core.(*Timer).",
+	"github.com/logbull/logbull-go/logbull/core.Recover",
+	"github.com/logbull/logbull-go/logbull/core.logPanic",
+	"runtime.",
+	"github.com/logbull/logbull-go/logbull/handlers.(*",
+	"github.com/logbull/logbull-go/logbull/sql.(*",
+	"github.com/logbull/logbull-go/logbull/middleware/internal/httplog.",
+	"github.com/logbull/logbull-go/logbull/middleware/grpc.logCall",
+	"log.",
+	"log/slog.",
+	"go.uber.org/zap.",
+	"go.uber.org/zap/zapcore.",
+	"github.com/sirupsen/logrus.",
+	"github.com/apex/log.",
+	"database/sql.",
+	"github.com/qustavo/sqlhooks/",
+}
+
+// Capture returns the first stack frame outside LogBull and the supported
+// logging libraries. skip is the number of frames to skip above Capture's
+// caller, usually 0.
+func Capture(skip int) (runtime.Frame, bool) {
+	var pcs [maxDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !isInternal(frame.Function) {
+			return frame, frame.PC != 0
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// FrameForPC resolves a program counter recorded by a logging library, such
+// as slog.Record.PC.
+func FrameForPC(pc uintptr) (runtime.Frame, bool) {
+	if pc == 0 {
+		return runtime.Frame{}, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return frame, frame.Function != ""
+}
+
+// AddFields stores the caller in fields without overriding existing keys.
+func AddFields(fields map[string]any, function, file string, line int) {
+	add := func(key string, value any) {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	add(FunctionField, function)
+	add(FileField, file)
+	add(LineField, line)
+}
+
+func isInternal(function string) bool {
+	for _, prefix := range internalPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
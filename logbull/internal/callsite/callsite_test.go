@@ -0,0 +1,47 @@
+package callsite
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	frame, ok := Capture(0)
+
+	if !ok {
+		t.Fatal("Capture() found no frame")
+	}
+	if !strings.HasSuffix(frame.Function, ".TestCapture") {
+		t.Errorf("Function = %s, want TestCapture", frame.Function)
+	}
+	if frame.Line != line+1 {
+		t.Errorf("Line = %d, want %d", frame.Line, line+1)
+	}
+}
+
+func TestFrameForPC(t *testing.T) {
+	if _, ok := FrameForPC(0); ok {
+		t.Error("FrameForPC(0) should report no frame")
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	frame, ok := FrameForPC(pcs[0])
+	if !ok || !strings.HasSuffix(frame.Function, ".TestFrameForPC") {
+		t.Errorf("FrameForPC() = %v, %v, want TestFrameForPC", frame.Function, ok)
+	}
+}
+
+func TestAddFields(t *testing.T) {
+	fields := map[string]any{LineField: "kept"}
+	AddFields(fields, "main.run", "/app/main.go", 42)
+
+	if fields[FunctionField] != "main.run" || fields[FileField] != "/app/main.go" {
+		t.Errorf("fields = %v", fields)
+	}
+	if fields[LineField] != "kept" {
+		t.Errorf("%s = %v, want the existing value kept", LineField, fields[LineField])
+	}
+}
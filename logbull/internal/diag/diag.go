@@ -0,0 +1,54 @@
+// Package diag reports the client's own diagnostics: errors go to a
+// configured handler or stderr, notices to stderr unless silenced.
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Output is where unhandled diagnostics are printed. Tests replace it.
+var Output io.Writer = os.Stderr
+
+// Report passes err and its context to handler. Without a handler it prints
+// them to Output unless silent is set.
+func Report(handler func(err error, context map[string]any), silent bool, err error, context map[string]any) {
+	if handler != nil {
+		handler(err, context)
+		return
+	}
+	if silent {
+		return
+	}
+
+	fmt.Fprintf(Output, "LogBull: %v%s\n", err, formatContext(context))
+}
+
+// Notice prints an informational message unless silent is set.
+func Notice(silent bool, message string) {
+	if !silent {
+		fmt.Fprintf(Output, "LogBull: %s\n", message)
+	}
+}
+
+func formatContext(context map[string]any) string {
+	if len(context) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(context))
+	for key := range context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, context[key]))
+	}
+
+	return " (" + strings.Join(pairs, ", ") + ")"
+}
@@ -0,0 +1,76 @@
+package diag
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	original := Output
+	Output = &buf
+	t.Cleanup(func() { Output = original })
+
+	return &buf
+}
+
+func TestReport(t *testing.T) {
+	t.Run("prints sorted context", func(t *testing.T) {
+		output := captureOutput(t)
+
+		Report(nil, false, errors.New("request failed"), map[string]any{"status": 500, "operation": "send"})
+
+		want := "LogBull: request failed (operation=send, status=500)\n"
+		if got := output.String(); got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("handler replaces output", func(t *testing.T) {
+		output := captureOutput(t)
+
+		var gotErr error
+		var gotContext map[string]any
+		handler := func(err error, context map[string]any) {
+			gotErr = err
+			gotContext = context
+		}
+
+		err := errors.New("request failed")
+		Report(handler, true, err, map[string]any{"operation": "send"})
+
+		if gotErr != err {
+			t.Errorf("handler err = %v, want %v", gotErr, err)
+		}
+		if gotContext["operation"] != "send" {
+			t.Errorf("handler context = %v, want operation=send", gotContext)
+		}
+		if output.Len() != 0 {
+			t.Errorf("output = %q, want nothing", output.String())
+		}
+	})
+
+	t.Run("silent drops unhandled errors", func(t *testing.T) {
+		output := captureOutput(t)
+
+		Report(nil, true, errors.New("request failed"), nil)
+
+		if output.Len() != 0 {
+			t.Errorf("output = %q, want nothing", output.String())
+		}
+	})
+}
+
+func TestNotice(t *testing.T) {
+	output := captureOutput(t)
+
+	Notice(true, "hidden")
+	Notice(false, "shown")
+
+	if got, want := output.String(), "LogBull: shown\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
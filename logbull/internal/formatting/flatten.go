@@ -0,0 +1,85 @@
+package formatting
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Flatten replaces nested maps and structs with dotted keys, so
+// {"user": {"address": {"city": "Berlin"}}} becomes {"user.address.city":
+// "Berlin"}. Values nested deeper than maxDepth are kept as JSON strings.
+// Top-level fields whose flattened keys would exceed maxKeys are sent as one
+// JSON string while there is room, and dropped after that.
+func Flatten(fields map[string]any, maxDepth, maxKeys int) map[string]any {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]any, len(fields))
+	for _, key := range keys {
+		flat := make(map[string]any)
+		flattenValue(flat, key, fields[key], 1, maxDepth)
+
+		switch {
+		case len(result)+len(flat) <= maxKeys:
+			for k, v := range flat {
+				result[k] = v
+			}
+		case len(result) < maxKeys:
+			result[key] = convertToString(fields[key])
+		}
+	}
+	return result
+}
+
+func flattenValue(dst map[string]any, key string, value any, depth, maxDepth int) {
+	nested, ok := nestedMap(value)
+	if !ok {
+		dst[key] = value
+		return
+	}
+	if depth >= maxDepth {
+		dst[key] = convertToString(value)
+		return
+	}
+	if len(nested) == 0 {
+		dst[key] = nested
+		return
+	}
+
+	for k, v := range nested {
+		flattenValue(dst, key+"."+k, v, depth+1, maxDepth)
+	}
+}
+
+// nestedMap returns the fields of a map or struct value, as ObjectValue
+// converts them, or using their JSON form so custom marshalers are respected. Values that do not
+// encode to a JSON object, such as time.Time or slices, are not nested.
+func nestedMap(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case nil, string, bool, error,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64, []any:
+		return nil, false
+	}
+
+	if object, ok := ObjectValue(value).(map[string]any); ok {
+		return object, true
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil || len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+
+	var nested map[string]any
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return nil, false
+	}
+	return nested, true
+}
@@ -0,0 +1,73 @@
+package formatting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City    string `json:"city"`
+	Country string `json:"country,omitempty"`
+}
+
+func TestFlatten(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	got := Flatten(map[string]any{
+		"user": map[string]any{
+			"id":      "u1",
+			"address": address{City: "Berlin"},
+			"profile": map[string]any{"settings": map[string]any{"theme": "dark"}},
+		},
+		"tags":    []any{"a", "b"},
+		"at":      at,
+		"empty":   map[string]any{},
+		"message": "plain",
+	}, 3, 100)
+
+	want := map[string]any{
+		"user.id":               "u1",
+		"user.address.city":     "Berlin",
+		"user.profile.settings": `{"theme":"dark"}`,
+		"tags":                  []any{"a", "b"},
+		"at":                    at,
+		"empty":                 map[string]any{},
+		"message":               "plain",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten() = %v, want %v", got, want)
+	}
+}
+
+func TestFlatten_MaxKeys(t *testing.T) {
+	got := Flatten(map[string]any{
+		"a": map[string]any{"x": 1, "y": 2},
+		"b": map[string]any{"x": 1, "y": 2},
+		"c": map[string]any{"x": 1},
+		"d": "dropped",
+	}, 5, 4)
+
+	want := map[string]any{
+		"a.x": 1,
+		"a.y": 2,
+		"b.x": 1,
+		"b.y": 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten() = %v, want %v", got, want)
+	}
+
+	got = Flatten(map[string]any{
+		"a": map[string]any{"x": 1, "y": 2, "z": 3},
+		"b": "kept",
+	}, 5, 2)
+
+	want = map[string]any{
+		"a": `{"x":1,"y":2,"z":3}`,
+		"b": "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten() = %v, want %v", got, want)
+	}
+}
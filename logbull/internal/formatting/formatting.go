@@ -3,10 +3,16 @@ package formatting
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 )
 
-const defaultMaxMessageLength = 10_000
+const (
+	defaultMaxMessageLength = 10_000
+	previewMessageLength    = 80
+	previewMaxKeys          = 10
+)
 
 func FormatMessage(message string) string {
 	message = strings.TrimSpace(message)
@@ -16,46 +22,114 @@ func FormatMessage(message string) string {
 	return message
 }
 
-func EnsureFields(fields map[string]any) map[string]any {
-	if fields == nil {
-		return make(map[string]any)
-	}
+// EnsureFields returns a copy of fields ready to send, with keys normalized
+// by keys.
+func EnsureFields(fields map[string]any, keys KeyPolicy) map[string]any {
+	formatted := make(map[string]any, len(fields))
+	addFields(formatted, fields, keys)
+	return formatted
+}
 
-	formatted := make(map[string]any)
-	for key, value := range fields {
-		key = strings.TrimSpace(key)
-		if key == "" {
-			continue
-		}
+// MergeFields builds a single map sized for both inputs, with additional
+// taking precedence over base.
+func MergeFields(base, additional map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(additional))
+	addFields(result, base, KeyPolicy{})
+	addFields(result, additional, KeyPolicy{})
+	return result
+}
 
-		if isJSONSerializable(value) {
-			formatted[key] = value
-		} else {
-			formatted[key] = convertToString(value)
+func addFields(dst, fields map[string]any, keys KeyPolicy) {
+	eachField(fields, keys, func(key string, value any) {
+		dst[key] = sendableValue(value)
+	})
+}
+
+// sendableValue converts value into one JSON can encode. A value panicking
+// on the way, e.g. in MarshalJSON or LogObject, is replaced by a description
+// of the panic, the way fmt prints panicking String methods, so the rest of
+// the entry is still logged.
+func sendableValue(value any) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = panicValue(r)
 		}
+	}()
+
+	if err, ok := value.(error); ok {
+		// Most error types marshal to "{}", so send the message instead
+		return errorString(err)
+	}
+	if value = ObjectValue(value); isJSONSerializable(value) {
+		return value
 	}
+	return convertToString(value)
+}
 
-	return formatted
+// panicValue describes a panic recovered while converting a field value.
+func panicValue(recovered any) string {
+	return fmt.Sprintf("%%!v(PANIC=%v)", recovered)
 }
 
-func MergeFields(base, additional map[string]any) map[string]any {
-	result := EnsureFields(base)
-	for key, value := range EnsureFields(additional) {
-		result[key] = value
+func PreviewEntry(message string, fields map[string]any) string {
+	message = strings.TrimSpace(message)
+	if len(message) > previewMessageLength {
+		message = message[:previewMessageLength-3] + "..."
 	}
-	return result
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) > previewMaxKeys {
+		keys = append(keys[:previewMaxKeys], fmt.Sprintf("... %d more", len(fields)-previewMaxKeys))
+	}
+
+	return fmt.Sprintf("message=%q fields=[%s]", message, strings.Join(keys, ", "))
+}
+
+func errorString(err error) (message string) {
+	defer func() {
+		// A typed nil pointer stored in an error interface panics in Error()
+		if recover() != nil {
+			message = "<nil>"
+		}
+	}()
+	return err.Error()
 }
 
 func isJSONSerializable(value any) bool {
+	// Common scalar types are checked without marshaling to keep the logging
+	// hot path allocation-free
+	switch v := value.(type) {
+	case nil, string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return true
+	case float64:
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	case float32:
+		return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+	}
+
 	_, err := json.Marshal(value)
 	return err == nil
 }
 
-func convertToString(value any) string {
+func convertToString(value any) (s string) {
 	if value == nil {
 		return "null"
 	}
 
+	defer func() {
+		// fmt.Sprint recovers panics itself, unlike json.Marshal
+		if recover() != nil {
+			s = fmt.Sprint(value)
+		}
+	}()
+
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Sprint(value)
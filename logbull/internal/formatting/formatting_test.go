@@ -1,6 +1,10 @@
 package formatting
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"strings"
 	"testing"
 )
@@ -106,11 +110,33 @@ func TestEnsureFields(t *testing.T) {
 				"user_id": "12345",
 			},
 		},
+		{
+			name: "error values",
+			fields: map[string]any{
+				"error": fmt.Errorf("wrapped: %w", errors.New("connection refused")),
+				"typed": (*pathError)(nil),
+			},
+			expected: map[string]any{
+				"error": "wrapped: connection refused",
+				"typed": "<nil>",
+			},
+		},
+		{
+			name: "panicking values",
+			fields: map[string]any{
+				"marshal": panicking{message: "boom"},
+				"string":  make(panickingStringer),
+			},
+			expected: map[string]any{
+				"marshal": "%!v(PANIC=boom)",
+				"string":  "%!v(PANIC=String method: stringer broke)",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := EnsureFields(tt.fields)
+			result := EnsureFields(tt.fields, KeyPolicy{})
 			if len(result) != len(tt.expected) {
 				t.Errorf("EnsureFields() length = %v, want %v", len(result), len(tt.expected))
 			}
@@ -123,6 +149,52 @@ func TestEnsureFields(t *testing.T) {
 	}
 }
 
+type pathError struct {
+	path string
+}
+
+func (e *pathError) Error() string {
+	return "bad path " + e.path
+}
+
+// panicking panics when marshaled, like a value with a buggy MarshalJSON.
+type panicking struct {
+	message string
+}
+
+func (p panicking) MarshalJSON() ([]byte, error) {
+	panic(p.message)
+}
+
+// panickingStringer cannot be marshaled, so it is converted with String.
+type panickingStringer chan int
+
+func (panickingStringer) String() string {
+	panic("stringer broke")
+}
+
+func FuzzEnsureFields(f *testing.F) {
+	f.Add("user_id", "12345", false)
+	f.Add("  payload ", `{"a":1}`, true)
+	f.Add("", "\xff", true)
+
+	f.Fuzz(func(t *testing.T, key, value string, panics bool) {
+		var field any = value
+		if panics {
+			field = panicking{message: value}
+		}
+
+		result := EnsureFields(map[string]any{
+			key:      field,
+			"nested": map[string]any{key: field},
+			"list":   []any{field},
+		}, KeyPolicy{})
+		if _, err := json.Marshal(result); err != nil {
+			t.Errorf("EnsureFields() result does not marshal: %v", err)
+		}
+	})
+}
+
 func TestMergeFields(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -248,6 +320,16 @@ func TestIsJSONSerializable(t *testing.T) {
 			value:    make(chan int),
 			expected: false,
 		},
+		{
+			name:     "NaN (not serializable)",
+			value:    math.NaN(),
+			expected: false,
+		},
+		{
+			name:     "float32 infinity (not serializable)",
+			value:    float32(math.Inf(1)),
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -292,3 +374,44 @@ func TestConvertToString(t *testing.T) {
 		})
 	}
 }
+
+func TestPreviewEntry(t *testing.T) {
+	t.Run("sorted keys", func(t *testing.T) {
+		got := PreviewEntry("hello", map[string]any{"b": 1, "a": 2})
+		want := `message="hello" fields=[a, b]`
+		if got != want {
+			t.Errorf("PreviewEntry() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("truncates long message", func(t *testing.T) {
+		got := PreviewEntry(strings.Repeat("m", 200), nil)
+		if !strings.Contains(got, strings.Repeat("m", previewMessageLength-3)+"...") {
+			t.Errorf("PreviewEntry() = %q, message not truncated", got)
+		}
+	})
+
+	t.Run("limits key count", func(t *testing.T) {
+		fields := make(map[string]any)
+		for i := 0; i < previewMaxKeys+5; i++ {
+			fields[fmt.Sprintf("key_%02d", i)] = i
+		}
+
+		got := PreviewEntry("msg", fields)
+		if !strings.Contains(got, "... 5 more") {
+			t.Errorf("PreviewEntry() = %q, want remaining key count", got)
+		}
+	})
+}
+
+func TestMergeFields_Allocations(t *testing.T) {
+	base := map[string]any{"service": "api"}
+	additional := map[string]any{"count": 42, "ok": true}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		MergeFields(base, additional)
+	})
+	if allocs > 2 {
+		t.Errorf("MergeFields() allocs = %v, want at most 2", allocs)
+	}
+}
@@ -0,0 +1,129 @@
+package formatting
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeyPolicy normalizes field keys, so entries from frameworks with different
+// naming conventions, such as zap's camelCase and logrus's snake_case, share
+// field names. The zero value keeps keys as logged.
+type KeyPolicy struct {
+	// SnakeCase converts camelCase, PascalCase, kebab-case and
+	// space-separated keys to snake_case, e.g. "userId", "UserID" and
+	// "user-id" to "user_id". Dots are kept, so "http.statusCode" becomes
+	// "http.status_code".
+	SnakeCase bool
+	// Lowercase lowercases keys.
+	Lowercase bool
+	// ReplaceInvalid replaces characters other than ASCII letters, digits,
+	// '_' and '.' with '_'.
+	ReplaceInvalid bool
+}
+
+func (p KeyPolicy) enabled() bool {
+	return p.SnakeCase || p.Lowercase || p.ReplaceInvalid
+}
+
+// Normalize applies the policy to key.
+func (p KeyPolicy) Normalize(key string) string {
+	if p.SnakeCase {
+		key = snakeCase(key)
+	}
+	if p.Lowercase {
+		key = strings.ToLower(key)
+	}
+	if p.ReplaceInvalid {
+		key = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '_' || r == '.' {
+				return r
+			}
+			return '_'
+		}, key)
+	}
+	return key
+}
+
+// NormalizeKeys returns fields with the policy applied to its top-level
+// keys. When several keys normalize to the same key, one already in
+// normalized form wins. fields is returned as is when the policy is the zero
+// value or changes no key.
+func NormalizeKeys(fields map[string]any, policy KeyPolicy) map[string]any {
+	if !policy.enabled() {
+		return fields
+	}
+
+	changed := false
+	for key := range fields {
+		if policy.Normalize(key) != key {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return fields
+	}
+
+	normalized := make(map[string]any, len(fields))
+	eachField(fields, policy, func(key string, value any) {
+		normalized[key] = value
+	})
+	return normalized
+}
+
+// eachField calls fn with the trimmed, normalized key of every field with a
+// non-empty key. Keys the policy changes come first, so that keys already in
+// normalized form overwrite them.
+func eachField(fields map[string]any, policy KeyPolicy, fn func(key string, value any)) {
+	if !policy.enabled() {
+		for key, value := range fields {
+			if key = strings.TrimSpace(key); key != "" {
+				fn(key, value)
+			}
+		}
+		return
+	}
+
+	for _, unchanged := range []bool{false, true} {
+		for key, value := range fields {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if normalized := policy.Normalize(key); (normalized == key) == unchanged {
+				fn(normalized, value)
+			}
+		}
+	}
+}
+
+func snakeCase(key string) string {
+	runes := []rune(key)
+
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			r = '_'
+		case unicode.IsUpper(r):
+			if i > 0 && wordEnds(runes, i) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// wordEnds reports whether the uppercase rune at i starts a new word: after
+// a lowercase letter or digit ("userId"), or as the last capital of an
+// acronym followed by a lowercase letter ("HTTPServer").
+func wordEnds(runes []rune, i int) bool {
+	prev := runes[i-1]
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
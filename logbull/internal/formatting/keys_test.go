@@ -0,0 +1,54 @@
+package formatting
+
+import "testing"
+
+func TestKeyPolicy_Normalize(t *testing.T) {
+	tests := []struct {
+		key    string
+		policy KeyPolicy
+		want   string
+	}{
+		{"userId", KeyPolicy{}, "userId"},
+		{"userId", KeyPolicy{SnakeCase: true}, "user_id"},
+		{"UserID", KeyPolicy{SnakeCase: true}, "user_id"},
+		{"user-id", KeyPolicy{SnakeCase: true}, "user_id"},
+		{"user_id", KeyPolicy{SnakeCase: true}, "user_id"},
+		{"HTTPServer", KeyPolicy{SnakeCase: true}, "http_server"},
+		{"http.statusCode", KeyPolicy{SnakeCase: true}, "http.status_code"},
+		{"retry2Count", KeyPolicy{SnakeCase: true}, "retry2_count"},
+		{"User_Name", KeyPolicy{SnakeCase: true}, "user_name"},
+		{"RequestID", KeyPolicy{Lowercase: true}, "requestid"},
+		{"@timestamp", KeyPolicy{ReplaceInvalid: true}, "_timestamp"},
+		{"grüße/key", KeyPolicy{ReplaceInvalid: true}, "gr__e_key"},
+		{"User Name!", KeyPolicy{SnakeCase: true, ReplaceInvalid: true}, "user_name_"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.Normalize(tt.key); got != tt.want {
+			t.Errorf("%+v.Normalize(%q) = %q, want %q", tt.policy, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeKeys(t *testing.T) {
+	policy := KeyPolicy{SnakeCase: true}
+
+	fields := map[string]any{"user_id": "1"}
+	if got := NormalizeKeys(fields, policy); len(got) != 1 || got["user_id"] != "1" {
+		t.Errorf("NormalizeKeys() = %v", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		got := NormalizeKeys(map[string]any{"userId": "camel", "user_id": "snake", "orderId": 7}, policy)
+		if len(got) != 2 || got["user_id"] != "snake" || got["order_id"] != 7 {
+			t.Fatalf("NormalizeKeys() = %v, want the snake_case key to win", got)
+		}
+	}
+}
+
+func TestEnsureFields_KeyPolicy(t *testing.T) {
+	got := EnsureFields(map[string]any{" userId ": "u1", "": "dropped"}, KeyPolicy{SnakeCase: true})
+	if len(got) != 1 || got["user_id"] != "u1" {
+		t.Errorf("EnsureFields() = %v", got)
+	}
+}
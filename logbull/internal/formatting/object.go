@@ -0,0 +1,167 @@
+package formatting
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// maxObjectDepth stops the conversion of self-referencing structs.
+const maxObjectDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ObjectValue converts structs, including those nested in slices and maps,
+// to map[string]any keyed by their json names. Fields tagged json:"-" or
+// logbull:"-" are left out, and omitempty is honored. Types with their own
+// JSON or text marshaling, such as time.Time, and all other values are
+// returned unchanged.
+func ObjectValue(value any) any {
+	switch value.(type) {
+	case nil, string, bool, error,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return value
+	}
+
+	converted, _ := objectValue(reflect.ValueOf(value), 0)
+	return converted
+}
+
+// objectValue reports whether v contained a struct, so values without one
+// are returned as they were instead of as a converted copy.
+func objectValue(v reflect.Value, depth int) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if depth > maxObjectDepth || hasCustomMarshaling(v.Type()) {
+		return v.Interface(), false
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return v.Interface(), false
+		}
+		if converted, ok := objectValue(v.Elem(), depth+1); ok {
+			return converted, true
+		}
+		return v.Interface(), false
+
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		addStructFields(fields, v, depth)
+		return fields, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v.Interface(), false
+		}
+		items := make([]any, v.Len())
+		changed := false
+		for i := range items {
+			var ok bool
+			items[i], ok = objectValue(v.Index(i), depth+1)
+			changed = changed || ok
+		}
+		if !changed {
+			return v.Interface(), false
+		}
+		return items, true
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.IsNil() {
+			return v.Interface(), false
+		}
+		entries := make(map[string]any, v.Len())
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			var ok bool
+			entries[iter.Key().String()], ok = objectValue(iter.Value(), depth+1)
+			changed = changed || ok
+		}
+		if !changed {
+			return v.Interface(), false
+		}
+		return entries, true
+	}
+
+	return v.Interface(), false
+}
+
+func addStructFields(dst map[string]any, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("logbull") == "-" {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		value := v.Field(i)
+
+		// Embedded structs without a json name are inlined, like in
+		// encoding/json
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !hasCustomMarshaling(embedded.Type()) {
+				addStructFields(dst, embedded, depth+1)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		dst[name], _ = objectValue(value, depth+1)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+func hasCustomMarshaling(t reflect.Type) bool {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	if t.Kind() != reflect.Pointer {
+		pointer := reflect.PointerTo(t)
+		return pointer.Implements(jsonMarshalerType) || pointer.Implements(textMarshalerType)
+	}
+	return false
+}
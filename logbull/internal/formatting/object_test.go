@@ -0,0 +1,86 @@
+package formatting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type objectAddress struct {
+	City string `json:"city"`
+}
+
+type objectBase struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type objectUser struct {
+	objectBase
+	ID        string            `json:"id"`
+	Email     string            `json:"email,omitempty"`
+	Password  string            `logbull:"-"`
+	Internal  string            `json:"-"`
+	Address   *objectAddress    `json:"address"`
+	Previous  []objectAddress   `json:"previous"`
+	ByLabel   map[string]any    `json:"by_label"`
+	Labels    map[string]string `json:"labels"`
+	NoTag     int
+	unexposed string
+}
+
+func TestObjectValue(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	user := &objectUser{
+		objectBase: objectBase{CreatedAt: created},
+		ID:         "u1",
+		Password:   "secret",
+		Internal:   "hidden",
+		Address:    &objectAddress{City: "Berlin"},
+		Previous:   []objectAddress{{City: "Paris"}},
+		ByLabel:    map[string]any{"home": objectAddress{City: "Rome"}},
+		Labels:     map[string]string{"tier": "gold"},
+		NoTag:      7,
+		unexposed:  "x",
+	}
+
+	want := map[string]any{
+		"created_at": created,
+		"id":         "u1",
+		"address":    map[string]any{"city": "Berlin"},
+		"previous":   []any{map[string]any{"city": "Paris"}},
+		"by_label":   map[string]any{"home": map[string]any{"city": "Rome"}},
+		"labels":     map[string]string{"tier": "gold"},
+		"NoTag":      7,
+	}
+	if got := ObjectValue(user); !reflect.DeepEqual(got, want) {
+		t.Errorf("ObjectValue() = %#v, want %#v", got, want)
+	}
+}
+
+func TestObjectValue_Unchanged(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	var nilUser *objectUser
+
+	for _, value := range []any{"text", 42, created, []string{"a"}, map[string]int{"a": 1}, nilUser} {
+		if got := ObjectValue(value); !reflect.DeepEqual(got, value) {
+			t.Errorf("ObjectValue(%#v) = %#v, want it unchanged", value, got)
+		}
+	}
+}
+
+func TestEnsureFields_Structs(t *testing.T) {
+	fields := EnsureFields(map[string]any{
+		"user": objectUser{ID: "u1", Password: "secret"},
+	}, KeyPolicy{})
+
+	user, ok := fields["user"].(map[string]any)
+	if !ok {
+		t.Fatalf("user = %#v, want a map", fields["user"])
+	}
+	if _, ok := user["Password"]; ok {
+		t.Error(`Fields tagged logbull:"-" must be left out`)
+	}
+	if user["id"] != "u1" {
+		t.Errorf("id = %v, want u1", user["id"])
+	}
+}
@@ -0,0 +1,60 @@
+package formatting
+
+import (
+	"strings"
+)
+
+// RenderTemplate replaces {key} placeholders in message with the matching
+// field values. Placeholders without a matching field are left as they are.
+// ok reports whether at least one placeholder was replaced.
+func RenderTemplate(message string, fields map[string]any) (rendered string, ok bool) {
+	if len(fields) == 0 || strings.IndexByte(message, '{') < 0 {
+		return message, false
+	}
+
+	var b strings.Builder
+	rest := message
+
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+
+		end := strings.IndexByte(rest[open+1:], '}')
+		if end < 0 {
+			break
+		}
+		end += open + 1
+
+		key := rest[open+1 : end]
+		value, found := fields[key]
+		if key == "" || strings.ContainsAny(key, "{ ") || !found {
+			b.WriteString(rest[:open+1])
+			rest = rest[open+1:]
+			continue
+		}
+
+		if !ok {
+			b.Grow(len(message))
+			ok = true
+		}
+		b.WriteString(rest[:open])
+		b.WriteString(templateValue(value))
+		rest = rest[end+1:]
+	}
+
+	if !ok {
+		return message, false
+	}
+
+	b.WriteString(rest)
+	return b.String(), true
+}
+
+func templateValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return convertToString(value)
+}
@@ -0,0 +1,48 @@
+package formatting
+
+import (
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]any{
+		"user_id": "user_42",
+		"ip":      "10.0.0.1",
+		"count":   3,
+		"ok":      true,
+		"tags":    []string{"a", "b"},
+		"empty":   nil,
+	}
+
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+		ok       bool
+	}{
+		{"no placeholders", "plain message", "plain message", false},
+		{"substitutes fields", "user {user_id} logged in from {ip}", "user user_42 logged in from 10.0.0.1", true},
+		{"non-string values", "{count} items, ok={ok}, tags={tags}, empty={empty}", `3 items, ok=true, tags=["a","b"], empty=null`, true},
+		{"unknown placeholder kept", "user {user_id} in {region}", "user user_42 in {region}", true},
+		{"only unknown placeholders", "value {missing}", "value {missing}", false},
+		{"unclosed brace", "user {user_id", "user {user_id", false},
+		{"json-like text", `payload {"user_id": 1}`, `payload {"user_id": 1}`, false},
+		{"nested opening brace", "{{user_id}}", "{user_42}", true},
+		{"empty placeholder", "{} {ip}", "{} 10.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, ok := RenderTemplate(tt.message, fields)
+			if rendered != tt.expected || ok != tt.ok {
+				t.Errorf("RenderTemplate(%q) = %q, %v, want %q, %v", tt.message, rendered, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate_NoFields(t *testing.T) {
+	if rendered, ok := RenderTemplate("user {user_id}", nil); rendered != "user {user_id}" || ok {
+		t.Errorf("RenderTemplate() = %q, %v, want the message unchanged", rendered, ok)
+	}
+}
@@ -0,0 +1,95 @@
+package formatting
+
+import "unicode/utf8"
+
+// TruncationMarker ends every value cut by TruncateString.
+const TruncationMarker = "...[truncated]"
+
+// TruncateString cuts s to at most maxLen bytes including TruncationMarker,
+// without splitting a UTF-8 sequence. It reports whether s was cut.
+func TruncateString(s string, maxLen int) (string, bool) {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s, false
+	}
+
+	keep := maxLen - len(TruncationMarker)
+	if keep <= 0 {
+		return TruncationMarker[:maxLen], true
+	}
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+	return s[:keep] + TruncationMarker, true
+}
+
+// TruncateValues cuts every string in fields longer than maxLen bytes,
+// including strings nested in maps and slices. The fields map is never
+// modified; a copy is returned when anything was cut.
+func TruncateValues(fields map[string]any, maxLen int) (map[string]any, bool) {
+	if maxLen <= 0 {
+		return fields, false
+	}
+
+	var result map[string]any
+	for key, value := range fields {
+		truncated, changed := truncateValue(value, maxLen)
+		if !changed {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]any, len(fields))
+			for k, v := range fields {
+				result[k] = v
+			}
+		}
+		result[key] = truncated
+	}
+
+	if result == nil {
+		return fields, false
+	}
+	return result, true
+}
+
+func truncateValue(value any, maxLen int) (any, bool) {
+	switch v := value.(type) {
+	case string:
+		return TruncateString(v, maxLen)
+	case map[string]any:
+		return TruncateValues(v, maxLen)
+	case []any:
+		var result []any
+		for i, item := range v {
+			truncated, changed := truncateValue(item, maxLen)
+			if !changed {
+				continue
+			}
+			if result == nil {
+				result = append([]any(nil), v...)
+			}
+			result[i] = truncated
+		}
+		if result == nil {
+			return value, false
+		}
+		return result, true
+	case []string:
+		var result []string
+		for i, item := range v {
+			truncated, changed := TruncateString(item, maxLen)
+			if !changed {
+				continue
+			}
+			if result == nil {
+				result = append([]string(nil), v...)
+			}
+			result[i] = truncated
+		}
+		if result == nil {
+			return value, false
+		}
+		return result, true
+	default:
+		return value, false
+	}
+}
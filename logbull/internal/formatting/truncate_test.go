@@ -0,0 +1,75 @@
+package formatting
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		maxLen int
+		want   string
+	}{
+		{"short", "hello", 10, "hello"},
+		{"disabled", "hello", 0, "hello"},
+		{"cut", strings.Repeat("a", 30), 20, "aaaaaa" + TruncationMarker},
+		{"limit below marker", strings.Repeat("a", 30), 5, TruncationMarker[:5]},
+		{"keeps runes whole", "ääääääääää", 17, "ä" + TruncationMarker},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := TruncateString(tt.input, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("TruncateString() = %q, want %q", got, tt.want)
+			}
+			if tt.maxLen > 0 && len(got) > tt.maxLen {
+				t.Errorf("len = %d, want at most %d", len(got), tt.maxLen)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateString() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestTruncateValues(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	fields := map[string]any{
+		"body":   long,
+		"status": 200,
+		"nested": map[string]any{"sql": long, "rows": 3},
+		"list":   []any{"ok", long},
+		"tags":   []string{long},
+	}
+
+	got, changed := TruncateValues(fields, 32)
+	if !changed {
+		t.Fatal("TruncateValues() reported no change")
+	}
+
+	want := strings.Repeat("x", 32-len(TruncationMarker)) + TruncationMarker
+	if got["body"] != want {
+		t.Errorf("body = %q", got["body"])
+	}
+	if got["nested"].(map[string]any)["sql"] != want || got["nested"].(map[string]any)["rows"] != 3 {
+		t.Errorf("nested = %v", got["nested"])
+	}
+	if list := got["list"].([]any); list[0] != "ok" || list[1] != want {
+		t.Errorf("list = %v", list)
+	}
+	if tags := got["tags"].([]string); tags[0] != want {
+		t.Errorf("tags = %v", tags)
+	}
+	if fields["body"] != long || fields["nested"].(map[string]any)["sql"] != long || fields["list"].([]any)[1] != long {
+		t.Error("TruncateValues() modified its input")
+	}
+
+	small := map[string]any{"a": "b"}
+	if got, changed := TruncateValues(small, 32); changed || len(got) != 1 {
+		t.Errorf("TruncateValues() = %v, %v for short values", got, changed)
+	}
+}
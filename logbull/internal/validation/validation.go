@@ -1,12 +1,42 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
 )
 
+var (
+	ErrInvalidProjectID = errors.New("invalid project ID")
+	ErrInvalidHost      = errors.New("invalid host URL")
+	ErrInvalidProxyURL  = errors.New("invalid proxy URL")
+	ErrInvalidAPIKey    = errors.New("invalid API key")
+	ErrEmptyMessage     = errors.New("empty log message")
+	ErrMessageTooLong   = errors.New("log message too long")
+	ErrInvalidFields    = errors.New("invalid log fields")
+)
+
+// Error is a validation failure. errors.Is matches it against its Kind, one
+// of the Err variables above.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
 var (
 	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 	apiKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-\.]{10,}$`)
@@ -16,16 +46,18 @@ const (
 	maxMessageLength = 10_000
 	maxFieldsCount   = 100
 	maxFieldKeyLen   = 100
+	keyPreviewLen    = 32
 )
 
 func ValidateProjectID(projectID string) error {
 	projectID = strings.TrimSpace(projectID)
 	if projectID == "" {
-		return fmt.Errorf("project ID cannot be empty")
+		return newError(ErrInvalidProjectID, "project ID cannot be empty")
 	}
 
 	if !uuidPattern.MatchString(projectID) {
-		return fmt.Errorf(
+		return newError(
+			ErrInvalidProjectID,
 			"invalid project ID format '%s'. Must be a valid UUID format: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx",
 			projectID,
 		)
@@ -37,20 +69,39 @@ func ValidateProjectID(projectID string) error {
 func ValidateHostURL(host string) error {
 	host = strings.TrimSpace(host)
 	if host == "" {
-		return fmt.Errorf("host URL cannot be empty")
+		return newError(ErrInvalidHost, "host URL cannot be empty")
 	}
 
 	parsedURL, err := url.Parse(host)
 	if err != nil {
-		return fmt.Errorf("invalid host URL format: %w", err)
+		return newError(ErrInvalidHost, "invalid host URL format: %v", err)
 	}
 
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("host URL must use http or https scheme, got: %s", parsedURL.Scheme)
+		return newError(ErrInvalidHost, "host URL must use http or https scheme, got: %s", parsedURL.Scheme)
+	}
+
+	if parsedURL.Host == "" {
+		return newError(ErrInvalidHost, "host URL must have a host component")
+	}
+
+	return nil
+}
+
+func ValidateProxyURL(proxy string) error {
+	parsedURL, err := url.Parse(strings.TrimSpace(proxy))
+	if err != nil {
+		return newError(ErrInvalidProxyURL, "invalid proxy URL format: %v", err)
+	}
+
+	switch parsedURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return newError(ErrInvalidProxyURL, "proxy URL must use http, https or socks5 scheme, got: %s", parsedURL.Scheme)
 	}
 
 	if parsedURL.Host == "" {
-		return fmt.Errorf("host URL must have a host component")
+		return newError(ErrInvalidProxyURL, "proxy URL must have a host component")
 	}
 
 	return nil
@@ -59,11 +110,12 @@ func ValidateHostURL(host string) error {
 func ValidateAPIKey(apiKey string) error {
 	apiKey = strings.TrimSpace(apiKey)
 	if len(apiKey) < 10 {
-		return fmt.Errorf("API key must be at least 10 characters long")
+		return newError(ErrInvalidAPIKey, "API key must be at least 10 characters long")
 	}
 
 	if !apiKeyPattern.MatchString(apiKey) {
-		return fmt.Errorf(
+		return newError(
+			ErrInvalidAPIKey,
 			"invalid API key format. API key must contain only alphanumeric characters, underscores, hyphens, and dots",
 		)
 	}
@@ -74,11 +126,11 @@ func ValidateAPIKey(apiKey string) error {
 func ValidateLogMessage(message string) error {
 	message = strings.TrimSpace(message)
 	if message == "" {
-		return fmt.Errorf("log message cannot be empty")
+		return newError(ErrEmptyMessage, "log message cannot be empty")
 	}
 
 	if len(message) > maxMessageLength {
-		return fmt.Errorf("log message too long (%d chars). Maximum allowed: %d", len(message), maxMessageLength)
+		return newError(ErrMessageTooLong, "log message too long (%d chars). Maximum allowed: %d", len(message), maxMessageLength)
 	}
 
 	return nil
@@ -90,17 +142,23 @@ func ValidateLogFields(fields map[string]any) error {
 	}
 
 	if len(fields) > maxFieldsCount {
-		return fmt.Errorf("too many fields (%d). Maximum allowed: %d", len(fields), maxFieldsCount)
+		return newError(ErrInvalidFields, "too many fields (%d). Maximum allowed: %d", len(fields), maxFieldsCount)
 	}
 
 	for key := range fields {
-		key = strings.TrimSpace(key)
-		if key == "" {
-			return fmt.Errorf("field key cannot be empty")
+		trimmed := strings.TrimSpace(key)
+		if trimmed == "" {
+			return newError(ErrInvalidFields, "field key %q cannot be empty", key)
 		}
 
-		if len(key) > maxFieldKeyLen {
-			return fmt.Errorf("field key too long (%d chars). Maximum: %d", len(key), maxFieldKeyLen)
+		if len(trimmed) > maxFieldKeyLen {
+			return newError(
+				ErrInvalidFields,
+				"field key %q too long (%d chars). Maximum: %d",
+				trimmed[:keyPreviewLen]+"...",
+				len(trimmed),
+				maxFieldKeyLen,
+			)
 		}
 	}
 
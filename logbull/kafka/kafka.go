@@ -0,0 +1,145 @@
+// Package logbullkafka provides a core.Transport that publishes log batches
+// to a Kafka topic instead of sending them to LogBull over HTTP, for
+// architectures that funnel logs through a message bus before a consumer
+// forwards them to LogBull.
+package logbullkafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+const (
+	defaultWriteTimeout = 10 * time.Second
+
+	// Headers of every message, next to core.BatchIDHeader.
+	ContentTypeHeader = "Content-Type"
+	ProjectIDHeader   = "X-Project-ID"
+)
+
+type Config struct {
+	Brokers []string
+	Topic   string
+
+	// ProjectID is sent as the X-Project-ID header, so consumers know which
+	// project to forward a batch to.
+	ProjectID string
+
+	// Encoder encodes each batch into one message (default:
+	// core.JSONEncoder).
+	Encoder core.Encoder
+
+	// Writer publishes the messages instead of a writer for Brokers, e.g. to
+	// configure TLS, SASL or compression. Topic is used when the writer has
+	// none. The transport does not close it.
+	Writer *kafka.Writer
+}
+
+// messageWriter is the part of *kafka.Writer the transport uses.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Transport publishes each batch as one message.
+type Transport struct {
+	writer messageWriter
+	// owned reports that Close closes writer
+	owned     bool
+	topic     string
+	projectID string
+	encoder   core.Encoder
+}
+
+func NewTransport(config Config) (*Transport, error) {
+	config.Topic = strings.TrimSpace(config.Topic)
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+
+	if config.ProjectID != "" {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	encoder := config.Encoder
+	if encoder == nil {
+		encoder = core.JSONEncoder
+	}
+
+	t := &Transport{projectID: config.ProjectID, encoder: encoder}
+
+	if config.Writer != nil {
+		if config.Writer.Topic == "" {
+			if config.Topic == "" {
+				return nil, errors.New("topic is required when the writer has none")
+			}
+			t.topic = config.Topic
+		}
+		t.writer = config.Writer
+		return t, nil
+	}
+
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("at least one broker is required")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("topic is required")
+	}
+
+	t.writer = &kafka.Writer{
+		Addr:     kafka.TCP(config.Brokers...),
+		Topic:    config.Topic,
+		Balancer: &kafka.LeastBytes{},
+		// Each message already holds a whole batch, so do not wait to fill
+		// a Kafka batch
+		BatchSize:    1,
+		WriteTimeout: defaultWriteTimeout,
+		RequiredAcks: kafka.RequireAll,
+	}
+	t.owned = true
+	return t, nil
+}
+
+// Send publishes logs as one message and returns once Kafka acknowledged it.
+func (t *Transport) Send(ctx context.Context, logs []core.LogEntry) error {
+	data, err := t.encoder.Encode(core.LogBatch{Logs: logs})
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	msg := kafka.Message{
+		Topic: t.topic,
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: ContentTypeHeader, Value: []byte(t.encoder.ContentType())},
+		},
+	}
+	if batchID := core.BatchIDFromContext(ctx); batchID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: core.BatchIDHeader, Value: []byte(batchID)})
+	}
+	if t.projectID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: ProjectIDHeader, Value: []byte(t.projectID)})
+	}
+
+	if err := t.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish batch: %w", err)
+	}
+	return nil
+}
+
+// Close closes the writer created for Config.Brokers. Call it after the
+// logger using the transport is shut down.
+func (t *Transport) Close() error {
+	if !t.owned {
+		return nil
+	}
+	return t.writer.Close()
+}
@@ -0,0 +1,122 @@
+package logbullkafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+type fakeWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestTransport(t *testing.T) {
+	writer := &fakeWriter{}
+	transport := &Transport{
+		writer:    writer,
+		topic:     "logs",
+		projectID: "12345678-1234-1234-1234-123456789012",
+		encoder:   core.JSONEncoder,
+	}
+
+	logger, err := core.NewLogger(core.Config{Transport: transport, ConsoleFormat: core.ConsoleDisabled})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("first", nil)
+	logger.Error("second", map[string]any{"order_id": 7})
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if len(writer.messages) != 1 {
+		t.Fatalf("Published %d messages, want one per batch", len(writer.messages))
+	}
+	msg := writer.messages[0]
+
+	if msg.Topic != "logs" {
+		t.Errorf("Topic = %q, want logs", msg.Topic)
+	}
+	if header(msg, ContentTypeHeader) != "application/json" || header(msg, ProjectIDHeader) != transport.projectID || header(msg, core.BatchIDHeader) == "" {
+		t.Errorf("Headers = %v", msg.Headers)
+	}
+
+	var batch core.LogBatch
+	if err := json.Unmarshal(msg.Value, &batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Logs) != 2 || batch.Logs[0].Message != "first" || batch.Logs[1].Level != "ERROR" {
+		t.Errorf("Batch = %+v", batch)
+	}
+}
+
+func TestTransport_Error(t *testing.T) {
+	writer := &fakeWriter{err: kafka.LeaderNotAvailable}
+	transport := &Transport{writer: writer, encoder: core.JSONEncoder}
+
+	err := transport.Send(context.Background(), []core.LogEntry{{Level: "INFO", Message: "lost"}})
+	if !errors.Is(err, kafka.LeaderNotAvailable) {
+		t.Errorf("Send() error = %v, want the writer error", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"brokers and topic", Config{Brokers: []string{"localhost:9092"}, Topic: "logs"}, false},
+		{"writer with topic", Config{Writer: &kafka.Writer{Topic: "logs"}}, false},
+		{"topic for writer", Config{Writer: &kafka.Writer{}, Topic: "logs"}, false},
+		{"no brokers", Config{Topic: "logs"}, true},
+		{"no topic", Config{Brokers: []string{"localhost:9092"}}, true},
+		{"writer without topic", Config{Writer: &kafka.Writer{}}, true},
+		{"invalid project ID", Config{Brokers: []string{"localhost:9092"}, Topic: "logs", ProjectID: "x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTransport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if transport != nil {
+				transport.Close()
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@
 //   - Standard library slog integration with SlogHandler
 //   - Uber-go zap integration with ZapCore
 //   - Sirupsen logrus integration with LogrusHook
+//   - Standard library log integration with StdLogWriter
 //
 // All components support asynchronous log sending with automatic batching,
 // context management, and thread-safe operations.
@@ -12,17 +13,115 @@ package logbull
 
 import (
 	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/fields"
 	"github.com/logbull/logbull-go/logbull/handlers"
 )
 
 type (
-	Config        = core.Config
-	LogLevel      = core.LogLevel
-	LogEntry      = core.LogEntry
-	LogBullLogger = core.LogBullLogger
-	SlogHandler   = handlers.SlogHandler
-	ZapCore       = handlers.ZapCore
-	LogrusHook    = handlers.LogrusHook
+	Config             = core.Config
+	LogLevel           = core.LogLevel
+	LogEntry           = core.LogEntry
+	RejectedLogEntry   = core.RejectedLogEntry
+	LogBatch           = core.LogBatch
+	ColumnarBatch      = core.ColumnarBatch
+	BatchFormat        = core.BatchFormat
+	LogBullResponse    = core.LogBullResponse
+	Transport          = core.Transport
+	Encoder            = core.Encoder
+	ServerCapabilities = core.ServerCapabilities
+	FallbackWriter     = core.FallbackWriter
+	OverflowPolicy     = core.OverflowPolicy
+	TimestampFormat    = core.TimestampFormat
+	TimestampStrategy  = core.TimestampStrategy
+	LogBullLogger      = core.LogBullLogger
+	Sender             = core.Sender
+	EntryBuilder       = core.EntryBuilder
+	Timer              = core.Timer
+	Stats              = core.Stats
+	ErrorBudget        = core.ErrorBudget
+	BudgetAlert        = core.BudgetAlert
+	ShutdownReport     = core.ShutdownReport
+	ConfigWatcher      = core.ConfigWatcher
+	Schema             = core.Schema
+	FieldType          = core.FieldType
+	FieldKeyPolicy     = core.FieldKeyPolicy
+	Clock              = core.Clock
+	Ticker             = core.Ticker
+	ValidationError    = core.ValidationError
+	RecoverConfig      = core.RecoverConfig
+	Field              = fields.Field
+	SlogHandler        = handlers.SlogHandler
+	ZapCore            = handlers.ZapCore
+	LogrusHook         = handlers.LogrusHook
+	ApexHandler        = handlers.ApexHandler
+	StdLogWriter       = handlers.StdLogWriter
+)
+
+var (
+	ErrQueueFull       = core.ErrQueueFull
+	ErrSenderShutdown  = core.ErrSenderShutdown
+	ErrLoggerFrozen    = core.ErrLoggerFrozen
+	ErrUnauthorized    = core.ErrUnauthorized
+	ErrSchemaViolation = core.ErrSchemaViolation
+	ErrLogPanic        = core.ErrLogPanic
+
+	ErrInvalidProjectID = core.ErrInvalidProjectID
+	ErrInvalidHost      = core.ErrInvalidHost
+	ErrInvalidProxyURL  = core.ErrInvalidProxyURL
+	ErrInvalidAPIKey    = core.ErrInvalidAPIKey
+	ErrEmptyMessage     = core.ErrEmptyMessage
+	ErrMessageTooLong   = core.ErrMessageTooLong
+	ErrInvalidFields    = core.ErrInvalidFields
+)
+
+const BatchIDHeader = core.BatchIDHeader
+
+var (
+	JSONEncoder    = core.JSONEncoder
+	MsgPackEncoder = core.MsgPackEncoder
+	CBOREncoder    = core.CBOREncoder
+)
+
+const (
+	FieldTypeString = core.FieldTypeString
+	FieldTypeNumber = core.FieldTypeNumber
+	FieldTypeBool   = core.FieldTypeBool
+	FieldTypeObject = core.FieldTypeObject
+	FieldTypeArray  = core.FieldTypeArray
+)
+
+const (
+	ProtocolBatch  = core.ProtocolBatch
+	ProtocolNDJSON = core.ProtocolNDJSON
+	ProtocolOTLP   = core.ProtocolOTLP
+)
+
+const (
+	BatchFormatRows     = core.BatchFormatRows
+	BatchFormatColumnar = core.BatchFormatColumnar
+)
+
+const (
+	TimestampUnique   = core.TimestampUnique
+	TimestampSequence = core.TimestampSequence
+)
+
+const (
+	TimestampRFC3339Nano = core.TimestampRFC3339Nano
+	TimestampRFC3339     = core.TimestampRFC3339
+	TimestampEpochMillis = core.TimestampEpochMillis
+)
+
+const (
+	OverflowDropNewest = core.OverflowDropNewest
+	OverflowDropOldest = core.OverflowDropOldest
+	OverflowBlock      = core.OverflowBlock
+)
+
+const (
+	ConsoleText     = core.ConsoleText
+	ConsoleJSON     = core.ConsoleJSON
+	ConsoleDisabled = core.ConsoleDisabled
 )
 
 const (
@@ -34,8 +133,57 @@ const (
 )
 
 var (
-	NewLogger      = core.NewLogger
-	NewSlogHandler = handlers.NewSlogHandler
-	NewZapCore     = handlers.NewZapCore
-	NewLogrusHook  = handlers.NewLogrusHook
+	NewLogger       = core.NewLogger
+	NewEntry        = core.NewEntry
+	NewSlogHandler  = handlers.NewSlogHandler
+	NewZapCore      = handlers.NewZapCore
+	NewLogrusHook   = handlers.NewLogrusHook
+	NewApexHandler  = handlers.NewApexHandler
+	NewStdLogWriter = handlers.NewStdLogWriter
+
+	NewSlogHandlerFromLogger  = handlers.NewSlogHandlerFromLogger
+	NewZapCoreFromLogger      = handlers.NewZapCoreFromLogger
+	NewLogrusHookFromLogger   = handlers.NewLogrusHookFromLogger
+	NewApexHandlerFromLogger  = handlers.NewApexHandlerFromLogger
+	NewStdLogWriterFromLogger = handlers.NewStdLogWriterFromLogger
+	AsSlogHandler             = handlers.AsSlogHandler
+	ConfigFromEnv             = core.ConfigFromEnv
+	ConfigFromFile            = core.ConfigFromFile
+	WatchConfigFile           = core.WatchConfigFile
+	ValidateConfig            = core.ValidateConfig
+	ValidateConfigOnline      = core.ValidateConfigOnline
+	ParseLevel                = core.ParseLevel
+	DefaultFingerprint        = core.DefaultFingerprint
+
+	NewSystemFallback = core.NewSystemFallback
+
+	ContextWithLogger    = core.ContextWithLogger
+	LoggerFromContext    = core.LoggerFromContext
+	ContextWithFields    = core.ContextWithFields
+	FieldsFromContext    = core.FieldsFromContext
+	ContextWithRequestID = core.ContextWithRequestID
+	RequestIDFromContext = core.RequestIDFromContext
+	BatchIDFromContext   = core.BatchIDFromContext
+	Go                   = core.Go
+
+	RecoverAndLog           = core.RecoverAndLog
+	RecoverAndLogWithConfig = core.RecoverAndLogWithConfig
+	RecoverMiddleware       = core.RecoverMiddleware
+
+	FlushAll           = core.FlushAll
+	ActiveSenders      = core.ActiveSenders
+	ShutdownAll        = core.ShutdownAll
+	InstallSignalFlush = core.InstallSignalFlush
+
+	String   = fields.String
+	Int      = fields.Int
+	Int64    = fields.Int64
+	Uint64   = fields.Uint64
+	Float64  = fields.Float64
+	Bool     = fields.Bool
+	Duration = fields.Duration
+	Time     = fields.Time
+	Err      = fields.Err
+	Any      = fields.Any
+	Object   = fields.Object
 )
@@ -0,0 +1,106 @@
+package logbulltest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+func TestClock_Timestamps(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	logger, recorder := NewLogger(t, core.Config{Clock: clock})
+
+	logger.Info("first", nil)
+	logger.Info("second", nil)
+	clock.Advance(time.Minute)
+	logger.Info("third", nil)
+
+	entries := recorder.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Entries() returned %d entries, want 3", len(entries))
+	}
+
+	want := []string{
+		"2024-03-01T12:00:00.000000000Z",
+		"2024-03-01T12:00:00.000000001Z",
+		"2024-03-01T12:01:00.000000000Z",
+	}
+	for i, entry := range entries {
+		if entry.Timestamp != want[i] {
+			t.Errorf("entries[%d].Timestamp = %s, want %s", i, entry.Timestamp, want[i])
+		}
+	}
+}
+
+func TestClock_DrivesBatchTicker(t *testing.T) {
+	clock := NewClock(time.Now())
+	recorder := NewRecorder()
+
+	logger, err := core.NewLogger(core.Config{
+		Transport:     recorder,
+		Clock:         clock,
+		ConsoleFormat: core.ConsoleDisabled,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	logger.Info("partial batch", nil)
+
+	// The batch processor starts its ticker asynchronously, so keep
+	// advancing until the tick it registered delivers the batch.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(recorder.Entries()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Partial batch was not delivered on tick")
+		}
+		clock.Advance(time.Second)
+	}
+}
+
+func TestClock_TickerStopAndReset(t *testing.T) {
+	clock := NewClock(time.Unix(0, 0))
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatal("Ticker fired before its period")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case now := <-ticker.C():
+		if !now.Equal(time.Unix(1, 0)) {
+			t.Errorf("Tick time = %v, want %v", now, time.Unix(1, 0))
+		}
+	default:
+		t.Fatal("Ticker did not fire after its period")
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Fatal("Stopped ticker fired")
+	default:
+	}
+
+	ticker.Reset(2 * time.Second)
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("Reset ticker fired before its new period")
+	default:
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.C():
+	default:
+		t.Fatal("Reset ticker did not fire")
+	}
+}
@@ -0,0 +1,119 @@
+// Package logbulltest provides an in-memory Recorder for unit-testing code
+// that logs through LogBull, without running an HTTP server.
+//
+//	logger, recorder := logbulltest.NewLogger(t, logbull.Config{})
+//	checkout(logger)
+//	if !recorder.Contains(logbull.ERROR, "payment failed") {
+//		t.Error("expected payment failure to be logged")
+//	}
+package logbulltest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+const flushTimeout = 5 * time.Second
+
+// Recorder is a core.Transport that keeps every delivered entry in memory.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []core.LogEntry
+	flush   func(ctx context.Context) error
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// NewLogger returns a logger that records into a new Recorder. Console output
+// is disabled unless config sets ConsoleFormat, and the logger is shut down
+// when the test ends.
+func NewLogger(t testing.TB, config core.Config) (*core.LogBullLogger, *Recorder) {
+	t.Helper()
+
+	recorder := NewRecorder()
+	config.Transport = recorder
+	if config.ConsoleFormat == "" {
+		config.ConsoleFormat = core.ConsoleDisabled
+	}
+	if config.LogLevel == "" {
+		config.LogLevel = core.DEBUG
+	}
+
+	logger, err := core.NewLogger(config)
+	if err != nil {
+		t.Fatalf("logbulltest: NewLogger() error = %v", err)
+	}
+	t.Cleanup(logger.Shutdown)
+
+	recorder.flush = logger.FlushSync
+	return logger, recorder
+}
+
+func (r *Recorder) Send(_ context.Context, logs []core.LogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, log := range logs {
+		fields := make(map[string]any, len(log.Fields))
+		for key, value := range log.Fields {
+			fields[key] = value
+		}
+		log.Fields = fields
+		r.entries = append(r.entries, log)
+	}
+	return nil
+}
+
+// Entries returns a copy of the recorded entries in delivery order. For a
+// Recorder created by NewLogger, queued logs are flushed first.
+func (r *Recorder) Entries() []core.LogEntry {
+	if r.flush != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		_ = r.flush(ctx)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]core.LogEntry(nil), r.entries...)
+}
+
+// Contains reports whether an entry with the given level has a message
+// containing substr.
+func (r *Recorder) Contains(level core.LogLevel, substr string) bool {
+	return len(r.Find(level, substr)) > 0
+}
+
+// Find returns the entries with the given level whose message contains
+// substr. An empty level matches every level.
+func (r *Recorder) Find(level core.LogLevel, substr string) []core.LogEntry {
+	var found []core.LogEntry
+	for _, entry := range r.Entries() {
+		if level != "" && entry.Level != level.String() {
+			continue
+		}
+		if strings.Contains(entry.Message, substr) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func (r *Recorder) Reset() {
+	if r.flush != nil {
+		r.Entries()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
@@ -0,0 +1,67 @@
+package logbulltest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/handlers"
+)
+
+func TestNewLogger(t *testing.T) {
+	logger, recorder := NewLogger(t, core.Config{})
+
+	logger.WithField("order_id", 7).Info("Order created", nil)
+	logger.WithError(errors.New("card declined")).Error("Payment failed", map[string]any{"attempt": 2})
+	logger.Debug("Cache miss", nil)
+
+	entries := recorder.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Entries() returned %d entries, want 3", len(entries))
+	}
+	if entries[0].Fields["order_id"] != 7 {
+		t.Errorf("order_id = %v, want 7", entries[0].Fields["order_id"])
+	}
+
+	if !recorder.Contains(core.ERROR, "Payment") {
+		t.Error("Contains(ERROR, Payment) = false, want true")
+	}
+	if recorder.Contains(core.INFO, "Payment") {
+		t.Error("Contains(INFO, Payment) = true, want false")
+	}
+
+	found := recorder.Find(core.ERROR, "failed")
+	if len(found) != 1 || found[0].Fields["error"] != "card declined" {
+		t.Errorf("Find() = %v", found)
+	}
+	if len(recorder.Find("", "")) != 3 {
+		t.Error("Find() with empty level should match all entries")
+	}
+
+	recorder.Reset()
+	if len(recorder.Entries()) != 0 {
+		t.Error("Reset() should clear recorded entries")
+	}
+}
+
+func TestRecorder_WithHandler(t *testing.T) {
+	recorder := NewRecorder()
+
+	handler, err := handlers.NewSlogHandler(core.Config{Transport: recorder})
+	if err != nil {
+		t.Fatalf("NewSlogHandler() error = %v", err)
+	}
+	defer handler.Shutdown()
+
+	slog.New(handler).Warn("Disk almost full", "percent", 91)
+
+	if err := handler.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if !recorder.Contains(core.WARNING, "Disk") {
+		t.Errorf("Entries() = %v, want the slog record", recorder.Entries())
+	}
+}
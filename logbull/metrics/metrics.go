@@ -0,0 +1,112 @@
+// Package logbullmetrics exposes LogBull client internals as Prometheus
+// metrics, so alerts can fire when the client drops logs or fails to send.
+package logbullmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+// StatsSource is implemented by LogBullLogger and every handler.
+type StatsSource interface {
+	Stats() core.Stats
+}
+
+type Config struct {
+	// ConstLabels are added to every metric, e.g. to tell several loggers of
+	// one process apart.
+	ConstLabels prometheus.Labels
+}
+
+// Collector is a prometheus.Collector reading a StatsSource on each scrape.
+type Collector struct {
+	source StatsSource
+
+	enqueued        *prometheus.Desc
+	dropped         *prometheus.Desc
+	batchesSent     *prometheus.Desc
+	logsSent        *prometheus.Desc
+	rejected        *prometheus.Desc
+	sendErrors      *prometheus.Desc
+	deferredFlushes *prometheus.Desc
+	deduped         *prometheus.Desc
+	suppressed      *prometheus.Desc
+	expired         *prometheus.Desc
+	hedged          *prometheus.Desc
+	queueDepth      *prometheus.Desc
+	pendingBatches  *prometheus.Desc
+	activeWorkers   *prometheus.Desc
+}
+
+func NewCollector(source StatsSource) *Collector {
+	return NewCollectorWithConfig(source, Config{})
+}
+
+func NewCollectorWithConfig(source StatsSource, config Config) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name, help, nil, config.ConstLabels)
+	}
+
+	return &Collector{
+		source: source,
+
+		enqueued:        desc("logbull_logs_enqueued_total", "Logs accepted into the send queue."),
+		dropped:         desc("logbull_logs_dropped_total", "Logs dropped before delivery."),
+		batchesSent:     desc("logbull_batches_sent_total", "Batches delivered to the LogBull server or transport."),
+		logsSent:        desc("logbull_logs_sent_total", "Logs in delivered batches."),
+		rejected:        desc("logbull_logs_rejected_total", "Logs in delivered batches rejected by the server."),
+		sendErrors:      desc("logbull_send_errors_total", "Batches that failed to be delivered."),
+		deferredFlushes: desc("logbull_deferred_flushes_total", "Flushes deferred because all send workers were busy."),
+		deduped:         desc("logbull_logs_deduplicated_total", "Duplicate logs collapsed into repeat_count summaries."),
+		suppressed:      desc("logbull_logs_suppressed_total", "Logs suppressed by per-level rate limits."),
+		expired:         desc("logbull_logs_expired_total", "Logs dropped after waiting longer than MaxLogAge."),
+		hedged:          desc("logbull_requests_hedged_total", "Second copies of slow batch requests sent for HedgeAfter."),
+		queueDepth:      desc("logbull_queue_depth", "Logs waiting in the send queue."),
+		pendingBatches:  desc("logbull_pending_batches", "Batches waiting for a free send worker."),
+		activeWorkers:   desc("logbull_active_workers", "Batches currently being delivered."),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enqueued
+	ch <- c.dropped
+	ch <- c.batchesSent
+	ch <- c.logsSent
+	ch <- c.rejected
+	ch <- c.sendErrors
+	ch <- c.deferredFlushes
+	ch <- c.deduped
+	ch <- c.suppressed
+	ch <- c.expired
+	ch <- c.hedged
+	ch <- c.queueDepth
+	ch <- c.pendingBatches
+	ch <- c.activeWorkers
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+
+	counter := func(desc *prometheus.Desc, value uint64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value))
+	}
+	gauge := func(desc *prometheus.Desc, value int) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+
+	counter(c.enqueued, stats.EnqueuedLogs)
+	counter(c.dropped, stats.DroppedLogs)
+	counter(c.batchesSent, stats.SentBatches)
+	counter(c.logsSent, stats.SentLogs)
+	counter(c.rejected, stats.RejectedLogs)
+	counter(c.sendErrors, stats.SendErrors)
+	counter(c.deferredFlushes, stats.DeferredFlushes)
+	counter(c.deduped, stats.DedupedLogs)
+	counter(c.suppressed, stats.SuppressedLogs)
+	counter(c.expired, stats.ExpiredLogs)
+	counter(c.hedged, stats.HedgedRequests)
+	gauge(c.queueDepth, stats.QueuedLogs)
+	gauge(c.pendingBatches, stats.PendingBatches)
+	gauge(c.activeWorkers, stats.ActiveWorkers)
+}
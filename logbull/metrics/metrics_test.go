@@ -0,0 +1,103 @@
+package logbullmetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+type fixedStats core.Stats
+
+func (f fixedStats) Stats() core.Stats {
+	return core.Stats(f)
+}
+
+func TestCollector(t *testing.T) {
+	collector := NewCollectorWithConfig(fixedStats{
+		QueuedLogs:      12,
+		PendingBatches:  2,
+		ActiveWorkers:   3,
+		MaxWorkers:      10,
+		EnqueuedLogs:    1500,
+		DroppedLogs:     7,
+		DeferredFlushes: 1,
+		DedupedLogs:     6,
+		SuppressedLogs:  8,
+		ExpiredLogs:     9,
+		SentBatches:     4,
+		SentLogs:        1400,
+		RejectedLogs:    11,
+		SendErrors:      5,
+		HedgedRequests:  13,
+	}, Config{ConstLabels: prometheus.Labels{"logger": "app"}})
+
+	expected := `
+# HELP logbull_active_workers Batches currently being delivered.
+# TYPE logbull_active_workers gauge
+logbull_active_workers{logger="app"} 3
+# HELP logbull_batches_sent_total Batches delivered to the LogBull server or transport.
+# TYPE logbull_batches_sent_total counter
+logbull_batches_sent_total{logger="app"} 4
+# HELP logbull_deferred_flushes_total Flushes deferred because all send workers were busy.
+# TYPE logbull_deferred_flushes_total counter
+logbull_deferred_flushes_total{logger="app"} 1
+# HELP logbull_logs_deduplicated_total Duplicate logs collapsed into repeat_count summaries.
+# TYPE logbull_logs_deduplicated_total counter
+logbull_logs_deduplicated_total{logger="app"} 6
+# HELP logbull_logs_dropped_total Logs dropped before delivery.
+# TYPE logbull_logs_dropped_total counter
+logbull_logs_dropped_total{logger="app"} 7
+# HELP logbull_logs_expired_total Logs dropped after waiting longer than MaxLogAge.
+# TYPE logbull_logs_expired_total counter
+logbull_logs_expired_total{logger="app"} 9
+# HELP logbull_logs_enqueued_total Logs accepted into the send queue.
+# TYPE logbull_logs_enqueued_total counter
+logbull_logs_enqueued_total{logger="app"} 1500
+# HELP logbull_logs_rejected_total Logs in delivered batches rejected by the server.
+# TYPE logbull_logs_rejected_total counter
+logbull_logs_rejected_total{logger="app"} 11
+# HELP logbull_logs_sent_total Logs in delivered batches.
+# TYPE logbull_logs_sent_total counter
+logbull_logs_sent_total{logger="app"} 1400
+# HELP logbull_logs_suppressed_total Logs suppressed by per-level rate limits.
+# TYPE logbull_logs_suppressed_total counter
+logbull_logs_suppressed_total{logger="app"} 8
+# HELP logbull_pending_batches Batches waiting for a free send worker.
+# TYPE logbull_pending_batches gauge
+logbull_pending_batches{logger="app"} 2
+# HELP logbull_queue_depth Logs waiting in the send queue.
+# TYPE logbull_queue_depth gauge
+logbull_queue_depth{logger="app"} 12
+# HELP logbull_requests_hedged_total Second copies of slow batch requests sent for HedgeAfter.
+# TYPE logbull_requests_hedged_total counter
+logbull_requests_hedged_total{logger="app"} 13
+# HELP logbull_send_errors_total Batches that failed to be delivered.
+# TYPE logbull_send_errors_total counter
+logbull_send_errors_total{logger="app"} 5
+`
+
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_Register(t *testing.T) {
+	logger, err := core.NewLogger(core.Config{ConsoleFormat: core.ConsoleDisabled})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(NewCollector(logger)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if count := testutil.CollectAndCount(registry); count != 14 {
+		t.Errorf("CollectAndCount() = %d, want 14", count)
+	}
+}
@@ -0,0 +1,107 @@
+// Package logbullchi provides net/http middleware that logs every HTTP request
+// through LogBull and exposes a request-scoped logger to handlers. It works
+// with chi routers and with any http.Handler, including http.ServeMux.
+package logbullchi
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/middleware/internal/httplog"
+)
+
+// BodyConfig enables attaching request and response bodies to the logged
+// requests.
+type BodyConfig = httplog.BodyConfig
+
+type Config struct {
+	// Skipper excludes matching requests from logging. The request-scoped
+	// logger is still attached so handlers can use FromContext.
+	Skipper func(r *http.Request) bool
+
+	// Body captures request and response bodies (default: none).
+	Body BodyConfig
+}
+
+func Middleware(logger *core.LogBullLogger) func(http.Handler) http.Handler {
+	return MiddlewareWithConfig(logger, Config{})
+}
+
+func MiddlewareWithConfig(logger *core.LogBullLogger, config Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(httplog.RequestIDHeader)
+			if requestID == "" {
+				requestID = w.Header().Get(httplog.RequestIDHeader)
+			}
+
+			requestLogger := logger.WithFields(httplog.Request{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				RemoteIP:  remoteIP(r),
+				UserAgent: r.UserAgent(),
+				RequestID: requestID,
+			}.Fields())
+
+			ctx := core.ContextWithLogger(r.Context(), requestLogger)
+			if requestID != "" {
+				ctx = core.ContextWithRequestID(ctx, requestID)
+			}
+			r = r.WithContext(ctx)
+			recorder := &responseRecorder{ResponseWriter: w}
+
+			var requestBody []byte
+			if config.Body.Request && r.Body != nil && r.Body != http.NoBody && config.Body.Captures(r.Header.Get("Content-Type")) {
+				requestBody, r.Body = httplog.PeekBody(r.Body, config.Body.Limit())
+			}
+			if config.Body.Response {
+				recorder.bodyConfig = &config.Body
+				recorder.body = httplog.NewBodyBuffer(config.Body.Limit())
+			}
+
+			next.ServeHTTP(wrapResponseWriter(recorder), r)
+
+			if config.Skipper == nil || !config.Skipper(r) {
+				// chi only knows the full pattern once routing has finished
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					if route := rctx.RoutePattern(); route != "" {
+						requestLogger = requestLogger.WithField("http.route", route)
+					}
+				}
+
+				if config.Body.Enabled() {
+					var responseBody []byte
+					if recorder.body != nil {
+						responseBody = recorder.body.Bytes()
+					}
+					bodies := config.Body.Fields(r.Header.Get("Content-Type"), requestBody, w.Header().Get("Content-Type"), responseBody)
+					if len(bodies) > 0 {
+						requestLogger = requestLogger.WithFields(bodies)
+					}
+				}
+
+				httplog.LogCompleted(requestLogger, recorder.statusCode(), recorder.size, start, nil)
+			}
+		})
+	}
+}
+
+// FromContext returns the request-scoped logger attached by Middleware, or
+// nil when the middleware is not installed.
+func FromContext(r *http.Request) *core.LogBullLogger {
+	return core.LoggerFromContext(r.Context())
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,240 @@
+package logbullchi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func TestMiddleware_Chi(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	r := chi.NewRouter()
+	r.Use(Middleware(logger))
+
+	var handlerLogger, requestLogger *core.LogBullLogger
+	var requestID string
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerLogger = FromContext(r)
+		requestLogger = core.LoggerFromContext(r.Context())
+		requestID = core.RequestIDFromContext(r.Context())
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if handlerLogger == nil {
+		t.Error("Expected FromContext to return the request logger")
+	}
+	if requestLogger != handlerLogger {
+		t.Error("Expected the request context to carry the same logger")
+	}
+	if requestID != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want req-1", requestID)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "INFO" {
+		t.Errorf("Level = %s, want INFO", entry.Level)
+	}
+
+	expected := map[string]any{
+		"http.method":    "GET",
+		"http.path":      "/users/42",
+		"http.route":     "/users/{id}",
+		"http.status":    200,
+		"http.bytes_out": int64(2),
+		"http.remote_ip": "192.0.2.1",
+		"request_id":     "req-1",
+	}
+	for key, value := range expected {
+		if entry.Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry.Fields[key], value)
+		}
+	}
+	if _, ok := entry.Fields["duration_ms"]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+}
+
+func TestMiddleware_ServeMux(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	handler := Middleware(logger)(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].Level != "WARNING" || entries[0].Fields["http.status"] != 404 {
+		t.Errorf("First entry = %s %v, want WARNING 404", entries[0].Level, entries[0].Fields["http.status"])
+	}
+	if entries[1].Level != "ERROR" || entries[1].Fields["http.status"] != 503 {
+		t.Errorf("Second entry = %s %v, want ERROR 503", entries[1].Level, entries[1].Fields["http.status"])
+	}
+	if _, ok := entries[0].Fields["http.route"]; ok {
+		t.Error("Expected no http.route outside chi")
+	}
+}
+
+func TestMiddleware_PreservesOptionalInterfaces(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	var flushed, hijackable bool
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijackable = w.(http.Hijacker)
+		if f, ok := w.(http.Flusher); ok {
+			w.Write([]byte("chunk"))
+			f.Flush()
+			flushed = true
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if !flushed || !rec.Flushed {
+		t.Error("Expected the wrapped writer to forward Flush")
+	}
+	if hijackable {
+		t.Error("Expected no http.Hijacker when the original writer lacks it")
+	}
+
+	if err := http.NewResponseController(&responseRecorder{ResponseWriter: rec}).Flush(); err != nil {
+		t.Errorf("ResponseController.Flush() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Fields["http.bytes_out"] != int64(5) {
+		t.Errorf("Entries = %+v, want one entry with 5 bytes out", entries)
+	}
+}
+
+func TestMiddleware_ReadFrom(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, strings.NewReader("streamed body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Body.String() != "streamed body" {
+		t.Errorf("Body = %q", rec.Body.String())
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Fields["http.bytes_out"] != int64(13) {
+		t.Errorf("Entries = %+v, want one entry with 13 bytes out", entries)
+	}
+}
+
+func TestMiddlewareWithConfig_Skipper(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	r := chi.NewRouter()
+	r.Use(MiddlewareWithConfig(logger, Config{
+		Skipper: func(r *http.Request) bool { return r.URL.Path == "/health" },
+	}))
+
+	var handlerLogger *core.LogBullLogger
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		handlerLogger = FromContext(r)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if handlerLogger == nil {
+		t.Error("Expected the request logger to be attached for skipped requests")
+	}
+
+	if entries := recorder.Entries(); len(entries) != 0 {
+		t.Errorf("Expected no log entries for skipped request, got %d", len(entries))
+	}
+}
+
+func TestMiddlewareWithConfig_Body(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	var received string
+	handler := MiddlewareWithConfig(logger, Config{
+		Body: BodyConfig{Request: true, Response: true},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"abc","id":1}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"ann","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if received != `{"user":"ann","password":"hunter2"}` {
+		t.Errorf("Handler read %q, want the whole request body", received)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if body := entries[0].Fields["http.request_body"]; body != `{"password":"[REDACTED]","user":"ann"}` {
+		t.Errorf("http.request_body = %v", body)
+	}
+	if body := entries[0].Fields["http.response_body"]; body != `{"id":1,"token":"[REDACTED]"}` {
+		t.Errorf("http.response_body = %v", body)
+	}
+}
+
+func TestMiddlewareWithConfig_BodyReadFrom(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	handler := MiddlewareWithConfig(logger, Config{
+		Body: BodyConfig{Response: true, MaxBytes: 20, ContentTypes: []string{"text/plain"}},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.Copy(w, strings.NewReader("a rather long streamed body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Body.String() != "a rather long streamed body" {
+		t.Errorf("Body = %q", rec.Body.String())
+	}
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Fields["http.response_body"] != "a rath...[truncated]" {
+		t.Errorf("Entries = %+v, want the response body cut to 20 bytes", entries)
+	}
+}
+
+func TestFromContext_WithoutMiddleware(t *testing.T) {
+	if logger := FromContext(httptest.NewRequest(http.MethodGet, "/", nil)); logger != nil {
+		t.Errorf("FromContext() = %v, want nil", logger)
+	}
+}
@@ -0,0 +1,164 @@
+package logbullchi
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/logbull/logbull-go/logbull/middleware/internal/httplog"
+)
+
+// responseRecorder captures the status code and body size written by the
+// wrapped handler, and the start of the body when body is set.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+
+	bodyConfig *httplog.BodyConfig
+	body       *httplog.BodyBuffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	// Informational responses are followed by the real status
+	if r.status == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.markWritten()
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	if r.body != nil {
+		r.body.Write(b[:n])
+	}
+	return n, err
+}
+
+// ReadFrom keeps the sendfile fast path of the underlying writer used by
+// io.Copy and http.ServeContent.
+func (r *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.markWritten()
+
+	// Teeing disables sendfile, so only bodies that are logged are teed
+	if r.body != nil && r.bodyConfig.Captures(r.Header().Get("Content-Type")) {
+		src = io.TeeReader(src, r.body)
+	}
+
+	var n int64
+	var err error
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{r.ResponseWriter}, src)
+	}
+	r.size += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) markWritten() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+type writerOnly struct {
+	io.Writer
+}
+
+type flusher struct {
+	*responseRecorder
+}
+
+func (f flusher) Flush() {
+	f.markWritten()
+	f.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct {
+	*responseRecorder
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h.status == 0 {
+		h.status = http.StatusSwitchingProtocols
+	}
+	return h.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type pusher struct {
+	*responseRecorder
+}
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrapResponseWriter returns a writer exposing exactly the optional
+// interfaces of the original one, so handlers probing for http.Flusher or
+// http.Hijacker keep behaving as without the middleware.
+func wrapResponseWriter(r *responseRecorder) http.ResponseWriter {
+	_, canFlush := r.ResponseWriter.(http.Flusher)
+	_, canHijack := r.ResponseWriter.(http.Hijacker)
+	_, canPush := r.ResponseWriter.(http.Pusher)
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{r, flusher{r}, hijacker{r}, pusher{r}}
+	case canFlush && canHijack:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+		}{r, flusher{r}, hijacker{r}}
+	case canFlush && canPush:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Pusher
+		}{r, flusher{r}, pusher{r}}
+	case canHijack && canPush:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+			http.Pusher
+		}{r, hijacker{r}, pusher{r}}
+	case canFlush:
+		return struct {
+			*responseRecorder
+			http.Flusher
+		}{r, flusher{r}}
+	case canHijack:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+		}{r, hijacker{r}}
+	case canPush:
+		return struct {
+			*responseRecorder
+			http.Pusher
+		}{r, pusher{r}}
+	default:
+		return r
+	}
+}
@@ -0,0 +1,131 @@
+// Package logbullecho provides Echo middleware that logs every HTTP request
+// through LogBull and exposes a request-scoped logger to handlers.
+package logbullecho
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/middleware/internal/httplog"
+)
+
+const loggerKey = "logbull.logger"
+
+// BodyConfig enables attaching request and response bodies to the logged
+// requests.
+type BodyConfig = httplog.BodyConfig
+
+type Config struct {
+	// Skipper excludes matching requests from logging. The request-scoped
+	// logger is still attached so handlers can use FromContext.
+	Skipper func(c echo.Context) bool
+
+	// Body captures request and response bodies (default: none).
+	Body BodyConfig
+}
+
+func Middleware(logger *core.LogBullLogger) echo.MiddlewareFunc {
+	return MiddlewareWithConfig(logger, Config{})
+}
+
+func MiddlewareWithConfig(logger *core.LogBullLogger, config Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			requestID := req.Header.Get(httplog.RequestIDHeader)
+			if requestID == "" {
+				requestID = c.Response().Header().Get(httplog.RequestIDHeader)
+			}
+
+			requestLogger := logger.WithFields(httplog.Request{
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Route:     c.Path(),
+				RemoteIP:  c.RealIP(),
+				UserAgent: req.UserAgent(),
+				RequestID: requestID,
+			}.Fields())
+
+			c.Set(loggerKey, requestLogger)
+			ctx := core.ContextWithLogger(req.Context(), requestLogger)
+			if requestID != "" {
+				ctx = core.ContextWithRequestID(ctx, requestID)
+			}
+			req = req.WithContext(ctx)
+
+			var requestBody []byte
+			if config.Body.Request && req.Body != nil && req.Body != http.NoBody && config.Body.Captures(req.Header.Get(echo.HeaderContentType)) {
+				requestBody, req.Body = httplog.PeekBody(req.Body, config.Body.Limit())
+			}
+			var responseBody *httplog.BodyBuffer
+			if config.Body.Response {
+				responseBody = httplog.NewBodyBuffer(config.Body.Limit())
+				c.Response().Writer = &bodyWriter{ResponseWriter: c.Response().Writer, body: responseBody}
+			}
+			c.SetRequest(req)
+
+			err := next(c)
+			if err != nil {
+				// Let Echo write the error response so the logged status matches
+				c.Error(err)
+			}
+
+			if config.Skipper == nil || !config.Skipper(c) {
+				res := c.Response()
+				if config.Body.Enabled() {
+					var captured []byte
+					if responseBody != nil {
+						captured = responseBody.Bytes()
+					}
+					bodies := config.Body.Fields(req.Header.Get(echo.HeaderContentType), requestBody, res.Header().Get(echo.HeaderContentType), captured)
+					if len(bodies) > 0 {
+						requestLogger = requestLogger.WithFields(bodies)
+					}
+				}
+				httplog.LogCompleted(requestLogger, res.Status, res.Size, start, err)
+			}
+
+			return err
+		}
+	}
+}
+
+// FromContext returns the request-scoped logger attached by Middleware, or
+// nil when the middleware is not installed.
+func FromContext(c echo.Context) *core.LogBullLogger {
+	logger, _ := c.Get(loggerKey).(*core.LogBullLogger)
+	return logger
+}
+
+// bodyWriter copies the start of the response body into body. Echo's
+// Response reaches Flush and Hijack through it, so it forwards both.
+type bodyWriter struct {
+	http.ResponseWriter
+	body *httplog.BodyBuffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
+
+func (w *bodyWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *bodyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,244 @@
+package logbullecho
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+type capturedLogs struct {
+	mu      sync.Mutex
+	entries []core.LogEntry
+}
+
+func (c *capturedLogs) all() []core.LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]core.LogEntry(nil), c.entries...)
+}
+
+func newTestLogger(t *testing.T) (*core.LogBullLogger, *capturedLogs) {
+	t.Helper()
+
+	captured := &capturedLogs{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch core.LogBatch
+		json.Unmarshal(body, &batch)
+
+		captured.mu.Lock()
+		captured.entries = append(captured.entries, batch.Logs...)
+		captured.mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	t.Cleanup(server.Close)
+
+	logger, err := core.NewLogger(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		LogLevel:  core.DEBUG,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	t.Cleanup(logger.Shutdown)
+
+	return logger, captured
+}
+
+func TestMiddleware(t *testing.T) {
+	logger, captured := newTestLogger(t)
+
+	e := echo.New()
+	e.Use(Middleware(logger))
+
+	var handlerLogger, requestLogger *core.LogBullLogger
+	e.GET("/users/:id", func(c echo.Context) error {
+		handlerLogger = FromContext(c)
+		requestLogger = core.LoggerFromContext(c.Request().Context())
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if handlerLogger == nil {
+		t.Error("Expected FromContext to return the request logger")
+	}
+	if requestLogger != handlerLogger {
+		t.Error("Expected the request context to carry the same logger")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := captured.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "INFO" {
+		t.Errorf("Level = %s, want INFO", entry.Level)
+	}
+
+	expected := map[string]any{
+		"http.method": "GET",
+		"http.path":   "/users/42",
+		"http.route":  "/users/:id",
+		"http.status": float64(200),
+		"request_id":  "req-1",
+	}
+	for key, value := range expected {
+		if entry.Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry.Fields[key], value)
+		}
+	}
+	if _, ok := entry.Fields["duration_ms"]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+}
+
+func TestMiddleware_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		level  string
+	}{
+		{"client error", echo.NewHTTPError(http.StatusNotFound, "missing"), 404, "WARNING"},
+		{"server error", errors.New("boom"), 500, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, captured := newTestLogger(t)
+
+			e := echo.New()
+			e.Use(Middleware(logger))
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("Response status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if err := logger.FlushSync(context.Background()); err != nil {
+				t.Fatalf("FlushSync() error = %v", err)
+			}
+
+			entries := captured.all()
+			if len(entries) != 1 {
+				t.Fatalf("Expected 1 log entry, got %d", len(entries))
+			}
+			if entries[0].Level != tt.level {
+				t.Errorf("Level = %s, want %s", entries[0].Level, tt.level)
+			}
+			if entries[0].Fields["http.status"] != float64(tt.status) {
+				t.Errorf("http.status = %v, want %d", entries[0].Fields["http.status"], tt.status)
+			}
+			if _, ok := entries[0].Fields["error"]; !ok {
+				t.Error("Expected error field")
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithConfig_Skipper(t *testing.T) {
+	logger, captured := newTestLogger(t)
+
+	e := echo.New()
+	e.Use(MiddlewareWithConfig(logger, Config{
+		Skipper: func(c echo.Context) bool { return c.Path() == "/health" },
+	}))
+
+	var handlerLogger *core.LogBullLogger
+	e.GET("/health", func(c echo.Context) error {
+		handlerLogger = FromContext(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if handlerLogger == nil {
+		t.Error("Expected the request logger to be attached for skipped requests")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	if entries := captured.all(); len(entries) != 0 {
+		t.Errorf("Expected no log entries for skipped request, got %d", len(entries))
+	}
+}
+
+func TestMiddlewareWithConfig_Body(t *testing.T) {
+	logger, captured := newTestLogger(t)
+
+	e := echo.New()
+	e.Use(MiddlewareWithConfig(logger, Config{
+		Body: BodyConfig{Request: true, Response: true},
+	}))
+
+	var received string
+	e.POST("/login", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		received = string(body)
+		return c.JSON(http.StatusOK, map[string]any{"token": "abc", "id": 1})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"ann","password":"hunter2"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if received != `{"user":"ann","password":"hunter2"}` {
+		t.Errorf("Handler read %q, want the whole request body", received)
+	}
+	if !strings.Contains(rec.Body.String(), `"token":"abc"`) {
+		t.Errorf("Response body = %q, want it unchanged", rec.Body.String())
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := captured.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if body := entries[0].Fields["http.request_body"]; body != `{"password":"[REDACTED]","user":"ann"}` {
+		t.Errorf("http.request_body = %v", body)
+	}
+	if body := entries[0].Fields["http.response_body"]; body != `{"id":1,"token":"[REDACTED]"}` {
+		t.Errorf("http.response_body = %v", body)
+	}
+}
+
+func TestFromContext_WithoutMiddleware(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	if logger := FromContext(c); logger != nil {
+		t.Errorf("FromContext() = %v, want nil", logger)
+	}
+}
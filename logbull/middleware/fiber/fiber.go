@@ -0,0 +1,104 @@
+// Package logbullfiber provides Fiber middleware that logs every HTTP request
+// through LogBull and exposes a request-scoped logger to handlers.
+package logbullfiber
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/middleware/internal/httplog"
+)
+
+const loggerKey = "logbull.logger"
+
+// BodyConfig enables attaching request and response bodies to the logged
+// requests.
+type BodyConfig = httplog.BodyConfig
+
+type Config struct {
+	// Skipper excludes matching requests from logging. The request-scoped
+	// logger is still attached so handlers can use FromContext.
+	Skipper func(c *fiber.Ctx) bool
+
+	// Body captures request and response bodies (default: none).
+	Body BodyConfig
+}
+
+func Middleware(logger *core.LogBullLogger) fiber.Handler {
+	return MiddlewareWithConfig(logger, Config{})
+}
+
+func MiddlewareWithConfig(logger *core.LogBullLogger, config Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(httplog.RequestIDHeader)
+		if requestID == "" {
+			requestID = c.GetRespHeader(httplog.RequestIDHeader)
+		}
+
+		// Fiber reuses its buffers after the handler returns, so every string
+		// kept in the logger context is copied
+		request := httplog.Request{
+			Method:    strings.Clone(c.Method()),
+			Path:      strings.Clone(c.Path()),
+			RemoteIP:  strings.Clone(c.IP()),
+			UserAgent: strings.Clone(c.Get(fiber.HeaderUserAgent)),
+			RequestID: strings.Clone(requestID),
+		}
+
+		requestLogger := logger.WithFields(request.Fields())
+
+		c.Locals(loggerKey, requestLogger)
+		ctx := core.ContextWithLogger(c.UserContext(), requestLogger)
+		if request.RequestID != "" {
+			ctx = core.ContextWithRequestID(ctx, request.RequestID)
+		}
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if err != nil {
+			// Let Fiber write the error response so the logged status matches
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		if config.Skipper == nil || !config.Skipper(c) {
+			if route := c.Route().Path; route != "" {
+				requestLogger = requestLogger.WithField("http.route", strings.Clone(route))
+			}
+
+			if config.Body.Enabled() {
+				// Fiber buffers both bodies, so they are cut to the capture limit
+				// here; Fields copies what it keeps
+				limit := config.Body.Limit()
+				request, response := c.Body(), c.Response().Body()
+				bodies := config.Body.Fields(
+					c.Get(fiber.HeaderContentType), request[:min(len(request), limit)],
+					string(c.Response().Header.ContentType()), response[:min(len(response), limit)],
+				)
+				if len(bodies) > 0 {
+					requestLogger = requestLogger.WithFields(bodies)
+				}
+			}
+
+			status := c.Response().StatusCode()
+			size := int64(len(c.Response().Body()))
+			httplog.LogCompleted(requestLogger, status, size, start, err)
+		}
+
+		// The error response has already been written
+		return nil
+	}
+}
+
+// FromContext returns the request-scoped logger attached by Middleware, or
+// nil when the middleware is not installed.
+func FromContext(c *fiber.Ctx) *core.LogBullLogger {
+	logger, _ := c.Locals(loggerKey).(*core.LogBullLogger)
+	return logger
+}
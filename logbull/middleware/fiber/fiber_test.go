@@ -0,0 +1,193 @@
+package logbullfiber
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+func TestMiddleware(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	app := fiber.New()
+	app.Use(Middleware(logger))
+
+	var handlerLogger, requestLogger *core.LogBullLogger
+	var requestID string
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		handlerLogger = FromContext(c)
+		requestLogger = core.LoggerFromContext(c.UserContext())
+		requestID = core.RequestIDFromContext(c.UserContext())
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	if handlerLogger == nil {
+		t.Error("Expected FromContext to return the request logger")
+	}
+	if requestLogger != handlerLogger {
+		t.Error("Expected the user context to carry the same logger")
+	}
+	if requestID != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want req-1", requestID)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "INFO" {
+		t.Errorf("Level = %s, want INFO", entry.Level)
+	}
+
+	expected := map[string]any{
+		"http.method":    "GET",
+		"http.path":      "/users/42",
+		"http.route":     "/users/:id",
+		"http.status":    200,
+		"http.bytes_out": int64(2),
+		"request_id":     "req-1",
+	}
+	for key, value := range expected {
+		if entry.Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry.Fields[key], value)
+		}
+	}
+	if _, ok := entry.Fields["duration_ms"]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+}
+
+func TestMiddleware_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		level  string
+	}{
+		{"client error", fiber.NewError(fiber.StatusNotFound, "missing"), 404, "WARNING"},
+		{"server error", errors.New("boom"), 500, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+			app := fiber.New()
+			app.Use(Middleware(logger))
+			app.Get("/", func(c *fiber.Ctx) error {
+				return tt.err
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("Response status = %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			entries := recorder.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("Expected 1 log entry, got %d", len(entries))
+			}
+			if entries[0].Level != tt.level {
+				t.Errorf("Level = %s, want %s", entries[0].Level, tt.level)
+			}
+			if entries[0].Fields["http.status"] != tt.status {
+				t.Errorf("http.status = %v, want %d", entries[0].Fields["http.status"], tt.status)
+			}
+			if _, ok := entries[0].Fields["error"]; !ok {
+				t.Error("Expected error field")
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithConfig_Skipper(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	app := fiber.New()
+	app.Use(MiddlewareWithConfig(logger, Config{
+		Skipper: func(c *fiber.Ctx) bool { return c.Path() == "/health" },
+	}))
+
+	var handlerLogger *core.LogBullLogger
+	app.Get("/health", func(c *fiber.Ctx) error {
+		handlerLogger = FromContext(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/health", nil)); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	if handlerLogger == nil {
+		t.Error("Expected the request logger to be attached for skipped requests")
+	}
+
+	if entries := recorder.Entries(); len(entries) != 0 {
+		t.Errorf("Expected no log entries for skipped request, got %d", len(entries))
+	}
+}
+
+func TestMiddlewareWithConfig_Body(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	app := fiber.New()
+	app.Use(MiddlewareWithConfig(logger, Config{
+		Body: BodyConfig{Request: true, Response: true},
+	}))
+
+	app.Post("/login", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"token": "abc", "id": 1})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"ann","password":"hunter2"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if body := entries[0].Fields["http.request_body"]; body != `{"password":"[REDACTED]","user":"ann"}` {
+		t.Errorf("http.request_body = %v", body)
+	}
+	if body := entries[0].Fields["http.response_body"]; body != `{"id":1,"token":"[REDACTED]"}` {
+		t.Errorf("http.response_body = %v", body)
+	}
+}
+
+func TestFromContext_WithoutMiddleware(t *testing.T) {
+	app := fiber.New()
+
+	var logger *core.LogBullLogger
+	app.Get("/", func(c *fiber.Ctx) error {
+		logger = FromContext(c)
+		return nil
+	})
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if logger != nil {
+		t.Errorf("FromContext() = %v, want nil", logger)
+	}
+}
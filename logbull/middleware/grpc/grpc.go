@@ -0,0 +1,116 @@
+// Package logbullgrpc provides gRPC server interceptors that log every RPC
+// through LogBull and inject a request-scoped logger into the handler context.
+package logbullgrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+func UnaryServerInterceptor(logger *core.LogBullLogger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+		callLogger := logger.WithFields(callFields(ctx, info.FullMethod, "unary"))
+
+		resp, err := handler(core.ContextWithLogger(ctx, callLogger), req)
+
+		logCall(callLogger, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor(logger *core.LogBullLogger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		ctx := stream.Context()
+		callLogger := logger.WithFields(callFields(ctx, info.FullMethod, "stream"))
+
+		err := handler(srv, &loggedStream{
+			ServerStream: stream,
+			ctx:          core.ContextWithLogger(ctx, callLogger),
+		})
+
+		logCall(callLogger, start, err)
+		return err
+	}
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+func callFields(ctx context.Context, fullMethod string, kind string) map[string]any {
+	fields := map[string]any{
+		"grpc.method": fullMethod,
+		"grpc.type":   kind,
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["grpc.peer"] = p.Addr.String()
+	}
+
+	return fields
+}
+
+func logCall(logger *core.LogBullLogger, start time.Time, err error) {
+	code := status.Code(err)
+
+	fields := map[string]any{
+		"grpc.code":   code.String(),
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	switch levelForCode(code) {
+	case core.ERROR:
+		logger.Error("gRPC call failed", fields)
+	case core.WARNING:
+		logger.Warning("gRPC call finished with client error", fields)
+	default:
+		logger.Info("gRPC call finished", fields)
+	}
+}
+
+func levelForCode(code codes.Code) core.LogLevel {
+	switch code {
+	case codes.OK:
+		return core.INFO
+	case codes.Canceled,
+		codes.InvalidArgument,
+		codes.NotFound,
+		codes.AlreadyExists,
+		codes.PermissionDenied,
+		codes.Unauthenticated,
+		codes.ResourceExhausted,
+		codes.FailedPrecondition,
+		codes.Aborted,
+		codes.OutOfRange:
+		return core.WARNING
+	default:
+		return core.ERROR
+	}
+}
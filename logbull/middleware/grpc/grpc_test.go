@@ -0,0 +1,219 @@
+package logbullgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+type capturedLogs struct {
+	mu      sync.Mutex
+	entries []core.LogEntry
+}
+
+func (c *capturedLogs) all() []core.LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]core.LogEntry(nil), c.entries...)
+}
+
+func newTestLogger(t *testing.T) (*core.LogBullLogger, *capturedLogs) {
+	t.Helper()
+
+	captured := &capturedLogs{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch core.LogBatch
+		json.Unmarshal(body, &batch)
+
+		captured.mu.Lock()
+		captured.entries = append(captured.entries, batch.Logs...)
+		captured.mu.Unlock()
+
+		json.NewEncoder(w).Encode(core.LogBullResponse{Accepted: len(batch.Logs)})
+	}))
+	t.Cleanup(server.Close)
+
+	logger, err := core.NewLogger(core.Config{
+		ProjectID: "12345678-1234-1234-1234-123456789012",
+		Host:      server.URL,
+		LogLevel:  core.DEBUG,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	t.Cleanup(logger.Shutdown)
+
+	return logger, captured
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	logger, captured := newTestLogger(t)
+	interceptor := UnaryServerInterceptor(logger)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000},
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	var handlerLogger *core.LogBullLogger
+	resp, err := interceptor(ctx, "request", info, func(ctx context.Context, req any) (any, error) {
+		handlerLogger = core.LoggerFromContext(ctx)
+		return "response", nil
+	})
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if resp != "response" {
+		t.Errorf("interceptor response = %v, want response", resp)
+	}
+	if handlerLogger == nil {
+		t.Error("Expected a logger in the handler context")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := captured.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "INFO" {
+		t.Errorf("Level = %s, want INFO", entry.Level)
+	}
+	if entry.Fields["grpc.method"] != "/orders.Orders/Get" {
+		t.Errorf("grpc.method = %v", entry.Fields["grpc.method"])
+	}
+	if entry.Fields["grpc.code"] != "OK" {
+		t.Errorf("grpc.code = %v, want OK", entry.Fields["grpc.code"])
+	}
+	if entry.Fields["grpc.peer"] != "10.0.0.1:5000" {
+		t.Errorf("grpc.peer = %v", entry.Fields["grpc.peer"])
+	}
+	if _, ok := entry.Fields["duration_ms"]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+}
+
+func TestUnaryServerInterceptor_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level string
+		code  string
+	}{
+		{"client error", status.Error(codes.NotFound, "missing"), "WARNING", "NotFound"},
+		{"server error", status.Error(codes.Internal, "boom"), "ERROR", "Internal"},
+		{"plain error", errors.New("plain"), "ERROR", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, captured := newTestLogger(t)
+			interceptor := UnaryServerInterceptor(logger)
+			info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+			_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, tt.err
+			})
+			if err != tt.err {
+				t.Errorf("interceptor error = %v, want %v", err, tt.err)
+			}
+
+			if err := logger.FlushSync(context.Background()); err != nil {
+				t.Fatalf("FlushSync() error = %v", err)
+			}
+
+			entries := captured.all()
+			if len(entries) != 1 {
+				t.Fatalf("Expected 1 log entry, got %d", len(entries))
+			}
+			if entries[0].Level != tt.level {
+				t.Errorf("Level = %s, want %s", entries[0].Level, tt.level)
+			}
+			if entries[0].Fields["grpc.code"] != tt.code {
+				t.Errorf("grpc.code = %v, want %s", entries[0].Fields["grpc.code"], tt.code)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_IncludeCaller(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{IncludeCaller: true})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	UnaryServerInterceptor(logger)(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if function, _ := entries[0].Fields[callsite.FunctionField].(string); !strings.Contains(function, ".UnaryServerInterceptor.") {
+		t.Errorf("%s = %q, want the interceptor", callsite.FunctionField, function)
+	}
+}
+
+type fakeServerStream struct {
+	ctx context.Context
+}
+
+func (s *fakeServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *fakeServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *fakeServerStream) SetTrailer(metadata.MD)       {}
+func (s *fakeServerStream) Context() context.Context     { return s.ctx }
+func (s *fakeServerStream) SendMsg(any) error            { return nil }
+func (s *fakeServerStream) RecvMsg(any) error            { return nil }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	logger, captured := newTestLogger(t)
+	interceptor := StreamServerInterceptor(logger)
+
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch", IsServerStream: true}
+	stream := &fakeServerStream{ctx: context.Background()}
+
+	var handlerLogger *core.LogBullLogger
+	err := interceptor(nil, stream, info, func(srv any, stream grpc.ServerStream) error {
+		handlerLogger = core.LoggerFromContext(stream.Context())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if handlerLogger == nil {
+		t.Error("Expected a logger in the stream context")
+	}
+
+	if err := logger.FlushSync(context.Background()); err != nil {
+		t.Fatalf("FlushSync() error = %v", err)
+	}
+
+	entries := captured.all()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if entries[0].Fields["grpc.type"] != "stream" {
+		t.Errorf("grpc.type = %v, want stream", entries[0].Fields["grpc.type"])
+	}
+}
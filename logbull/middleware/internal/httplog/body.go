@@ -0,0 +1,254 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+const (
+	RequestBodyField  = "http.request_body"
+	ResponseBodyField = "http.response_body"
+
+	defaultMaxBodyBytes = 4 << 10
+
+	formMediaType = "application/x-www-form-urlencoded"
+
+	redactedValue = "[REDACTED]"
+	// omittedJSON replaces JSON bodies that cannot be parsed, and so cannot
+	// be redacted, because they are invalid or longer than MaxBytes.
+	omittedJSON = "[omitted: JSON body too long or invalid to redact]"
+)
+
+var (
+	// defaultBodyContentTypes are the types RedactKeys applies to; others,
+	// such as XML or text, would be logged unredacted, so they are opt-in
+	defaultBodyContentTypes = []string{
+		"application/json",
+		"application/*+json",
+		formMediaType,
+	}
+
+	defaultRedactKeys = []string{
+		"password",
+		"secret",
+		"token",
+		"access_token",
+		"refresh_token",
+		"api_key",
+		"apikey",
+		"authorization",
+		"card_number",
+		"cvv",
+	}
+)
+
+// BodyConfig enables capturing request and response bodies as log fields,
+// e.g. to debug API issues in staging. Bodies are buffered in memory, so
+// keep MaxBytes small and avoid enabling it in production.
+type BodyConfig struct {
+	// Request and Response attach the bodies as the http.request_body and
+	// http.response_body fields.
+	Request  bool
+	Response bool
+
+	// ContentTypes lists the media types whose bodies are captured, with
+	// path.Match wildcards such as "text/*" (default: JSON and form types,
+	// the ones RedactKeys applies to). Bodies of other types are logged as
+	// they are.
+	ContentTypes []string
+
+	// MaxBytes caps each captured body; longer text bodies are cut and end
+	// with "...[truncated]" (default: 4 KiB).
+	MaxBytes int
+
+	// RedactKeys lists JSON object keys, matched case-insensitively at any
+	// depth, and form fields whose values are replaced with "[REDACTED]".
+	// JSON bodies that cannot be parsed, including those longer than
+	// MaxBytes, are omitted (default: password, secret, token, access_token,
+	// refresh_token, api_key, apikey, authorization, card_number and cvv).
+	RedactKeys []string
+}
+
+// Enabled reports whether any body is captured.
+func (c BodyConfig) Enabled() bool {
+	return c.Request || c.Response
+}
+
+// Limit returns how many bytes of a body to buffer: one more than MaxBytes,
+// so that longer bodies can be told apart.
+func (c BodyConfig) Limit() int {
+	return c.maxBytes() + 1
+}
+
+func (c BodyConfig) maxBytes() int {
+	if c.MaxBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return c.MaxBytes
+}
+
+// Captures reports whether bodies of contentType are captured.
+func (c BodyConfig) Captures(contentType string) bool {
+	mediaType := mediaType(contentType)
+	if mediaType == "" {
+		return false
+	}
+
+	patterns := c.ContentTypes
+	if patterns == nil {
+		patterns = defaultBodyContentTypes
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the fields for the captured request and response bodies,
+// which hold at most Limit bytes each. Bodies that are empty, not enabled or
+// of other content types are skipped.
+func (c BodyConfig) Fields(requestType string, request []byte, responseType string, response []byte) map[string]any {
+	fields := make(map[string]any, 2)
+	if c.Request && len(request) > 0 && c.Captures(requestType) {
+		fields[RequestBodyField] = c.value(requestType, request)
+	}
+	if c.Response && len(response) > 0 && c.Captures(responseType) {
+		fields[ResponseBodyField] = c.value(responseType, response)
+	}
+	return fields
+}
+
+func (c BodyConfig) value(contentType string, body []byte) string {
+	mediaType := mediaType(contentType)
+	if mediaType == formMediaType {
+		value, _ := formatting.TruncateString(redactForm(string(body), c.redactKeys()), c.maxBytes())
+		return value
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		value, _ := formatting.TruncateString(string(body), c.maxBytes())
+		return value
+	}
+
+	if len(body) > c.maxBytes() {
+		return omittedJSON
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return omittedJSON
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return omittedJSON
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redact(value, c.redactKeys())); err != nil {
+		return omittedJSON
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redactKeys returns RedactKeys lowercased.
+func (c BodyConfig) redactKeys() map[string]bool {
+	keys := c.RedactKeys
+	if keys == nil {
+		keys = defaultRedactKeys
+	}
+	redactKeys := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redactKeys[strings.ToLower(key)] = true
+	}
+	return redactKeys
+}
+
+// redactForm replaces the values of keys in a form-encoded body, keeping
+// the other pairs as sent. A body cut at MaxBytes still has every complete
+// key redacted.
+func redactForm(body string, keys map[string]bool) string {
+	pairs := strings.Split(body, "&")
+	for i, pair := range pairs {
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if keys[strings.ToLower(key)] {
+			pairs[i] = rawKey + "=" + redactedValue
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func redact(value any, keys map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if keys[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redact(nested, keys)
+			}
+		}
+	case []any:
+		for i, nested := range v {
+			v[i] = redact(nested, keys)
+		}
+	}
+	return value
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// PeekBody reads up to limit bytes of body, returning them with a body that
+// still yields everything from the start.
+func PeekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	peeked, _ := io.ReadAll(io.LimitReader(body, int64(limit)))
+	return peeked, readCloser{io.MultiReader(bytes.NewReader(peeked), body), body}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// BodyBuffer keeps the first bytes written to it, up to a limit, and
+// discards the rest.
+type BodyBuffer struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func NewBodyBuffer(limit int) *BodyBuffer {
+	return &BodyBuffer{limit: limit}
+}
+
+// Write never fails, so the buffer can be used with io.TeeReader.
+func (b *BodyBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (b *BodyBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
@@ -0,0 +1,128 @@
+package httplog
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBodyConfig_Captures(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"application/problem+json", true},
+		{"application/x-www-form-urlencoded", true},
+		{"Text/Plain", false},
+		{"application/xml", false},
+		{"application/octet-stream", false},
+		{"image/png", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := (BodyConfig{}).Captures(tt.contentType); got != tt.want {
+			t.Errorf("Captures(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+
+	if !(BodyConfig{ContentTypes: []string{"text/*"}}).Captures("Text/Plain") {
+		t.Error("Captures() should accept a configured content type")
+	}
+}
+
+func TestBodyConfig_Fields(t *testing.T) {
+	t.Run("redacts JSON keys at any depth", func(t *testing.T) {
+		config := BodyConfig{Request: true, Response: true}
+		request := []byte(`{"user":"ann","Password":"hunter2","card":{"cvv":123,"last4":"4242"},"items":[{"token":"t"}]}`)
+
+		fields := config.Fields("application/json", request, "application/json", []byte(`{"id":7}`))
+
+		want := `{"Password":"[REDACTED]","card":{"cvv":"[REDACTED]","last4":"4242"},"items":[{"token":"[REDACTED]"}],"user":"ann"}`
+		if fields[RequestBodyField] != want {
+			t.Errorf("%s = %v, want %s", RequestBodyField, fields[RequestBodyField], want)
+		}
+		if fields[ResponseBodyField] != `{"id":7}` {
+			t.Errorf("%s = %v", ResponseBodyField, fields[ResponseBodyField])
+		}
+	})
+
+	t.Run("custom redact keys", func(t *testing.T) {
+		config := BodyConfig{Request: true, RedactKeys: []string{"ssn"}}
+
+		fields := config.Fields("application/json", []byte(`{"ssn":"123","password":"p"}`), "", nil)
+
+		if want := `{"password":"p","ssn":"[REDACTED]"}`; fields[RequestBodyField] != want {
+			t.Errorf("%s = %v, want %s", RequestBodyField, fields[RequestBodyField], want)
+		}
+	})
+
+	t.Run("redacts form fields", func(t *testing.T) {
+		config := BodyConfig{Request: true}
+
+		fields := config.Fields("application/x-www-form-urlencoded", []byte("user=ann&Password=hunter2&api%5Fkey=k&token"), "", nil)
+
+		if want := "user=ann&Password=[REDACTED]&api%5Fkey=[REDACTED]&token=[REDACTED]"; fields[RequestBodyField] != want {
+			t.Errorf("%s = %v, want %s", RequestBodyField, fields[RequestBodyField], want)
+		}
+	})
+
+	t.Run("cuts long text bodies", func(t *testing.T) {
+		config := BodyConfig{Response: true, MaxBytes: 20, ContentTypes: []string{"text/plain"}}
+		body := strings.Repeat("a", config.Limit())
+
+		fields := config.Fields("", nil, "text/plain", []byte(body))
+
+		value, _ := fields[ResponseBodyField].(string)
+		if len(value) != 20 || !strings.HasSuffix(value, "...[truncated]") {
+			t.Errorf("%s = %q, want 20 bytes ending with the truncation marker", ResponseBodyField, value)
+		}
+	})
+
+	t.Run("omits JSON that cannot be redacted", func(t *testing.T) {
+		config := BodyConfig{Request: true, MaxBytes: 10}
+
+		for _, body := range []string{`{"password":"hunter2"}`, `{"a":`, `{} {}`} {
+			fields := config.Fields("application/json", []byte(body), "", nil)
+			if fields[RequestBodyField] != omittedJSON {
+				t.Errorf("Body %s: %s = %v, want it omitted", body, RequestBodyField, fields[RequestBodyField])
+			}
+		}
+	})
+
+	t.Run("skips disabled, empty and other bodies", func(t *testing.T) {
+		config := BodyConfig{Response: true}
+
+		fields := config.Fields("text/plain", []byte("request"), "image/png", []byte("png"))
+		if len(fields) != 0 {
+			t.Errorf("Fields() = %v, want none", fields)
+		}
+	})
+}
+
+func TestPeekBody(t *testing.T) {
+	body := io.NopCloser(strings.NewReader("hello world"))
+
+	peeked, rest := PeekBody(body, 5)
+	if string(peeked) != "hello" {
+		t.Errorf("PeekBody() = %q, want hello", peeked)
+	}
+	if all, _ := io.ReadAll(rest); string(all) != "hello world" {
+		t.Errorf("Remaining body = %q, want the whole body", all)
+	}
+}
+
+func TestBodyBuffer(t *testing.T) {
+	buf := NewBodyBuffer(4)
+	if n, err := buf.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if n, err := buf.Write([]byte("def")); n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if string(buf.Bytes()) != "abcd" {
+		t.Errorf("Bytes() = %q, want abcd", buf.Bytes())
+	}
+}
@@ -0,0 +1,71 @@
+package httplog
+
+import (
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type Request struct {
+	Method    string
+	Path      string
+	Route     string
+	RemoteIP  string
+	UserAgent string
+	RequestID string
+}
+
+func (r Request) Fields() map[string]any {
+	fields := map[string]any{
+		"http.method": r.Method,
+		"http.path":   r.Path,
+	}
+
+	if r.Route != "" {
+		fields["http.route"] = r.Route
+	}
+	if r.RemoteIP != "" {
+		fields["http.remote_ip"] = r.RemoteIP
+	}
+	if r.UserAgent != "" {
+		fields["http.user_agent"] = r.UserAgent
+	}
+	if r.RequestID != "" {
+		fields[core.RequestIDField] = r.RequestID
+	}
+
+	return fields
+}
+
+func LogCompleted(logger *core.LogBullLogger, status int, size int64, start time.Time, err error) {
+	fields := map[string]any{
+		"http.status":    status,
+		"http.bytes_out": size,
+		"duration_ms":    float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	switch LevelForStatus(status) {
+	case core.ERROR:
+		logger.Error("HTTP request failed", fields)
+	case core.WARNING:
+		logger.Warning("HTTP request finished with client error", fields)
+	default:
+		logger.Info("HTTP request finished", fields)
+	}
+}
+
+func LevelForStatus(status int) core.LogLevel {
+	switch {
+	case status >= 500:
+		return core.ERROR
+	case status >= 400:
+		return core.WARNING
+	default:
+		return core.INFO
+	}
+}
@@ -0,0 +1,61 @@
+package httplog
+
+import (
+	"testing"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+func TestRequest_Fields(t *testing.T) {
+	t.Run("all fields", func(t *testing.T) {
+		fields := Request{
+			Method:    "POST",
+			Path:      "/users/42",
+			Route:     "/users/:id",
+			RemoteIP:  "10.0.0.1",
+			UserAgent: "curl/8.0",
+			RequestID: "req-1",
+		}.Fields()
+
+		expected := map[string]any{
+			"http.method":     "POST",
+			"http.path":       "/users/42",
+			"http.route":      "/users/:id",
+			"http.remote_ip":  "10.0.0.1",
+			"http.user_agent": "curl/8.0",
+			"request_id":      "req-1",
+		}
+		for key, value := range expected {
+			if fields[key] != value {
+				t.Errorf("Fields()[%s] = %v, want %v", key, fields[key], value)
+			}
+		}
+	})
+
+	t.Run("omits empty optional fields", func(t *testing.T) {
+		fields := Request{Method: "GET", Path: "/"}.Fields()
+		if len(fields) != 2 {
+			t.Errorf("Fields() = %v, want only method and path", fields)
+		}
+	})
+}
+
+func TestLevelForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   core.LogLevel
+	}{
+		{200, core.INFO},
+		{304, core.INFO},
+		{404, core.WARNING},
+		{499, core.WARNING},
+		{500, core.ERROR},
+		{503, core.ERROR},
+	}
+
+	for _, tt := range tests {
+		if got := LevelForStatus(tt.status); got != tt.want {
+			t.Errorf("LevelForStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package logbullnats provides a core.Transport that publishes log batches
+// to a NATS subject instead of sending them to LogBull over HTTP, for
+// architectures that funnel logs through a message bus before a consumer
+// forwards them to LogBull.
+package logbullnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	// Headers of every message, next to core.BatchIDHeader.
+	ContentTypeHeader = "Content-Type"
+	ProjectIDHeader   = "X-Project-ID"
+)
+
+type Config struct {
+	// URL of the NATS servers, comma-separated (default: nats.DefaultURL).
+	URL     string
+	Subject string
+
+	// ProjectID is sent as the X-Project-ID header, so consumers know which
+	// project to forward a batch to.
+	ProjectID string
+
+	// Encoder encodes each batch into one message (default:
+	// core.JSONEncoder).
+	Encoder core.Encoder
+
+	// Conn publishes the messages instead of a connection to URL, e.g. to
+	// configure TLS or credentials. The transport does not close it.
+	Conn *nats.Conn
+
+	// JetStream publishes to a JetStream stream and waits for its
+	// acknowledgement, so batches survive consumer restarts. Otherwise Send
+	// only waits until the server received the batch.
+	JetStream bool
+
+	// Timeout bounds each publish (default: 10s).
+	Timeout time.Duration
+}
+
+// Transport publishes each batch as one message.
+type Transport struct {
+	conn *nats.Conn
+	// owned reports that Close closes conn
+	owned     bool
+	subject   string
+	projectID string
+	encoder   core.Encoder
+	timeout   time.Duration
+
+	// publish sends msg, waiting for the acknowledgement the config asks for
+	publish func(ctx context.Context, msg *nats.Msg) error
+}
+
+func NewTransport(config Config) (*Transport, error) {
+	config.Subject = strings.TrimSpace(config.Subject)
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+
+	if config.Subject == "" {
+		return nil, errors.New("subject is required")
+	}
+	if config.ProjectID != "" {
+		if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	if config.Timeout < 0 {
+		return nil, fmt.Errorf("invalid timeout %v", config.Timeout)
+	}
+
+	t := &Transport{
+		conn:      config.Conn,
+		subject:   config.Subject,
+		projectID: config.ProjectID,
+		encoder:   config.Encoder,
+		timeout:   config.Timeout,
+	}
+	if t.encoder == nil {
+		t.encoder = core.JSONEncoder
+	}
+	if t.timeout == 0 {
+		t.timeout = defaultTimeout
+	}
+
+	if t.conn == nil {
+		url := config.URL
+		if url == "" {
+			url = nats.DefaultURL
+		}
+		conn, err := nats.Connect(url, nats.Name("LogBull-Go-Client"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		t.conn = conn
+		t.owned = true
+	}
+
+	if config.JetStream {
+		js, err := t.conn.JetStream()
+		if err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to open JetStream: %w", err)
+		}
+		t.publish = func(ctx context.Context, msg *nats.Msg) error {
+			_, err := js.PublishMsg(msg, nats.Context(ctx))
+			return err
+		}
+	} else {
+		t.publish = func(ctx context.Context, msg *nats.Msg) error {
+			if err := t.conn.PublishMsg(msg); err != nil {
+				return err
+			}
+			return t.conn.FlushWithContext(ctx)
+		}
+	}
+
+	return t, nil
+}
+
+// Send publishes logs as one message. Batches larger than the server's max
+// payload (1 MB by default) fail, so keep Config.MaxBatchBytes below it.
+func (t *Transport) Send(ctx context.Context, logs []core.LogEntry) error {
+	data, err := t.encoder.Encode(core.LogBatch{Logs: logs})
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	msg := nats.NewMsg(t.subject)
+	msg.Data = data
+	msg.Header.Set(ContentTypeHeader, t.encoder.ContentType())
+	if batchID := core.BatchIDFromContext(ctx); batchID != "" {
+		msg.Header.Set(core.BatchIDHeader, batchID)
+	}
+	if t.projectID != "" {
+		msg.Header.Set(ProjectIDHeader, t.projectID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	if err := t.publish(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish batch: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection opened for Config.URL. Call it after the
+// logger using the transport is shut down.
+func (t *Transport) Close() error {
+	if t.owned {
+		t.conn.Close()
+	}
+	return nil
+}
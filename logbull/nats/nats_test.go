@@ -0,0 +1,83 @@
+package logbullnats
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+func TestTransport(t *testing.T) {
+	var published []*nats.Msg
+	transport := &Transport{
+		subject:   "logs.app",
+		projectID: "12345678-1234-1234-1234-123456789012",
+		encoder:   core.MsgPackEncoder,
+		timeout:   defaultTimeout,
+		publish: func(ctx context.Context, msg *nats.Msg) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("Expected publish to have a deadline")
+			}
+			published = append(published, msg)
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	logs := []core.LogEntry{{Level: "INFO", Message: "first"}, {Level: "WARNING", Message: "second"}}
+	if err := transport.Send(ctx, logs); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("Published %d messages, want one per batch", len(published))
+	}
+	msg := published[0]
+	if msg.Subject != "logs.app" {
+		t.Errorf("Subject = %q, want logs.app", msg.Subject)
+	}
+	if msg.Header.Get(ContentTypeHeader) != "application/msgpack" || msg.Header.Get(ProjectIDHeader) != transport.projectID {
+		t.Errorf("Headers = %v", msg.Header)
+	}
+
+	var batch core.LogBatch
+	decoder := msgpack.NewDecoder(bytes.NewReader(msg.Data))
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Logs) != 2 || batch.Logs[1].Message != "second" {
+		t.Errorf("Batch = %+v", batch)
+	}
+}
+
+func TestTransport_Error(t *testing.T) {
+	transport := &Transport{
+		subject: "logs",
+		encoder: core.JSONEncoder,
+		timeout: defaultTimeout,
+		publish: func(context.Context, *nats.Msg) error { return nats.ErrMaxPayload },
+	}
+
+	err := transport.Send(context.Background(), []core.LogEntry{{Level: "INFO", Message: "lost"}})
+	if !errors.Is(err, nats.ErrMaxPayload) {
+		t.Errorf("Send() error = %v, want the publish error", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	if _, err := NewTransport(Config{}); err == nil {
+		t.Error("NewTransport() expected error without a subject")
+	}
+	if _, err := NewTransport(Config{Subject: "logs", ProjectID: "x"}); err == nil {
+		t.Error("NewTransport() expected error for an invalid project ID")
+	}
+	if _, err := NewTransport(Config{Subject: "logs", URL: "nats://127.0.0.1:1"}); err == nil {
+		t.Error("NewTransport() expected error when no server is reachable")
+	}
+}
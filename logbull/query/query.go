@@ -0,0 +1,207 @@
+// Package logbullquery is a client for the LogBull search API, so tools and
+// tests can check that logs arrived without hand-rolling HTTP calls.
+package logbullquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// pollInterval is how often WaitFor repeats a query that found nothing.
+	pollInterval = 500 * time.Millisecond
+)
+
+type Config struct {
+	Host      string
+	ProjectID string
+	// APIKey is required when the project restricts reads.
+	APIKey string
+
+	// HTTPClient sends the requests (default: a client with a 30s timeout).
+	HTTPClient *http.Client
+}
+
+// Query selects logs. Zero fields do not filter.
+type Query struct {
+	Levels []core.LogLevel
+	// From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+	// Fields match logs whose fields have all these values.
+	Fields map[string]any
+	// Message matches logs whose message contains it.
+	Message string
+
+	// Limit caps the logs of one page; the server applies its own default
+	// and maximum.
+	Limit int
+	// Cursor continues from Result.NextCursor.
+	Cursor string
+}
+
+// Result is one page of logs, oldest first.
+type Result struct {
+	Logs []core.LogEntry `json:"logs"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+type queryRequest struct {
+	Levels  []core.LogLevel `json:"levels,omitempty"`
+	From    string          `json:"from,omitempty"`
+	To      string          `json:"to,omitempty"`
+	Fields  map[string]any  `json:"fields,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Limit   int             `json:"limit,omitempty"`
+	Cursor  string          `json:"cursor,omitempty"`
+}
+
+func NewClient(config Config) (*Client, error) {
+	config.Host = strings.TrimRight(strings.TrimSpace(config.Host), "/")
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+	config.APIKey = strings.TrimSpace(config.APIKey)
+
+	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateHostURL(config.Host); err != nil {
+		return nil, err
+	}
+
+	if config.APIKey != "" {
+		if err := validation.ValidateAPIKey(config.APIKey); err != nil {
+			return nil, err
+		}
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &Client{config: config, client: client}, nil
+}
+
+// Search returns one page of the logs matching q.
+func (c *Client) Search(ctx context.Context, q Query) (Result, error) {
+	for _, level := range q.Levels {
+		if level.Priority() == 0 {
+			return Result{}, fmt.Errorf("invalid level '%s'", level)
+		}
+	}
+
+	body, err := json.Marshal(queryRequest{
+		Levels:  q.Levels,
+		From:    formatTime(q.From),
+		To:      formatTime(q.To),
+		Fields:  q.Fields,
+		Message: q.Message,
+		Limit:   q.Limit,
+		Cursor:  q.Cursor,
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/logs/query/%s", c.config.Host, c.config.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "LogBull-Go-Client/1.0")
+	if c.config.APIKey != "" {
+		req.Header.Set("X-API-Key", c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return Result{}, fmt.Errorf("%w (status %d: %s)", core.ErrUnauthorized, resp.StatusCode, string(data))
+	case resp.StatusCode != http.StatusOK:
+		return Result{}, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Result{}, fmt.Errorf("invalid query response: %w", err)
+	}
+	return result, nil
+}
+
+// All follows the cursors of q and returns every matching log.
+func (c *Client) All(ctx context.Context, q Query) ([]core.LogEntry, error) {
+	var logs []core.LogEntry
+	for {
+		result, err := c.Search(ctx, q)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, result.Logs...)
+
+		if result.NextCursor == "" {
+			return logs, nil
+		}
+		q.Cursor = result.NextCursor
+	}
+}
+
+// WaitFor repeats q until it matches at least one log, or until ctx is done.
+// Logs take a moment to become searchable after they are sent, so tests
+// should use it instead of a single Search.
+func (c *Client) WaitFor(ctx context.Context, q Query) ([]core.LogEntry, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := c.Search(ctx, q)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if len(result.Logs) > 0 {
+			return result.Logs, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,172 @@
+package logbullquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+const testProjectID = "12345678-1234-1234-1234-123456789012"
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"valid", Config{Host: "http://localhost:4005", ProjectID: testProjectID}, false},
+		{"invalid project ID", Config{Host: "http://localhost:4005", ProjectID: "project"}, true},
+		{"invalid host", Config{Host: "localhost", ProjectID: testProjectID}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Search(t *testing.T) {
+	var request queryRequest
+	var path, apiKey string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		apiKey = r.Header.Get("X-API-Key")
+		json.NewDecoder(r.Body).Decode(&request)
+
+		json.NewEncoder(w).Encode(Result{
+			Logs:       []core.LogEntry{{Level: "ERROR", Message: "payment failed", Fields: map[string]any{"order_id": "o1"}}},
+			NextCursor: "c2",
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID, APIKey: "read-api-key"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	result, err := client.Search(context.Background(), Query{
+		Levels: []core.LogLevel{core.ERROR, core.CRITICAL},
+		From:   from,
+		Fields: map[string]any{"order_id": "o1"},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if path != "/api/v1/logs/query/"+testProjectID || apiKey != "read-api-key" {
+		t.Errorf("Path = %s, X-API-Key = %q", path, apiKey)
+	}
+	if len(request.Levels) != 2 || request.From != "2024-03-01T12:00:00Z" || request.To != "" ||
+		request.Fields["order_id"] != "o1" || request.Limit != 10 {
+		t.Errorf("Request = %+v", request)
+	}
+	if len(result.Logs) != 1 || result.Logs[0].Message != "payment failed" || result.NextCursor != "c2" {
+		t.Errorf("Result = %+v", result)
+	}
+}
+
+func TestClient_SearchErrors(t *testing.T) {
+	status := http.StatusForbidden
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":"denied"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.Search(context.Background(), Query{}); !errors.Is(err, core.ErrUnauthorized) {
+		t.Errorf("Search() error = %v, want ErrUnauthorized", err)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := client.Search(context.Background(), Query{}); err == nil || errors.Is(err, core.ErrUnauthorized) {
+		t.Errorf("Search() error = %v, want a status error", err)
+	}
+
+	if _, err := client.Search(context.Background(), Query{Levels: []core.LogLevel{"VERBOSE"}}); err == nil {
+		t.Error("Search() expected error for an invalid level")
+	}
+}
+
+func TestClient_All(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request queryRequest
+		json.NewDecoder(r.Body).Decode(&request)
+
+		switch request.Cursor {
+		case "":
+			json.NewEncoder(w).Encode(Result{Logs: []core.LogEntry{{Message: "first"}}, NextCursor: "page-2"})
+		case "page-2":
+			json.NewEncoder(w).Encode(Result{Logs: []core.LogEntry{{Message: "second"}}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	logs, err := client.All(context.Background(), Query{})
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if len(logs) != 2 || logs[0].Message != "first" || logs[1].Message != "second" {
+		t.Errorf("All() = %+v", logs)
+	}
+}
+
+func TestClient_WaitFor(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 2 {
+			json.NewEncoder(w).Encode(Result{})
+			return
+		}
+		json.NewEncoder(w).Encode(Result{Logs: []core.LogEntry{{Message: "arrived"}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logs, err := client.WaitFor(ctx, Query{Message: "arrived"})
+	if err != nil {
+		t.Fatalf("WaitFor() error = %v", err)
+	}
+	if len(logs) != 1 || calls.Load() != 2 {
+		t.Errorf("WaitFor() = %+v after %d queries", logs, calls.Load())
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.WaitFor(ctx, Query{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitFor() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,328 @@
+package logbullsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+)
+
+// Open opens a database like sql.Open, logging the queries sent through the
+// registered driver driverName.
+func Open(driverName, dataSourceName string, logger *core.LogBullLogger, config Config) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	connector, err := Wrap(d, logger, config).(driver.DriverContext).OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// Wrap returns a driver that logs the queries sent through d, e.g. for
+// sql.Register.
+func Wrap(d driver.Driver, logger *core.LogBullLogger, config Config) driver.Driver {
+	return &loggedDriver{Driver: d, hooks: NewHooks(logger, config)}
+}
+
+// WrapConnector returns a connector that logs the queries sent through c,
+// for sql.OpenDB.
+func WrapConnector(c driver.Connector, logger *core.LogBullLogger, config Config) driver.Connector {
+	d := &loggedDriver{Driver: c.Driver(), hooks: NewHooks(logger, config)}
+	return &loggedConnector{connector: c, driver: d}
+}
+
+type loggedDriver struct {
+	driver.Driver
+	hooks *Hooks
+}
+
+func (d *loggedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{conn: conn, hooks: d.hooks}, nil
+}
+
+func (d *loggedDriver) OpenConnector(name string) (driver.Connector, error) {
+	dc, ok := d.Driver.(driver.DriverContext)
+	if !ok {
+		return &dsnConnector{name: name, driver: d}, nil
+	}
+	c, err := dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConnector{connector: c, driver: d}, nil
+}
+
+type loggedConnector struct {
+	connector driver.Connector
+	driver    *loggedDriver
+}
+
+func (c *loggedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{conn: conn, hooks: c.driver.hooks}, nil
+}
+
+func (c *loggedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close lets sql.DB.Close release the resources of the wrapped connector.
+func (c *loggedConnector) Close() error {
+	if closer, ok := c.connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector connects through drivers that are not a
+// driver.DriverContext, like database/sql does.
+type dsnConnector struct {
+	name   string
+	driver *loggedDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// loggedConn logs the queries of conn. Optional interfaces conn lacks are
+// answered the way database/sql falls back without them, e.g. ErrSkip for
+// ExecContext, so statements are then prepared and logged by loggedStmt.
+type loggedConn struct {
+	conn  driver.Conn
+	hooks *Hooks
+}
+
+func (c *loggedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{stmt: stmt, query: query, hooks: c.hooks}, nil
+}
+
+func (c *loggedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.conn.(driver.ConnPrepareContext)
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Prepare(query)
+	}
+
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{stmt: stmt, query: query, hooks: c.hooks}, nil
+}
+
+func (c *loggedConn) Close() error {
+	return c.conn.Close()
+}
+
+// Begin is required by driver.Conn; database/sql calls BeginTx.
+func (c *loggedConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *loggedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.conn.Begin()
+}
+
+func (c *loggedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	c.hooks.log(ctx, start, query, argValues(args), rowsAffected(result, err), err)
+	return result, err
+}
+
+func (c *loggedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	c.hooks.log(ctx, start, query, argValues(args), -1, err)
+	return rows, err
+}
+
+func (c *loggedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *loggedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type loggedStmt struct {
+	stmt  driver.Stmt
+	query string
+	hooks *Hooks
+}
+
+func (s *loggedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *loggedStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+// Exec is required by driver.Stmt; database/sql calls ExecContext.
+func (s *loggedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.stmt.Exec(args)
+}
+
+// Query is required by driver.Stmt; database/sql calls QueryContext.
+func (s *loggedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.stmt.Query(args)
+}
+
+func (s *loggedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+
+	var result driver.Result
+	var err error
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = positionalValues(ctx, args); err == nil {
+			result, err = s.stmt.Exec(values)
+		}
+	}
+
+	s.hooks.log(ctx, start, s.query, argValues(args), rowsAffected(result, err), err)
+	return result, err
+}
+
+func (s *loggedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = positionalValues(ctx, args); err == nil {
+			rows, err = s.stmt.Query(values)
+		}
+	}
+
+	s.hooks.log(ctx, start, s.query, argValues(args), -1, err)
+	return rows, err
+}
+
+func (s *loggedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// positionalValues converts args for the pre-context Stmt methods, which
+// database/sql also only calls with a live ctx and without named args.
+func positionalValues(ctx context.Context, args []driver.NamedValue) ([]driver.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+func argValues(args []driver.NamedValue) []any {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// rowsAffected returns -1 when the driver does not know.
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package logbullsql
+
+import "strings"
+
+// Sanitize replaces the string and number literals of query with '?', drops
+// comments and collapses whitespace, so statements that differ only in
+// their values log the same and values typed into the SQL are not logged.
+// Placeholders such as $1 and quoted identifiers are kept.
+func Sanitize(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	space := false
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case isSpace(c):
+			space = true
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+			space = true
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+			space = true
+			continue
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		switch {
+		case c == '\'':
+			i = skipQuoted(query, i)
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			end := skipQuoted(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case isDigit(c) && !continuesWord(b.String()):
+			for i < len(query) && (isWordByte(query[i]) || query[i] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipQuoted returns the index after the quoted text starting at start,
+// treating a doubled quote as an escaped one.
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// continuesWord reports whether a digit written after out belongs to an
+// identifier or a placeholder, as in t1, $1, :p1 or @p1.
+func continuesWord(out string) bool {
+	if out == "" {
+		return false
+	}
+	c := out[len(out)-1]
+	return isWordByte(c) || c == '$' || c == ':' || c == '@' || c == '?'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// operation returns the first keyword of a sanitized statement, e.g. SELECT.
+func operation(statement string) string {
+	statement = strings.TrimLeft(statement, "( ")
+	end := strings.IndexFunc(statement, func(r rune) bool {
+		return r == ' ' || r == '('
+	})
+	if end >= 0 {
+		statement = statement[:end]
+	}
+	return strings.ToUpper(statement)
+}
@@ -0,0 +1,39 @@
+package logbullsql
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM t1 WHERE name = 'O''Brien' AND score > 1.5e3", "SELECT * FROM t1 WHERE name = ? AND score > ?"},
+		{"SELECT * FROM users WHERE id = $1 AND org = :org2 AND x = @p3", "SELECT * FROM users WHERE id = $1 AND org = :org2 AND x = @p3"},
+		{"INSERT INTO \"Users 2\" (`id`) VALUES (?, 7)", "INSERT INTO \"Users 2\" (`id`) VALUES (?, ?)"},
+		{"SELECT id\n\t FROM users -- active only\n WHERE active /* hint */ = true", "SELECT id FROM users WHERE active = true"},
+		{"SELECT 'unterminated", "SELECT ?"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Sanitize(tt.query); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestOperation(t *testing.T) {
+	tests := map[string]string{
+		"select id from users":        "SELECT",
+		"(SELECT 1) UNION (SELECT 2)": "SELECT",
+		"WITH x AS (SELECT 1) SELECT": "WITH",
+		"":                            "",
+	}
+
+	for statement, want := range tests {
+		if got := operation(statement); got != want {
+			t.Errorf("operation(%q) = %q, want %q", statement, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package logbullsql logs the queries of database/sql drivers through
+// LogBull, with their duration, rows affected and errors, so slow or failing
+// queries show up next to the application logs of the same request.
+//
+// Open and Wrap wrap a driver directly. Hooks plugs into hook-based driver
+// wrappers such as github.com/qustavo/sqlhooks instead.
+package logbullsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/formatting"
+)
+
+const defaultMaxStatementLength = 2048
+
+// Fields of the query logs, next to duration_ms and error.
+const (
+	StatementField    = "db.statement"
+	OperationField    = "db.operation"
+	SystemField       = "db.system"
+	RowsAffectedField = "db.rows_affected"
+	ArgsField         = "db.args"
+)
+
+type Config struct {
+	// System is logged as db.system, e.g. "postgresql".
+	System string
+
+	// Level of successful queries (default: DEBUG). Failed queries are
+	// logged as ERROR.
+	Level core.LogLevel
+
+	// SlowThreshold logs successful queries taking at least this long as
+	// WARNING (default: 0, disabled).
+	SlowThreshold time.Duration
+
+	// KeepLiterals logs statements as written instead of sanitized by
+	// Sanitize.
+	KeepLiterals bool
+
+	// LogArgs attaches the query arguments as db.args. They often hold
+	// personal data, so they are not logged by default.
+	LogArgs bool
+
+	// MaxStatementLength cuts longer statements (default: 2048 bytes).
+	MaxStatementLength int
+}
+
+// Hooks logs queries through logger. Its Before, After and OnError methods
+// match the hook interfaces of github.com/qustavo/sqlhooks.
+type Hooks struct {
+	logger *core.LogBullLogger
+	config Config
+}
+
+type startContextKey struct{}
+
+func NewHooks(logger *core.LogBullLogger, config Config) *Hooks {
+	if config.Level.Priority() == 0 {
+		config.Level = core.DEBUG
+	}
+	if config.MaxStatementLength <= 0 {
+		config.MaxStatementLength = defaultMaxStatementLength
+	}
+	return &Hooks{logger: logger, config: config}
+}
+
+// Before records the start of a query in the returned context.
+func (h *Hooks) Before(ctx context.Context, query string, args ...any) (context.Context, error) {
+	return context.WithValue(ctx, startContextKey{}, time.Now()), nil
+}
+
+// After logs a successful query started by Before.
+func (h *Hooks) After(ctx context.Context, query string, args ...any) (context.Context, error) {
+	h.log(ctx, startFromContext(ctx), query, args, -1, nil)
+	return ctx, nil
+}
+
+// OnError logs a failed query started by Before and returns err unchanged.
+func (h *Hooks) OnError(ctx context.Context, err error, query string, args ...any) error {
+	h.log(ctx, startFromContext(ctx), query, args, -1, err)
+	return err
+}
+
+func startFromContext(ctx context.Context) time.Time {
+	start, _ := ctx.Value(startContextKey{}).(time.Time)
+	return start
+}
+
+// log logs one query; rowsAffected is negative when unknown.
+func (h *Hooks) log(ctx context.Context, start time.Time, query string, args []any, rowsAffected int64, err error) {
+	var duration time.Duration
+	if !start.IsZero() {
+		duration = time.Since(start)
+	}
+
+	level := h.config.Level
+	message := "SQL query"
+	switch {
+	case err != nil:
+		level = core.ERROR
+		message = "SQL query failed"
+	case h.config.SlowThreshold > 0 && duration >= h.config.SlowThreshold:
+		level = core.WARNING
+		message = "Slow SQL query"
+	}
+	if level.Priority() < h.logger.Level().Priority() {
+		return
+	}
+
+	statement := query
+	if !h.config.KeepLiterals {
+		statement = Sanitize(query)
+	}
+
+	fields := map[string]any{
+		OperationField: operation(statement),
+		"duration_ms":  float64(duration.Microseconds()) / 1000,
+	}
+	fields[StatementField], _ = formatting.TruncateString(statement, h.config.MaxStatementLength)
+	if h.config.System != "" {
+		fields[SystemField] = h.config.System
+	}
+	if rowsAffected >= 0 {
+		fields[RowsAffectedField] = rowsAffected
+	}
+	if h.config.LogArgs && len(args) > 0 {
+		fields[ArgsField] = args
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	logger := h.logger.WithCtx(ctx)
+	switch level {
+	case core.DEBUG:
+		logger.Debug(message, fields)
+	case core.INFO:
+		logger.Info(message, fields)
+	case core.WARNING:
+		logger.Warning(message, fields)
+	case core.ERROR:
+		logger.Error(message, fields)
+	default:
+		logger.Critical(message, fields)
+	}
+}
@@ -0,0 +1,229 @@
+package logbullsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/callsite"
+	"github.com/logbull/logbull-go/logbull/logbulltest"
+)
+
+var errSyntax = errors.New("syntax error")
+
+// fakeDriver runs no SQL: statements containing "fail" return errSyntax,
+// and every other statement affects one row and returns no rows. With
+// legacy set, connections only support Prepare.
+type fakeDriver struct {
+	legacy bool
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	if d.legacy {
+		return legacyConn{}, nil
+	}
+	return fakeConn{}, nil
+}
+
+type legacyConn struct{}
+
+func (legacyConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (legacyConn) Close() error                              { return nil }
+func (legacyConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeConn struct {
+	legacyConn
+}
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return fakeStmt{query: query}.Exec(nil)
+}
+
+func (fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return fakeStmt{query: query}.Query(nil)
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errSyntax
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errSyntax
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("logbullsql-fake", fakeDriver{})
+}
+
+func TestOpen(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+	db, err := Open("logbullsql-fake", "", logger, Config{System: "fake"})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	ctx := core.ContextWithRequestID(context.Background(), "req-1")
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = 'ann' WHERE id = ?", 42); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != "DEBUG" || entry.Message != "SQL query" {
+		t.Errorf("Entry = %s %q, want DEBUG SQL query", entry.Level, entry.Message)
+	}
+	expected := map[string]any{
+		StatementField:    "UPDATE users SET name = ? WHERE id = ?",
+		OperationField:    "UPDATE",
+		SystemField:       "fake",
+		RowsAffectedField: int64(1),
+		"request_id":      "req-1",
+	}
+	for key, value := range expected {
+		if entry.Fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry.Fields[key], value)
+		}
+	}
+	if _, ok := entry.Fields["duration_ms"]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+	if _, ok := entry.Fields[ArgsField]; ok {
+		t.Error("Expected no db.args field without LogArgs")
+	}
+}
+
+func TestOpen_IncludeCaller(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{IncludeCaller: true})
+
+	db, err := Open("logbullsql-fake", "", logger, Config{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(context.Background(), "DELETE FROM sessions"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	if function, _ := entries[0].Fields[callsite.FunctionField].(string); !strings.HasSuffix(function, ".TestOpen_IncludeCaller") {
+		t.Errorf("%s = %q, want the function running the query", callsite.FunctionField, function)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		name := "context"
+		if legacy {
+			name = "prepared"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			logger, recorder := logbulltest.NewLogger(t, core.Config{})
+
+			connector, err := Wrap(fakeDriver{legacy: legacy}, logger, Config{LogArgs: true}).(driver.DriverContext).OpenConnector("")
+			if err != nil {
+				t.Fatalf("OpenConnector() error = %v", err)
+			}
+			db := sql.OpenDB(connector)
+			defer db.Close()
+
+			rows, err := db.Query("SELECT id FROM users WHERE email = ?", "ann@example.com")
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			rows.Close()
+
+			if _, err := db.Exec("fail"); !errors.Is(err, errSyntax) {
+				t.Errorf("Exec() error = %v, want errSyntax", err)
+			}
+
+			entries := recorder.Entries()
+			if len(entries) != 2 {
+				t.Fatalf("Expected 2 log entries, got %d", len(entries))
+			}
+
+			args, _ := entries[0].Fields[ArgsField].([]any)
+			if entries[0].Fields[OperationField] != "SELECT" || len(args) != 1 || args[0] != "ann@example.com" {
+				t.Errorf("Query entry fields = %v", entries[0].Fields)
+			}
+			if _, ok := entries[0].Fields[RowsAffectedField]; ok {
+				t.Error("Expected no db.rows_affected for a query")
+			}
+
+			if entries[1].Level != "ERROR" || entries[1].Fields["error"] != "syntax error" {
+				t.Errorf("Failed entry = %s %v, want ERROR with the error", entries[1].Level, entries[1].Fields)
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	logger, recorder := logbulltest.NewLogger(t, core.Config{LogLevel: core.INFO})
+	hooks := NewHooks(logger, Config{SlowThreshold: 10 * time.Millisecond})
+
+	ctx, _ := hooks.Before(context.Background(), "SELECT 1")
+	hooks.After(ctx, "SELECT 1")
+
+	ctx, _ = hooks.Before(context.Background(), "SELECT pg_sleep(1)")
+	time.Sleep(20 * time.Millisecond)
+	hooks.After(ctx, "SELECT pg_sleep(1)")
+
+	ctx, _ = hooks.Before(context.Background(), "SELEC 1")
+	if err := hooks.OnError(ctx, errSyntax, "SELEC 1"); err != errSyntax {
+		t.Errorf("OnError() = %v, want the error unchanged", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected the DEBUG entry to be filtered and 2 entries logged, got %d", len(entries))
+	}
+	if entries[0].Level != "WARNING" || entries[0].Message != "Slow SQL query" {
+		t.Errorf("Slow entry = %s %q", entries[0].Level, entries[0].Message)
+	}
+	if duration, _ := entries[0].Fields["duration_ms"].(float64); duration < 20 {
+		t.Errorf("duration_ms = %v, want at least 20", entries[0].Fields["duration_ms"])
+	}
+	if entries[1].Level != "ERROR" || entries[1].Fields[StatementField] != "SELEC ?" {
+		t.Errorf("Failed entry = %s %v", entries[1].Level, entries[1].Fields)
+	}
+}
@@ -0,0 +1,245 @@
+// Package logbulltail subscribes to LogBull's live-tail endpoint, which
+// streams a project's logs over a WebSocket as they arrive, e.g. for a CLI
+// tail or to assert in a test that a log was delivered.
+package logbulltail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/internal/validation"
+)
+
+const (
+	defaultHandshakeTimeout = 30 * time.Second
+
+	// bufferSize is how many logs a subscription holds before it stops
+	// reading from the server.
+	bufferSize = 256
+)
+
+// ErrTailDisabled is returned by Subscribe when the server has no live-tail
+// endpoint, because it is turned off or the release predates it.
+var ErrTailDisabled = errors.New("live tail is not enabled on the server")
+
+type Config struct {
+	Host      string
+	ProjectID string
+	// APIKey is required when the project restricts reads.
+	APIKey string
+
+	// Dialer opens the WebSockets (default: a dialer with a 30s handshake
+	// timeout that honors the proxy environment variables).
+	Dialer *websocket.Dialer
+}
+
+// Filter selects the logs to stream. Zero fields do not filter.
+type Filter struct {
+	Levels []core.LogLevel
+	// Fields match logs whose fields have all these values.
+	Fields map[string]any
+	// Message matches logs whose message contains it.
+	Message string
+}
+
+type Client struct {
+	config Config
+	dialer *websocket.Dialer
+	url    string
+}
+
+// subscribeRequest is the first message of a subscription.
+type subscribeRequest struct {
+	Levels  []core.LogLevel `json:"levels,omitempty"`
+	Fields  map[string]any  `json:"fields,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+func NewClient(config Config) (*Client, error) {
+	config.Host = strings.TrimRight(strings.TrimSpace(config.Host), "/")
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+	config.APIKey = strings.TrimSpace(config.APIKey)
+
+	if err := validation.ValidateProjectID(config.ProjectID); err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateHostURL(config.Host); err != nil {
+		return nil, err
+	}
+
+	if config.APIKey != "" {
+		if err := validation.ValidateAPIKey(config.APIKey); err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := config.Dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{Proxy: http.ProxyFromEnvironment, HandshakeTimeout: defaultHandshakeTimeout}
+	}
+
+	// ValidateHostURL only accepts http and https
+	url := "ws" + strings.TrimPrefix(config.Host, "http")
+	url = fmt.Sprintf("%s/api/v1/logs/tail/%s", url, config.ProjectID)
+
+	return &Client{config: config, dialer: dialer, url: url}, nil
+}
+
+// Subscribe streams the logs matching filter that arrive from now on, until
+// ctx is done or the subscription is closed.
+func (c *Client) Subscribe(ctx context.Context, filter Filter) (*Subscription, error) {
+	for _, level := range filter.Levels {
+		if level.Priority() == 0 {
+			return nil, fmt.Errorf("invalid level '%s'", level)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("User-Agent", "LogBull-Go-Client/1.0")
+	if c.config.APIKey != "" {
+		header.Set("X-API-Key", c.config.APIKey)
+	}
+
+	conn, resp, err := c.dialer.DialContext(ctx, c.url, header)
+	if err != nil {
+		if resp == nil {
+			return nil, err
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, fmt.Errorf("%w (status %d)", core.ErrUnauthorized, resp.StatusCode)
+		case http.StatusNotFound:
+			return nil, ErrTailDisabled
+		}
+		return nil, fmt.Errorf("server returned status %d: %w", resp.StatusCode, err)
+	}
+
+	request := subscribeRequest{Levels: filter.Levels, Fields: filter.Fields, Message: filter.Message}
+	if err := conn.WriteJSON(request); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	s := &Subscription{
+		conn:    conn,
+		logs:    make(chan core.LogEntry, bufferSize),
+		closing: make(chan struct{}),
+	}
+	go s.read()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.stop(ctx.Err())
+		case <-s.closing:
+		}
+	}()
+
+	return s, nil
+}
+
+// Subscription is one live stream of logs.
+type Subscription struct {
+	conn *websocket.Conn
+	logs chan core.LogEntry
+
+	closeOnce sync.Once
+	closing   chan struct{}
+
+	mu sync.Mutex
+	// err is why the stream ended; errClosed when Close ended it
+	err error
+}
+
+var errClosed = errors.New("subscription closed")
+
+// Logs returns the streamed logs, oldest first. The channel is closed when
+// the stream ends; Err then tells why.
+func (s *Subscription) Logs() <-chan core.LogEntry {
+	return s.logs
+}
+
+// Err returns why the stream ended: ctx.Err() when the context of Subscribe
+// is done, the connection error when it broke, and nil after Close or when
+// the server ended the stream normally.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if errors.Is(s.err, errClosed) {
+		return nil
+	}
+	return s.err
+}
+
+// Close ends the stream.
+func (s *Subscription) Close() error {
+	s.stop(errClosed)
+	return nil
+}
+
+// WaitFor returns the first streamed log match returns true for, or an error
+// when ctx is done or the stream ends first.
+func (s *Subscription) WaitFor(ctx context.Context, match func(entry core.LogEntry) bool) (core.LogEntry, error) {
+	for {
+		select {
+		case entry, ok := <-s.logs:
+			if !ok {
+				if err := s.Err(); err != nil {
+					return core.LogEntry{}, err
+				}
+				return core.LogEntry{}, errors.New("stream ended")
+			}
+			if match(entry) {
+				return entry, nil
+			}
+		case <-ctx.Done():
+			return core.LogEntry{}, ctx.Err()
+		}
+	}
+}
+
+// stop records err as the reason the stream ended, unless it already ended,
+// and closes the connection.
+func (s *Subscription) stop(err error) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.mu.Unlock()
+
+		deadline := time.Now().Add(time.Second)
+		_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+		close(s.closing)
+		s.conn.Close()
+	})
+}
+
+func (s *Subscription) read() {
+	defer close(s.logs)
+
+	for {
+		var entry core.LogEntry
+		if err := s.conn.ReadJSON(&entry); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				err = errClosed
+			}
+			s.stop(err)
+			return
+		}
+
+		select {
+		case s.logs <- entry:
+		case <-s.closing:
+			return
+		}
+	}
+}
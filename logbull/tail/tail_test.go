@@ -0,0 +1,119 @@
+package logbulltail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/logbull/logbull-go/logbull/core"
+	"github.com/logbull/logbull-go/logbull/integrationtest"
+)
+
+const testProjectID = integrationtest.DefaultProjectID
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(Config{Host: "http://localhost:4005", ProjectID: "not-a-uuid"}); err == nil {
+		t.Error("NewClient() expected error for an invalid project ID")
+	}
+
+	client, err := NewClient(Config{Host: "https://logs.example.com/", ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if want := "wss://logs.example.com/api/v1/logs/tail/" + testProjectID; client.url != want {
+		t.Errorf("url = %q, want %q", client.url, want)
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	server := integrationtest.NewServer(t, integrationtest.Config{APIKey: "test-api-key"})
+
+	config := server.LoggerConfig()
+	config.ConsoleFormat = core.ConsoleDisabled
+	logger, err := core.NewLogger(config)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Shutdown()
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID, APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := client.Subscribe(ctx, Filter{Levels: []core.LogLevel{core.ERROR}})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	logger.Info("cache warmed", nil)
+	logger.Error("payment failed", map[string]any{"order_id": "A-1"})
+	logger.Flush()
+
+	entry, err := sub.WaitFor(ctx, func(entry core.LogEntry) bool { return entry.Fields["order_id"] == "A-1" })
+	if err != nil {
+		t.Fatalf("WaitFor() error = %v", err)
+	}
+	if entry.Message != "payment failed" {
+		t.Errorf("Entry = %+v", entry)
+	}
+
+	sub.Close()
+	for range sub.Logs() {
+		t.Error("Got a log after the filtered ones")
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err() after Close = %v, want nil", err)
+	}
+}
+
+func TestClient_SubscribeContextDone(t *testing.T) {
+	server := integrationtest.NewServer(t, integrationtest.Config{})
+
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := client.Subscribe(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	cancel()
+
+	for range sub.Logs() {
+	}
+	if err := sub.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+}
+
+func TestClient_SubscribeErrors(t *testing.T) {
+	ctx := context.Background()
+
+	server := integrationtest.NewServer(t, integrationtest.Config{APIKey: "test-api-key"})
+	client, err := NewClient(Config{Host: server.URL, ProjectID: testProjectID, APIKey: "wrong-api-key"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.Subscribe(ctx, Filter{}); !errors.Is(err, core.ErrUnauthorized) {
+		t.Errorf("Subscribe() error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := client.Subscribe(ctx, Filter{Levels: []core.LogLevel{"VERBOSE"}}); err == nil {
+		t.Error("Subscribe() expected error for an invalid level")
+	}
+
+	disabled := integrationtest.NewServer(t, integrationtest.Config{DisableTail: true})
+	client, err = NewClient(Config{Host: disabled.URL, ProjectID: testProjectID})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.Subscribe(ctx, Filter{}); !errors.Is(err, ErrTailDisabled) {
+		t.Errorf("Subscribe() error = %v, want ErrTailDisabled", err)
+	}
+}